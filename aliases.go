@@ -0,0 +1,141 @@
+package hello
+
+import (
+	"sort"
+	"strings"
+
+	"github.com/pdeziel/grpc-example/catalog"
+	"google.golang.org/grpc/metadata"
+)
+
+// DeprecatedTrailer is the trailer metadata key set by the server when a request uses a
+// deprecated language code. Each value is the deprecated code and its replacement
+// separated by an equals sign, e.g. "iw=he".
+const DeprecatedTrailer = "hello-deprecated-language"
+
+// Language codes that were withdrawn from ISO 639-1 and replaced by another code.
+var deprecatedCodes = map[string]string{
+	"in": "id", "iw": "he", "ji": "yi", "jw": "jv", "mo": "ro",
+}
+
+// Alternative codes for languages: Norwegian (no) is served as Norwegian Bokmål (nb),
+// and the ISO 639-2 terminology and bibliographic codes and the ISO 639-3 codes of the
+// individual languages of macrolanguages are served as their ISO 639-1 code.
+var alternativeCodes = map[string]string{
+	"aar": "aa", "abk": "ab", "afr": "af", "aka": "ak", "alb": "sq", "amh": "am", "ara": "ar", "arb": "ar",
+	"arg": "an", "arm": "hy", "asm": "as", "ava": "av", "ave": "ae", "aym": "ay", "aze": "az", "azj": "az",
+	"bak": "ba", "bam": "bm", "baq": "eu", "bel": "be", "ben": "bn", "bih": "bh", "bis": "bi", "bod": "bo",
+	"bos": "bs", "bre": "br", "bul": "bg", "bur": "my", "cat": "ca", "ces": "cs", "cha": "ch", "che": "ce",
+	"chi": "zh", "chu": "cu", "chv": "cv", "cmn": "zh", "cor": "kw", "cos": "co", "cre": "cr", "cym": "cy",
+	"cze": "cs", "dan": "da", "deu": "de", "div": "dv", "dut": "nl", "dzo": "dz", "ekk": "et", "ell": "el",
+	"eng": "en", "epo": "eo", "est": "et", "eus": "eu", "ewe": "ee", "fao": "fo", "fas": "fa", "fij": "fj",
+	"fin": "fi", "fra": "fr", "fre": "fr", "fry": "fy", "ful": "ff", "geo": "ka", "ger": "de", "gla": "gd",
+	"gle": "ga", "glg": "gl", "glv": "gv", "gre": "el", "grn": "gn", "gug": "gn", "guj": "gu", "hat": "ht",
+	"hau": "ha", "heb": "he", "her": "hz", "hin": "hi", "hmo": "ho", "hrv": "hr", "hun": "hu", "hye": "hy",
+	"ibo": "ig", "ice": "is", "ido": "io", "iii": "ii", "iku": "iu", "ile": "ie", "ina": "ia", "ind": "id",
+	"ipk": "ik", "isl": "is", "ita": "it", "jav": "jv", "jpn": "ja", "kal": "kl", "kan": "kn", "kas": "ks",
+	"kat": "ka", "kau": "kr", "kaz": "kk", "khk": "mn", "khm": "km", "kik": "ki", "kin": "rw", "kir": "ky",
+	"kom": "kv", "kon": "kg", "kor": "ko", "kua": "kj", "kur": "ku", "lao": "lo", "lat": "la", "lav": "lv",
+	"lim": "li", "lin": "ln", "lit": "lt", "ltz": "lb", "lub": "lu", "lug": "lg", "lvs": "lv", "mac": "mk",
+	"mah": "mh", "mal": "ml", "mao": "mi", "mar": "mr", "may": "ms", "mkd": "mk", "mlg": "mg", "mlt": "mt",
+	"mon": "mn", "mri": "mi", "msa": "ms", "mya": "my", "nau": "na", "nav": "nv", "nbl": "nr", "nde": "nd",
+	"ndo": "ng", "nep": "ne", "nld": "nl", "nno": "nn", "no": "nb", "nob": "nb", "nor": "nb", "nya": "ny",
+	"oci": "oc", "oji": "oj", "ori": "or", "orm": "om", "oss": "os", "pan": "pa", "per": "fa", "pes": "fa",
+	"pli": "pi", "plt": "mg", "pol": "pl", "por": "pt", "pus": "ps", "que": "qu", "quz": "qu", "roh": "rm",
+	"ron": "ro", "rum": "ro", "run": "rn", "rus": "ru", "sag": "sg", "san": "sa", "sin": "si", "slk": "sk",
+	"slo": "sk", "slv": "sl", "sme": "se", "smo": "sm", "sna": "sn", "snd": "sd", "som": "so", "sot": "st",
+	"spa": "es", "sqi": "sq", "srd": "sc", "srp": "sr", "ssw": "ss", "sun": "su", "swa": "sw", "swe": "sv",
+	"swh": "sw", "tah": "ty", "tam": "ta", "tat": "tt", "tel": "te", "tgk": "tg", "tgl": "tl", "tha": "th",
+	"tib": "bo", "tir": "ti", "ton": "to", "tsn": "tn", "tso": "ts", "tuk": "tk", "tur": "tr", "twi": "tw",
+	"uig": "ug", "ukr": "uk", "urd": "ur", "uzb": "uz", "uzn": "uz", "ven": "ve", "vie": "vi", "vol": "vo",
+	"wel": "cy", "wln": "wa", "wol": "wo", "xho": "xh", "ydd": "yi", "yid": "yi", "yor": "yo", "zha": "za",
+	"zho": "zh", "zsm": "ms", "zul": "zu",
+}
+
+// Canonical returns the canonical language code for a code and whether the code is
+// deprecated. Only the primary language subtag is replaced, so "iw-IL" is canonicalized
+// as "he-IL"; codes without an alias are returned unchanged.
+func Canonical(code string) (canonical string, deprecated bool) {
+	primary, rest, _ := strings.Cut(code, "-")
+	primary = strings.ToLower(primary)
+
+	var ok bool
+	if canonical, ok = deprecatedCodes[primary]; ok {
+		deprecated = true
+	} else if canonical, ok = alternativeCodes[primary]; !ok {
+		return code, false
+	}
+
+	if rest != "" {
+		canonical += "-" + rest
+	}
+	return canonical, deprecated
+}
+
+// Aliases returns the language codes in the catalog that are aliases of a canonical
+// code, mapped to the canonical code.
+func Aliases(messages catalog.Catalog) map[string]string {
+	aliases := make(map[string]string)
+	for _, lang := range messages.Languages() {
+		if canonical, _ := Canonical(lang); canonical != lang {
+			aliases[lang] = canonical
+		}
+	}
+	return aliases
+}
+
+// Replace the aliases in the catalog with their canonical codes. If the catalog has
+// greetings for both an alias and its canonical code, e.g. the duplicate iw and he,
+// the greetings of the canonical code are kept; of several aliases for a code that is
+// not in the catalog the first in sorted order is kept.
+func canonicalize(messages catalog.Catalog) catalog.Catalog {
+	aliases := Aliases(messages)
+	if len(aliases) == 0 {
+		return messages
+	}
+
+	canonical := make(catalog.Catalog, len(messages))
+	for lang, variants := range messages {
+		if _, ok := aliases[lang]; !ok {
+			canonical[lang] = variants
+		}
+	}
+
+	langs := make([]string, 0, len(aliases))
+	for lang := range aliases {
+		langs = append(langs, lang)
+	}
+	sort.Strings(langs)
+
+	for _, lang := range langs {
+		if _, ok := canonical[aliases[lang]]; !ok {
+			canonical[aliases[lang]] = messages[lang]
+		}
+	}
+	return canonical
+}
+
+// Create the trailer that signals the use of a deprecated language code, or nil if
+// the code is not deprecated.
+func deprecationTrailer(code string) metadata.MD {
+	if canonical, deprecated := Canonical(code); deprecated {
+		return metadata.Pairs(DeprecatedTrailer, code+"="+canonical)
+	}
+	return nil
+}
+
+// Deprecations returns the deprecated language codes used by the requests of an RPC
+// mapped to their replacements, from the trailer metadata of the RPC.
+//
+//	var trailer metadata.MD
+//	rep, err := client.SayHello(ctx, &pb.HelloRequest{IsoLanguageCode: "iw"}, grpc.Trailer(&trailer))
+//	hello.Deprecations(trailer) // map[iw:he]
+func Deprecations(trailer metadata.MD) map[string]string {
+	deprecations := make(map[string]string)
+	for _, value := range trailer.Get(DeprecatedTrailer) {
+		if code, canonical, ok := strings.Cut(value, "="); ok {
+			deprecations[code] = canonical
+		}
+	}
+	return deprecations
+}
@@ -0,0 +1,407 @@
+/*
+Package bench is a load generator that measures how many greetings per second a hello
+server can deliver. It drives one of the four RPCs from a pool of workers spread
+across one or more connections, optionally limited to a target rate, and reports the
+throughput, latency percentiles and a breakdown of errors by status code:
+
+	report, err := bench.Run(ctx, "localhost:443", bench.Options{
+		RPC:         bench.Unary,
+		Concurrency: 16,
+		Connections: 4,
+		Duration:    30 * time.Second,
+		Languages:   []bench.Language{{Code: "en", Weight: 3}, {Code: "fr", Weight: 1}},
+	}, grpc.WithTransportCredentials(insecure.NewCredentials()))
+
+Every operation is a complete RPC: streaming RPCs send or receive Batch greetings and
+the latency of an operation is the time until the stream is finished.
+*/
+package bench
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"math/rand"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/pdeziel/grpc-example/pb"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/status"
+)
+
+// RPC names the method that is benchmarked.
+type RPC string
+
+const (
+	Unary         RPC = "unary"
+	ServerStream  RPC = "server"
+	ClientStream  RPC = "client"
+	Bidirectional RPC = "bidi"
+)
+
+// Defaults that are used when the options are not specified.
+const (
+	DefaultConcurrency = 10
+	DefaultDuration    = 10 * time.Second
+	DefaultBatch       = 5
+
+	// MaxQPS is the highest rate that can be limited, the rate limiter cannot tick more
+	// than once a nanosecond so higher rates are clamped to it.
+	MaxQPS = 1e9
+
+	// MinQPS is the lowest rate that can be limited, lower rates would tick less often
+	// than the longest time.Duration and are rejected.
+	MinQPS = 1e-9
+)
+
+// Options configures a benchmark run.
+type Options struct {
+	// RPC is the method to call, by default the unary SayHello RPC.
+	RPC RPC
+
+	// Concurrency is the number of workers making requests at the same time.
+	Concurrency int
+
+	// Connections is the number of client connections that the workers are spread
+	// across; by default all workers share a single connection.
+	Connections int
+
+	// Duration limits how long the benchmark runs. If Requests is set the benchmark
+	// instead stops after that many operations have been made.
+	Duration time.Duration
+	Requests int
+
+	// QPS is the target rate of operations per second across all workers; zero means
+	// that workers make requests as fast as possible.
+	QPS float64
+
+	// Languages are chosen at random for each request in proportion to their weights,
+	// by default every request is for "en".
+	Languages []Language
+
+	// Batch is the number of greetings sent or received by each streaming RPC.
+	Batch int
+
+	// Seed for the random source used to choose languages.
+	Seed int64
+}
+
+// Language is a language code with the relative weight it is requested with.
+type Language struct {
+	Code   string
+	Weight int
+}
+
+// ParseLanguages parses a language mix from codes with optional weights, e.g. "en=3",
+// "fr=1", "es" where a code without a weight has a weight of 1.
+func ParseLanguages(specs []string) (langs []Language, err error) {
+	for _, spec := range specs {
+		lang := Language{Code: strings.TrimSpace(spec), Weight: 1}
+		if code, weight, ok := strings.Cut(lang.Code, "="); ok {
+			lang.Code = strings.TrimSpace(code)
+			if lang.Weight, err = strconv.Atoi(strings.TrimSpace(weight)); err != nil || lang.Weight < 1 {
+				return nil, fmt.Errorf("invalid weight for language %q: %q", lang.Code, weight)
+			}
+		}
+
+		if lang.Code == "" {
+			return nil, fmt.Errorf("invalid language mix %q: missing language code", spec)
+		}
+		langs = append(langs, lang)
+	}
+	return langs, nil
+}
+
+func (o *Options) setDefaults() error {
+	if o.RPC == "" {
+		o.RPC = Unary
+	}
+
+	switch o.RPC {
+	case Unary, ServerStream, ClientStream, Bidirectional:
+	default:
+		return fmt.Errorf("unknown rpc %q: must be one of unary, server, client or bidi", o.RPC)
+	}
+
+	if o.Concurrency < 1 {
+		o.Concurrency = DefaultConcurrency
+	}
+
+	if o.Connections < 1 {
+		o.Connections = 1
+	}
+
+	if o.Connections > o.Concurrency {
+		o.Connections = o.Concurrency
+	}
+
+	if o.Duration <= 0 && o.Requests <= 0 {
+		o.Duration = DefaultDuration
+	}
+
+	if o.Batch < 1 {
+		o.Batch = DefaultBatch
+	}
+
+	if (o.QPS != 0 && o.QPS < MinQPS) || math.IsNaN(o.QPS) {
+		return fmt.Errorf("invalid qps %g: must be zero or at least %g", o.QPS, MinQPS)
+	}
+
+	if o.QPS > MaxQPS {
+		o.QPS = MaxQPS
+	}
+
+	if len(o.Languages) == 0 {
+		o.Languages = []Language{{Code: "en", Weight: 1}}
+	}
+
+	for _, lang := range o.Languages {
+		if lang.Weight < 1 {
+			return fmt.Errorf("invalid weight %d for language %q", lang.Weight, lang.Code)
+		}
+	}
+	return nil
+}
+
+// Run the benchmark against the server at the endpoint, dialing each connection with
+// the dial options. Run blocks until the duration has elapsed, the requests have all
+// been made or the context is cancelled; cancelling the context ends the benchmark
+// early and still reports the operations that were completed.
+func Run(ctx context.Context, endpoint string, opts Options, dial ...grpc.DialOption) (_ *Report, err error) {
+	if err = opts.setDefaults(); err != nil {
+		return nil, err
+	}
+
+	conns := make([]*grpc.ClientConn, 0, opts.Connections)
+	defer func() {
+		for _, cc := range conns {
+			cc.Close()
+		}
+	}()
+
+	for i := 0; i < opts.Connections; i++ {
+		var cc *grpc.ClientConn
+		if cc, err = grpc.DialContext(ctx, endpoint, dial...); err != nil {
+			return nil, err
+		}
+		conns = append(conns, cc)
+	}
+
+	if opts.Requests <= 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, opts.Duration)
+		defer cancel()
+	}
+
+	b := &bench{
+		opts:  opts,
+		start: time.Now(),
+	}
+
+	if opts.QPS > 0 {
+		b.tokens = make(chan struct{})
+		go b.limit(ctx)
+	}
+
+	var wg sync.WaitGroup
+	results := make([]*result, opts.Concurrency)
+	for i := 0; i < opts.Concurrency; i++ {
+		wg.Add(1)
+		results[i] = &result{errors: make(map[string]int)}
+		go func(i int) {
+			defer wg.Done()
+			w := &worker{
+				bench:  b,
+				client: pb.NewHelloClient(conns[i%len(conns)]),
+				rng:    rand.New(rand.NewSource(opts.Seed + int64(i))),
+				result: results[i],
+			}
+			w.run(ctx)
+		}(i)
+	}
+
+	wg.Wait()
+	return newReport(opts, time.Since(b.start), results), nil
+}
+
+// State shared by all of the workers in a benchmark.
+type bench struct {
+	opts   Options
+	start  time.Time
+	issued int64
+	tokens chan struct{}
+}
+
+// Issue tokens to the workers at the target rate until the context is done.
+func (b *bench) limit(ctx context.Context) {
+	interval := time.Duration(float64(time.Second) / b.opts.QPS)
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ticker.C:
+			select {
+			case b.tokens <- struct{}{}:
+			case <-ctx.Done():
+				return
+			}
+		case <-ctx.Done():
+			return
+		}
+	}
+}
+
+// Wait until the next operation may be made, returning false if the benchmark is done.
+func (b *bench) next(ctx context.Context) bool {
+	if b.opts.Requests > 0 && atomic.AddInt64(&b.issued, 1) > int64(b.opts.Requests) {
+		return false
+	}
+
+	if b.tokens != nil {
+		select {
+		case <-b.tokens:
+		case <-ctx.Done():
+			return false
+		}
+	}
+	return ctx.Err() == nil
+}
+
+// The operations and errors recorded by a single worker.
+type result struct {
+	latencies []time.Duration
+	errors    map[string]int
+}
+
+type worker struct {
+	*bench
+	client pb.HelloClient
+	rng    *rand.Rand
+	result *result
+}
+
+func (w *worker) run(ctx context.Context) {
+	for w.next(ctx) {
+		start := time.Now()
+		err := w.call(ctx)
+
+		// Operations interrupted by the end of the benchmark are not counted
+		if err != nil && ctx.Err() != nil {
+			return
+		}
+
+		w.result.latencies = append(w.result.latencies, time.Since(start))
+		if err != nil {
+			w.result.errors[status.Code(err).String()]++
+		}
+	}
+}
+
+func (w *worker) call(ctx context.Context) (err error) {
+	switch w.opts.RPC {
+	case ServerStream:
+		return w.serverStream(ctx)
+	case ClientStream:
+		return w.clientStream(ctx)
+	case Bidirectional:
+		return w.bidirectional(ctx)
+	default:
+		_, err = w.client.SayHello(ctx, &pb.HelloRequest{IsoLanguageCode: w.language()})
+		return err
+	}
+}
+
+func (w *worker) serverStream(ctx context.Context) (err error) {
+	req := &pb.HelloManyRequest{IsoLanguageCodes: make([]string, 0, w.opts.Batch)}
+	for i := 0; i < w.opts.Batch; i++ {
+		req.IsoLanguageCodes = append(req.IsoLanguageCodes, w.language())
+	}
+
+	var stream pb.Hello_SayServerStreamClient
+	if stream, err = w.client.SayServerStream(ctx, req); err != nil {
+		return err
+	}
+
+	for {
+		if _, err = stream.Recv(); err != nil {
+			if errors.Is(err, io.EOF) {
+				return nil
+			}
+			return err
+		}
+	}
+}
+
+func (w *worker) clientStream(ctx context.Context) (err error) {
+	var stream pb.Hello_SayClientStreamClient
+	if stream, err = w.client.SayClientStream(ctx); err != nil {
+		return err
+	}
+
+	for i := 0; i < w.opts.Batch; i++ {
+		if err = stream.Send(&pb.HelloRequest{IsoLanguageCode: w.language()}); err != nil {
+			// The server ended the stream, the reason is returned by CloseAndRecv
+			if errors.Is(err, io.EOF) {
+				break
+			}
+			return err
+		}
+	}
+
+	_, err = stream.CloseAndRecv()
+	return err
+}
+
+func (w *worker) bidirectional(ctx context.Context) (err error) {
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	var stream pb.Hello_SayBidirectionalClient
+	if stream, err = w.client.SayBidirectional(ctx); err != nil {
+		return err
+	}
+
+	// Each request is sent once the reply to the previous one has been received
+	for i := 0; i < w.opts.Batch; i++ {
+		if err = stream.Send(&pb.HelloRequest{IsoLanguageCode: w.language()}); err != nil && !errors.Is(err, io.EOF) {
+			return err
+		}
+
+		if _, err = stream.Recv(); err != nil {
+			return err
+		}
+	}
+
+	if err = stream.CloseSend(); err != nil {
+		return err
+	}
+
+	if _, err = stream.Recv(); !errors.Is(err, io.EOF) {
+		return err
+	}
+	return nil
+}
+
+// Choose a language at random in proportion to the weights of the language mix.
+func (w *worker) language() string {
+	total := 0
+	for _, lang := range w.opts.Languages {
+		total += lang.Weight
+	}
+
+	n := w.rng.Intn(total)
+	for _, lang := range w.opts.Languages {
+		if n < lang.Weight {
+			return lang.Code
+		}
+		n -= lang.Weight
+	}
+	return w.opts.Languages[len(w.opts.Languages)-1].Code
+}
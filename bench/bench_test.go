@@ -0,0 +1,140 @@
+package bench_test
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/pdeziel/grpc-example/bench"
+	"github.com/pdeziel/grpc-example/hellotest"
+	"github.com/pdeziel/grpc-example/mock"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials/insecure"
+)
+
+func dialOptions(srv *hellotest.Server) []grpc.DialOption {
+	return []grpc.DialOption{
+		grpc.WithContextDialer(srv.Listener.Dialer),
+		grpc.WithTransportCredentials(insecure.NewCredentials()),
+	}
+}
+
+func TestRequests(t *testing.T) {
+	srv := hellotest.NewServer(t)
+
+	for _, rpc := range []bench.RPC{bench.Unary, bench.ServerStream, bench.ClientStream, bench.Bidirectional} {
+		t.Run(string(rpc), func(t *testing.T) {
+			report, err := bench.Run(context.Background(), mock.DefaultTarget, bench.Options{
+				RPC:         rpc,
+				Concurrency: 4,
+				Connections: 2,
+				Requests:    40,
+				Batch:       3,
+				Languages:   []bench.Language{{Code: "en", Weight: 3}, {Code: "fr", Weight: 1}},
+			}, dialOptions(srv)...)
+			require.NoError(t, err, "could not run the benchmark")
+
+			require.Equal(t, rpc, report.RPC)
+			require.Equal(t, 40, report.Requests, "expected exactly the number of requests to be made")
+			require.Zero(t, report.Errors)
+			require.Empty(t, report.Codes)
+			require.Greater(t, report.Throughput, 0.0)
+			require.LessOrEqual(t, report.Latency.Min, report.Latency.P50)
+			require.LessOrEqual(t, report.Latency.P50, report.Latency.P99)
+			require.LessOrEqual(t, report.Latency.P99, report.Latency.Max)
+		})
+	}
+}
+
+func TestErrors(t *testing.T) {
+	srv := hellotest.NewServer(t)
+
+	report, err := bench.Run(context.Background(), mock.DefaultTarget, bench.Options{
+		Concurrency: 2,
+		Requests:    20,
+		Languages:   []bench.Language{{Code: "en", Weight: 1}, {Code: "xx", Weight: 1}},
+		Seed:        42,
+	}, dialOptions(srv)...)
+	require.NoError(t, err, "could not run the benchmark")
+
+	require.Equal(t, 20, report.Requests)
+	require.Greater(t, report.Errors, 0, "expected unknown languages to fail")
+	require.Less(t, report.Errors, 20, "expected known languages to succeed")
+	require.Equal(t, map[string]int{"NotFound": report.Errors}, report.Codes)
+}
+
+func TestDurationAndQPS(t *testing.T) {
+	srv := hellotest.NewServer(t)
+
+	report, err := bench.Run(context.Background(), mock.DefaultTarget, bench.Options{
+		Concurrency: 4,
+		Duration:    500 * time.Millisecond,
+		QPS:         40,
+	}, dialOptions(srv)...)
+	require.NoError(t, err, "could not run the benchmark")
+
+	require.GreaterOrEqual(t, report.Duration, 500*time.Millisecond)
+	require.Greater(t, report.Requests, 0)
+	require.LessOrEqual(t, report.Requests, 21, "expected the rate to be limited")
+
+	// Rates above the limit of the ticker are clamped rather than panicking
+	report, err = bench.Run(context.Background(), mock.DefaultTarget, bench.Options{
+		Requests: 10,
+		QPS:      2 * bench.MaxQPS,
+	}, dialOptions(srv)...)
+	require.NoError(t, err, "could not run the benchmark")
+	require.Equal(t, 10, report.Requests)
+}
+
+func TestInvalidOptions(t *testing.T) {
+	_, err := bench.Run(context.Background(), mock.DefaultTarget, bench.Options{RPC: "foo"})
+	require.EqualError(t, err, `unknown rpc "foo": must be one of unary, server, client or bidi`)
+
+	_, err = bench.Run(context.Background(), mock.DefaultTarget, bench.Options{QPS: -1})
+	require.EqualError(t, err, "invalid qps -1: must be zero or at least 1e-09")
+
+	// Rates so low that the interval would overflow a time.Duration are rejected
+	_, err = bench.Run(context.Background(), mock.DefaultTarget, bench.Options{QPS: 1e-10})
+	require.EqualError(t, err, "invalid qps 1e-10: must be zero or at least 1e-09")
+}
+
+func TestParseLanguages(t *testing.T) {
+	langs, err := bench.ParseLanguages([]string{"en=3", " fr = 2", "es"})
+	require.NoError(t, err)
+	require.Equal(t, []bench.Language{{"en", 3}, {"fr", 2}, {"es", 1}}, langs)
+
+	_, err = bench.ParseLanguages([]string{"en=0"})
+	require.Error(t, err)
+
+	_, err = bench.ParseLanguages([]string{"=3"})
+	require.Error(t, err)
+}
+
+func TestReportOutput(t *testing.T) {
+	srv := hellotest.NewServer(t)
+
+	report, err := bench.Run(context.Background(), mock.DefaultTarget, bench.Options{
+		Concurrency: 1,
+		Requests:    5,
+		Languages:   []bench.Language{{Code: "xx", Weight: 1}},
+	}, dialOptions(srv)...)
+	require.NoError(t, err, "could not run the benchmark")
+
+	buf := &bytes.Buffer{}
+	require.NoError(t, report.WriteText(buf))
+	require.Contains(t, buf.String(), "Requests:    5 (5 errors)")
+	require.Contains(t, buf.String(), "NotFound")
+
+	buf.Reset()
+	require.NoError(t, report.WriteJSON(buf))
+
+	var data map[string]interface{}
+	require.NoError(t, json.Unmarshal(buf.Bytes(), &data))
+	require.Equal(t, "unary", data["rpc"])
+	require.Equal(t, 5.0, data["requests"])
+	require.Equal(t, map[string]interface{}{"NotFound": 5.0}, data["codes"])
+	require.Contains(t, data["latency_ms"], "p99")
+}
@@ -0,0 +1,178 @@
+package bench
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"sort"
+	"text/tabwriter"
+	"time"
+)
+
+// Report summarizes the operations made during a benchmark run.
+type Report struct {
+	RPC         RPC
+	Concurrency int
+	Connections int
+	Duration    time.Duration
+	Requests    int
+	Errors      int
+	Throughput  float64
+	Latency     Latency
+
+	// Codes counts the failed operations by the name of their status code.
+	Codes map[string]int
+}
+
+// Latency summarizes the distribution of operation latencies.
+type Latency struct {
+	Min  time.Duration
+	Mean time.Duration
+	P50  time.Duration
+	P90  time.Duration
+	P95  time.Duration
+	P99  time.Duration
+	Max  time.Duration
+}
+
+func newReport(opts Options, elapsed time.Duration, results []*result) *Report {
+	report := &Report{
+		RPC:         opts.RPC,
+		Concurrency: opts.Concurrency,
+		Connections: opts.Connections,
+		Duration:    elapsed,
+		Codes:       make(map[string]int),
+	}
+
+	latencies := make([]time.Duration, 0)
+	for _, res := range results {
+		latencies = append(latencies, res.latencies...)
+		for code, n := range res.errors {
+			report.Codes[code] += n
+			report.Errors += n
+		}
+	}
+
+	report.Requests = len(latencies)
+	if elapsed > 0 {
+		report.Throughput = float64(report.Requests) / elapsed.Seconds()
+	}
+
+	if len(latencies) == 0 {
+		return report
+	}
+
+	sort.Slice(latencies, func(i, j int) bool { return latencies[i] < latencies[j] })
+
+	var total time.Duration
+	for _, d := range latencies {
+		total += d
+	}
+
+	report.Latency = Latency{
+		Min:  latencies[0],
+		Mean: total / time.Duration(len(latencies)),
+		P50:  percentile(latencies, 50),
+		P90:  percentile(latencies, 90),
+		P95:  percentile(latencies, 95),
+		P99:  percentile(latencies, 99),
+		Max:  latencies[len(latencies)-1],
+	}
+	return report
+}
+
+// Nearest-rank percentile of sorted latencies.
+func percentile(sorted []time.Duration, p int) time.Duration {
+	rank := (p*len(sorted) + 99) / 100
+	if rank < 1 {
+		rank = 1
+	}
+	return sorted[rank-1]
+}
+
+// WriteText writes a human readable summary of the report.
+func (r *Report) WriteText(w io.Writer) error {
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	fmt.Fprintf(tw, "RPC:\t%s\n", r.RPC)
+	fmt.Fprintf(tw, "Workers:\t%d on %d connection(s)\n", r.Concurrency, r.Connections)
+	fmt.Fprintf(tw, "Duration:\t%s\n", r.Duration.Round(time.Millisecond))
+	fmt.Fprintf(tw, "Requests:\t%d (%d errors)\n", r.Requests, r.Errors)
+	fmt.Fprintf(tw, "Throughput:\t%.2f req/s\n", r.Throughput)
+	fmt.Fprintln(tw)
+
+	fmt.Fprintln(tw, "Latency:")
+	for _, row := range []struct {
+		name string
+		d    time.Duration
+	}{
+		{"min", r.Latency.Min},
+		{"mean", r.Latency.Mean},
+		{"p50", r.Latency.P50},
+		{"p90", r.Latency.P90},
+		{"p95", r.Latency.P95},
+		{"p99", r.Latency.P99},
+		{"max", r.Latency.Max},
+	} {
+		fmt.Fprintf(tw, "  %s\t%s\n", row.name, row.d)
+	}
+
+	if len(r.Codes) > 0 {
+		codes := make([]string, 0, len(r.Codes))
+		for code := range r.Codes {
+			codes = append(codes, code)
+		}
+		sort.Strings(codes)
+
+		fmt.Fprintln(tw)
+		fmt.Fprintln(tw, "Errors:")
+		for _, code := range codes {
+			fmt.Fprintf(tw, "  %s\t%d\n", code, r.Codes[code])
+		}
+	}
+	return tw.Flush()
+}
+
+// WriteJSON writes the report as indented JSON with durations in milliseconds.
+func (r *Report) WriteJSON(w io.Writer) error {
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(r)
+}
+
+// MarshalJSON encodes durations as fractional milliseconds, which are easier to consume
+// from scripts than the nanoseconds of a time.Duration.
+func (r *Report) MarshalJSON() ([]byte, error) {
+	ms := func(d time.Duration) float64 {
+		return float64(d) / float64(time.Millisecond)
+	}
+
+	return json.Marshal(struct {
+		RPC         RPC                `json:"rpc"`
+		Concurrency int                `json:"concurrency"`
+		Connections int                `json:"connections"`
+		Duration    float64            `json:"duration_ms"`
+		Requests    int                `json:"requests"`
+		Errors      int                `json:"errors"`
+		Throughput  float64            `json:"throughput"`
+		Latency     map[string]float64 `json:"latency_ms"`
+		Codes       map[string]int     `json:"codes"`
+	}{
+		RPC:         r.RPC,
+		Concurrency: r.Concurrency,
+		Connections: r.Connections,
+		Duration:    ms(r.Duration),
+		Requests:    r.Requests,
+		Errors:      r.Errors,
+		Throughput:  r.Throughput,
+		Latency: map[string]float64{
+			"min":  ms(r.Latency.Min),
+			"mean": ms(r.Latency.Mean),
+			"p50":  ms(r.Latency.P50),
+			"p90":  ms(r.Latency.P90),
+			"p95":  ms(r.Latency.P95),
+			"p99":  ms(r.Latency.P99),
+			"max":  ms(r.Latency.Max),
+		},
+		Codes: r.Codes,
+	})
+}
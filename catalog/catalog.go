@@ -0,0 +1,323 @@
+/*
+Package catalog reads and writes greeting catalogs, which map language codes to the
+greeting in that language, in the file formats used by translators:
+
+	JSON   an object of language codes to greetings (messages.json)
+	YAML   a mapping of language codes to greetings
+	TOML   key/value pairs of language codes to string greetings
+	CSV    rows of language code and greeting with an optional header row
+	PO     gettext entries with the language code as msgctxt or the Language header
+	XLIFF  translation units with the language code as the target language
+
+A language may have several variants of its greeting tagged by register, time of day
+and context, e.g. a formal "Guten Tag" and an informal "Hallo". In JSON and YAML the
+greeting of a language is either a string or a list of variants:
+
+	{
+	    "en": "Hello",
+	    "de": [
+	        {"greeting": "Guten Tag", "register": "formal"},
+	        {"greeting": "Guten Morgen", "register": "formal", "time": "morning"},
+	        {"greeting": "Hallo"}
+	    ]
+	}
+
+Variants may also have a weight, a positive integer that defaults to 1, which is used
+by applications that rotate through or randomly choose among equally suitable variants.
+
+In TOML the variants are an array of tables ([[de]]) with the same keys and in CSV they
+are rows with register, time, context and weight columns named in the header row. PO and
+XLIFF entries are tagged by suffixing the msgctxt or the translation unit id with the
+tags, e.g. "de|register=formal|time=morning".
+
+Errors in a catalog are reported as an *Error with the line of the offending entry so
+that translators can find and fix it.
+*/
+package catalog
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"unicode/utf8"
+)
+
+// Format of a catalog file.
+type Format string
+
+const (
+	JSON  Format = "json"
+	YAML  Format = "yaml"
+	TOML  Format = "toml"
+	CSV   Format = "csv"
+	PO    Format = "po"
+	XLIFF Format = "xliff"
+)
+
+// Catalog greetings are translated from the greeting in the source language.
+const SourceLanguage = "en"
+
+// Formats returns all of the supported catalog formats.
+func Formats() []Format {
+	return []Format{JSON, YAML, TOML, CSV, PO, XLIFF}
+}
+
+// ParseFormat returns the format with the name, e.g. "po" or "xliff".
+func ParseFormat(name string) (Format, error) {
+	name = strings.ToLower(strings.TrimSpace(name))
+	if name == "yml" {
+		return YAML, nil
+	}
+
+	if name == "xlf" {
+		return XLIFF, nil
+	}
+
+	for _, format := range Formats() {
+		if string(format) == name {
+			return format, nil
+		}
+	}
+	return "", fmt.Errorf("unknown catalog format %q", name)
+}
+
+// FormatOf returns the format of the file from its extension.
+func FormatOf(path string) (Format, error) {
+	ext := strings.TrimPrefix(filepath.Ext(path), ".")
+	if ext == "" {
+		return "", fmt.Errorf("cannot determine the catalog format of %q without an extension", path)
+	}
+	return ParseFormat(ext)
+}
+
+// Error describes a problem with an entry in a catalog.
+type Error struct {
+	Path   string
+	Format Format
+	Line   int
+	Msg    string
+}
+
+func (e *Error) Error() string {
+	loc := e.Path
+	if loc == "" {
+		loc = string(e.Format)
+	}
+
+	if e.Line > 0 {
+		return fmt.Sprintf("%s:%d: %s", loc, e.Line, e.Msg)
+	}
+	return fmt.Sprintf("%s: %s", loc, e.Msg)
+}
+
+func errorf(format Format, line int, msg string, args ...interface{}) error {
+	return &Error{Format: format, Line: line, Msg: fmt.Sprintf(msg, args...)}
+}
+
+// Decode a catalog in the format from the reader.
+func Decode(r io.Reader, format Format) (_ Catalog, err error) {
+	var data []byte
+	if data, err = io.ReadAll(r); err != nil {
+		return nil, err
+	}
+
+	var catalog *entries
+	if catalog, err = decode(data, format); err != nil {
+		return nil, err
+	}
+	return catalog.catalog, nil
+}
+
+func decode(data []byte, format Format) (*entries, error) {
+	// Catalogs are always UTF-8. The raw data is checked since some decoders, e.g. JSON,
+	// replace invalid bytes with U+FFFD, which would hide the error.
+	if err := checkUTF8(data, format); err != nil {
+		return nil, err
+	}
+
+	switch format {
+	case JSON:
+		return decodeJSON(data)
+	case YAML:
+		return decodeYAML(data)
+	case TOML:
+		return decodeTOML(data)
+	case CSV:
+		return decodeCSV(data)
+	case PO:
+		return decodePO(data)
+	case XLIFF:
+		return decodeXLIFF(data)
+	default:
+		return nil, fmt.Errorf("unknown catalog format %q", format)
+	}
+}
+
+// Encode the catalog in the format to the writer with the languages sorted.
+func Encode(w io.Writer, format Format, messages Catalog) error {
+	switch format {
+	case JSON:
+		return encodeJSON(w, messages)
+	case YAML:
+		return encodeYAML(w, messages)
+	case TOML:
+		return encodeTOML(w, messages)
+	case CSV:
+		return encodeCSV(w, messages)
+	case PO:
+		return encodePO(w, messages)
+	case XLIFF:
+		return encodeXLIFF(w, messages)
+	default:
+		return fmt.Errorf("unknown catalog format %q", format)
+	}
+}
+
+// ReadFile decodes the catalog at path. If format is empty it is determined from the
+// extension of the file.
+func ReadFile(path string, format Format) (_ Catalog, err error) {
+	var catalog *entries
+	if catalog, err = readFile(path, format); err != nil {
+		return nil, err
+	}
+	return catalog.catalog, nil
+}
+
+func readFile(path string, format Format) (catalog *entries, err error) {
+	if format == "" {
+		if format, err = FormatOf(path); err != nil {
+			return nil, err
+		}
+	}
+
+	var data []byte
+	if data, err = os.ReadFile(path); err != nil {
+		return nil, err
+	}
+
+	if catalog, err = decode(data, format); err != nil {
+		var cerr *Error
+		if errors.As(err, &cerr) {
+			cerr.Path = path
+		}
+		return nil, err
+	}
+	return catalog, nil
+}
+
+// WriteFile encodes the catalog to the file at path, replacing it if it exists. If
+// format is empty it is determined from the extension of the file.
+func WriteFile(path string, format Format, messages Catalog) (err error) {
+	if format == "" {
+		if format, err = FormatOf(path); err != nil {
+			return err
+		}
+	}
+
+	buf := &bytes.Buffer{}
+	if err = Encode(buf, format, messages); err != nil {
+		return err
+	}
+	return os.WriteFile(path, buf.Bytes(), 0644)
+}
+
+// The greeting that other greetings are translated from.
+func source(messages Catalog) string {
+	if variants := messages[SourceLanguage]; len(variants) > 0 {
+		return variants[0].Greeting
+	}
+	return "Hello"
+}
+
+// Collects the variants decoded from a catalog and the lines that they were defined on
+// to report duplicates.
+type entries struct {
+	format  Format
+	catalog Catalog
+	lines   map[string]int
+	keys    map[string]int
+}
+
+func newEntries(format Format) *entries {
+	return &entries{
+		format:  format,
+		catalog: make(Catalog),
+		lines:   make(map[string]int),
+		keys:    make(map[string]int),
+	}
+}
+
+// Declare a language in formats where each language has a single key, such as JSON,
+// which is a duplicate if the language has already been declared.
+func (e *entries) declare(lang string, line int) error {
+	if lang == "" {
+		return errorf(e.format, line, "missing language code")
+	}
+
+	if prev, ok := e.lines[lang]; ok {
+		return errorf(e.format, line, "duplicate language %q (first defined on line %d)", lang, prev)
+	}
+
+	e.lines[lang] = line
+	return nil
+}
+
+// Claim a unique key in formats where entries are identified by a key, such as the
+// msgctxt of a PO entry, which is a duplicate if it has already been claimed.
+func (e *entries) claim(key string, line int) error {
+	if prev, ok := e.keys[key]; ok {
+		lang, v, _ := parseKey(key)
+		if !v.Tagged() {
+			return errorf(e.format, line, "duplicate language %q (first defined on line %d)", lang, prev)
+		}
+		return errorf(e.format, line, "duplicate variant %q (first defined on line %d)", key, prev)
+	}
+
+	e.keys[key] = line
+	return nil
+}
+
+// Add a variant of the greeting for the language.
+func (e *entries) add(lang string, v Variant, line int) error {
+	if lang == "" {
+		return errorf(e.format, line, "missing language code")
+	}
+
+	if err := v.validate(); err != nil {
+		return errorf(e.format, line, "%s", err)
+	}
+
+	if _, ok := e.lines[lang]; !ok {
+		e.lines[lang] = line
+	}
+	e.catalog[lang] = append(e.catalog[lang], v)
+	return nil
+}
+
+// Return an error with the line of the first byte of the data that is not valid UTF-8.
+func checkUTF8(data []byte, format Format) error {
+	if utf8.Valid(data) {
+		return nil
+	}
+
+	for offset := 0; offset < len(data); {
+		r, size := utf8.DecodeRune(data[offset:])
+		if r == utf8.RuneError && size == 1 {
+			return errorf(format, lineOf(data, int64(offset)), "invalid UTF-8 byte 0x%02x", data[offset])
+		}
+		offset += size
+	}
+	return nil
+}
+
+// Convert a byte offset into a line number.
+func lineOf(data []byte, offset int64) int {
+	if offset > int64(len(data)) {
+		offset = int64(len(data))
+	}
+	return bytes.Count(data[:offset], []byte("\n")) + 1
+}
@@ -0,0 +1,325 @@
+package catalog_test
+
+import (
+	"bytes"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/pdeziel/grpc-example/catalog"
+	"github.com/stretchr/testify/require"
+)
+
+var greetings = catalog.Flat(map[string]string{
+	"en":      "Hello",
+	"fr":      "Bonjour",
+	"ja":      "こんにちは",
+	"zh-Hant": "你好",
+	"x-quote": `Say "hi", then \ wave`,
+	"x-html":  "<b>Hi</b> & bye",
+})
+
+var variants = catalog.Catalog{
+	"en": {{Greeting: "Hello"}},
+	"de": {
+		{Greeting: "Guten Tag", Register: catalog.Formal},
+		{Greeting: "Guten Morgen", Register: catalog.Formal, TimeOfDay: catalog.Morning},
+		{Greeting: "Moin", Context: "email"},
+		{Greeting: "Moin", Context: "email"},
+		{Greeting: "Hallo"},
+	},
+	"fr": {{Greeting: "Bonsoir", TimeOfDay: catalog.Evening}},
+	"ja": {{Greeting: "おはよう", Weight: 3}, {Greeting: "こんにちは"}},
+}
+
+func TestRoundTrip(t *testing.T) {
+	for _, format := range catalog.Formats() {
+		t.Run(string(format), func(t *testing.T) {
+			for _, messages := range []catalog.Catalog{greetings, variants} {
+				buf := &bytes.Buffer{}
+				require.NoError(t, catalog.Encode(buf, format, messages), "could not encode the catalog")
+
+				decoded, err := catalog.Decode(bytes.NewReader(buf.Bytes()), format)
+				require.NoError(t, err, "could not decode the encoded catalog:\n%s", buf)
+				require.Equal(t, messages, decoded)
+
+				// Encoding is deterministic
+				again := &bytes.Buffer{}
+				require.NoError(t, catalog.Encode(again, format, decoded))
+				require.Equal(t, buf.String(), again.String())
+			}
+		})
+	}
+}
+
+func TestReadFile(t *testing.T) {
+	// The messages.json shipped with the server must be loadable
+	messages, err := catalog.ReadFile(filepath.Join("..", "messages.json"), "")
+	require.NoError(t, err, "could not read messages.json")
+	require.Equal(t, []catalog.Variant{{Greeting: "Bonjour"}}, messages["fr"])
+
+	dir := t.TempDir()
+	for _, name := range []string{"messages.yml", "messages.xlf", "messages.toml"} {
+		path := filepath.Join(dir, name)
+		require.NoError(t, catalog.WriteFile(path, "", greetings))
+
+		messages, err := catalog.ReadFile(path, "")
+		require.NoError(t, err, "could not read %s", name)
+		require.Equal(t, greetings, messages)
+	}
+
+	// An explicit format overrides the extension
+	path := filepath.Join(dir, "messages.txt")
+	require.NoError(t, catalog.WriteFile(path, catalog.PO, greetings))
+	_, err = catalog.ReadFile(path, "")
+	require.EqualError(t, err, `unknown catalog format "txt"`)
+
+	messages, err = catalog.ReadFile(path, catalog.PO)
+	require.NoError(t, err)
+	require.Equal(t, greetings, messages)
+
+	// Errors include the path of the file
+	require.NoError(t, os.WriteFile(path, []byte("msgctxt \"fr\"\nmsgstr \"Bonjour\"\n"), 0644))
+	_, err = catalog.ReadFile(path, catalog.PO)
+	require.EqualError(t, err, path+":2: msgstr without msgid")
+
+	_, err = catalog.ReadFile(filepath.Join(dir, "missing.json"), "")
+	require.ErrorIs(t, err, os.ErrNotExist)
+}
+
+func TestParseFormat(t *testing.T) {
+	for name, expected := range map[string]catalog.Format{
+		"json": catalog.JSON, "YAML": catalog.YAML, "yml": catalog.YAML, "toml": catalog.TOML,
+		"csv": catalog.CSV, "po": catalog.PO, "xliff": catalog.XLIFF, " xlf ": catalog.XLIFF,
+	} {
+		format, err := catalog.ParseFormat(name)
+		require.NoError(t, err, "could not parse %q", name)
+		require.Equal(t, expected, format)
+	}
+
+	_, err := catalog.ParseFormat("ini")
+	require.EqualError(t, err, `unknown catalog format "ini"`)
+
+	_, err = catalog.FormatOf("messages")
+	require.Error(t, err, "expected an error without an extension")
+}
+
+func TestDecode(t *testing.T) {
+	testCases := []struct {
+		format   catalog.Format
+		input    string
+		expected catalog.Catalog
+	}{
+		{
+			catalog.YAML,
+			"# greetings\nen: Hello\n\"no\": Hei\nfr: 'Bonjour'\n",
+			catalog.Flat(map[string]string{"en": "Hello", "no": "Hei", "fr": "Bonjour"}),
+		},
+		{
+			catalog.TOML,
+			"# greetings\nen = \"Hello\"  # source\n\"zh-Hant\" = \"\\u4f60\\u597d\"\nfr = 'C:\\Bonjour'\n",
+			catalog.Flat(map[string]string{"en": "Hello", "zh-Hant": "你好", "fr": `C:\Bonjour`}),
+		},
+		{
+			catalog.CSV,
+			"en,Hello\n# comment\nfr,\"Bonjour, monde\"\n",
+			catalog.Flat(map[string]string{"en": "Hello", "fr": "Bonjour, monde"}),
+		},
+		{
+			// A PO file exported for a single language with fuzzy and untranslated entries
+			catalog.PO,
+			strings.Join([]string{
+				`# French translation`,
+				`msgid ""`,
+				`msgstr ""`,
+				`"Language: fr\n"`,
+				`"Content-Type: text/plain; charset=UTF-8\n"`,
+				``,
+				`#: messages.json`,
+				`msgid "Hello"`,
+				`msgstr ""`,
+				`"Bon"`,
+				`"jour"`,
+				``,
+				`#, fuzzy`,
+				`msgctxt "de"`,
+				`msgid "Hello"`,
+				`msgstr "Hallo"`,
+				``,
+				`msgctxt "it"`,
+				`msgid "Hello"`,
+				`msgstr ""`,
+				`#~ msgid "Goodbye"`,
+				`#~ msgstr "Au revoir"`,
+			}, "\n"),
+			catalog.Flat(map[string]string{"fr": "Bonjour"}),
+		},
+		{
+			// An XLIFF 2.0 document has a single target language
+			catalog.XLIFF,
+			`<?xml version="1.0"?>
+<xliff xmlns="urn:oasis:names:tc:xliff:document:2.0" version="2.0" srcLang="en" trgLang="es">
+  <file id="messages">
+    <unit id="greeting">
+      <segment>
+        <source>Hello</source>
+        <target>Hola</target>
+      </segment>
+    </unit>
+  </file>
+</xliff>`,
+			catalog.Flat(map[string]string{"es": "Hola"}),
+		},
+	}
+
+	for _, tc := range testCases {
+		messages, err := catalog.Decode(strings.NewReader(tc.input), tc.format)
+		require.NoError(t, err, "could not decode %s", tc.format)
+		require.Equal(t, tc.expected, messages, "unexpected %s catalog", tc.format)
+	}
+}
+
+func TestDecodeVariants(t *testing.T) {
+	expected := catalog.Catalog{
+		"en": {{Greeting: "Hello"}},
+		"de": {
+			{Greeting: "Guten Tag", Register: catalog.Formal},
+			{Greeting: "Hallo"},
+			{Greeting: "Guten Morgen", TimeOfDay: catalog.Morning, Context: "email"},
+			{Greeting: "Servus", Weight: 2},
+		},
+	}
+
+	inputs := map[catalog.Format]string{
+		catalog.JSON: `{
+  "en": "Hello",
+  "de": [
+    {"register": "formal", "greeting": "Guten Tag"},
+    "Hallo",
+    {"greeting": "Guten Morgen", "time": "morning", "context": "email"},
+    {"greeting": "Servus", "weight": 2}
+  ]
+}`,
+		catalog.YAML: `en: Hello
+de:
+  - greeting: Guten Tag
+    register: formal
+  - Hallo
+  - {greeting: Guten Morgen, time: morning, context: email}
+  - greeting: Servus
+    weight: 2
+`,
+		catalog.TOML: `en = "Hello"
+
+[[de]]
+register = "formal"
+greeting = "Guten Tag"
+
+[[de]]
+greeting = "Hallo"
+
+[[de]]
+greeting = "Guten Morgen"
+time = "morning"  # before noon
+context = "email"
+
+[[de]]
+greeting = "Servus"
+weight = 2
+`,
+		catalog.CSV: `Language,Context,Greeting,Time,Register,Weight
+en,,Hello,,,
+de,,Guten Tag,,formal,
+de,,Hallo,,,
+de,email,Guten Morgen,morning,,
+de,,Servus,,,2
+`,
+		catalog.PO: strings.Join([]string{
+			`msgctxt "en"`, `msgid "Hello"`, `msgstr "Hello"`, ``,
+			`msgctxt "de|register=formal"`, `msgid "Hello"`, `msgstr "Guten Tag"`, ``,
+			`msgctxt "de"`, `msgid "Hello"`, `msgstr "Hallo"`, ``,
+			`msgctxt "de|time=morning|context=email"`, `msgid "Hello"`, `msgstr "Guten Morgen"`, ``,
+			`msgctxt "de|weight=2"`, `msgid "Hello"`, `msgstr "Servus"`,
+		}, "\n"),
+		catalog.XLIFF: `<xliff version="1.2">
+  <file target-language="en"><body>
+    <trans-unit id="greeting"><source>Hello</source><target>Hello</target></trans-unit>
+  </body></file>
+  <file target-language="de"><body>
+    <trans-unit id="greeting|register=formal"><source>Hello</source><target>Guten Tag</target></trans-unit>
+    <trans-unit id="greeting"><source>Hello</source><target>Hallo</target></trans-unit>
+    <trans-unit id="greeting|time=morning|context=email"><source>Hello</source><target>Guten Morgen</target></trans-unit>
+    <trans-unit id="greeting|weight=2"><source>Hello</source><target>Servus</target></trans-unit>
+  </body></file>
+</xliff>`,
+	}
+
+	for format, input := range inputs {
+		messages, err := catalog.Decode(strings.NewReader(input), format)
+		require.NoError(t, err, "could not decode %s", format)
+		require.Equal(t, expected, messages, "unexpected %s catalog", format)
+	}
+}
+
+func TestDecodeErrors(t *testing.T) {
+	testCases := []struct {
+		format catalog.Format
+		input  string
+		line   int
+		msg    string
+	}{
+		{catalog.JSON, "[\"en\"]", 1, "expected an object of language codes to greetings"},
+		{catalog.JSON, "{\n  \"en\": \"Hello\",\n  \"fr\": 42\n}", 3, `greeting for "fr" must be a string or a list of variants`},
+		{catalog.JSON, "{\n  \"en\": \"Hello\",\n  \"en\": \"Hi\"\n}", 3, `duplicate language "en" (first defined on line 2)`},
+		{catalog.JSON, "{\n  \"en\": \"Hello\"\n  \"fr\": \"Bonjour\"\n}", 3, `invalid character '"' after object key:value pair`},
+		{catalog.JSON, "{\"en\": \"Hello\"}\n{}", 2, "unexpected data after the catalog"},
+		{catalog.YAML, "en: Hello\nfr: [[Bonjour]]\n", 2, `variant of "fr" must be a string or a mapping`},
+		{catalog.YAML, "en: Hello\nfr: \"Bonjour\n", 2, "found unexpected end of stream"},
+		{catalog.YAML, "- en\n- fr\n", 1, "expected a mapping of language codes to greetings"},
+		{catalog.TOML, "en = \"Hello\"\n[fr]\n", 2, "tables are not supported in a catalog, use an array of tables for variants"},
+		{catalog.TOML, "en = \"Hello\"\nfr = 42\n", 2, `invalid greeting for "fr": greetings must be strings`},
+		{catalog.TOML, "en = \"Hello\"\nfr \"Bonjour\"\n", 2, `expected = after key "fr"`},
+		{catalog.TOML, "en = \"Hello\nfr = \"Bonjour\"\n", 1, `invalid greeting for "en": unterminated string`},
+		{catalog.TOML, "en = \"Hello\" extra\n", 1, `unexpected "extra" after greeting for "en"`},
+		{catalog.TOML, "en = \"Hello\"\n\nen = \"Hi\"\n", 3, `duplicate language "en" (first defined on line 1)`},
+		{catalog.CSV, "language,greeting\nen,Hello\nfr\n", 3, "wrong number of fields (expected language, greeting)"},
+		{catalog.CSV, "en,Hello\n,Bonjour\n", 2, "missing language code"},
+		{catalog.CSV, "en,Hello\nfr,\"Bonjour\n", 2, `extraneous or missing " in quoted-field`},
+		{catalog.PO, "msgctxt \"fr\"\nmsgid \"Hello\"\nmsgstr Bonjour\n", 3, "expected a quoted string"},
+		{catalog.PO, "msgid \"Hello\"\nmsgstr \"Bonjour\"\n", 1, "entry has no msgctxt and the file has no Language header"},
+		{catalog.PO, "msgctxt \"fr\"\nmsgid \"Hello\"\n\nmsgstr \"Bonjour\"\n", 1, "entry is missing msgstr"},
+		{catalog.PO, "msgctxt \"fr\"\nmsgid \"Hello\"\nmsgtxt \"Bonjour\"\n", 3, `unknown keyword "msgtxt"`},
+		{catalog.PO, "msgctxt \"fr\"\nmsgid \"Hello\"\nmsgstr \"Bonjour\"\n\nmsgctxt \"fr\"\nmsgid \"Hello\"\nmsgstr \"Salut\"\n", 5, `duplicate language "fr" (first defined on line 1)`},
+		{catalog.XLIFF, "<xliff version=\"1.2\">\n<file target-language=\"fr\">\n<body>\n<trans-unit id=\"greeting\">\n<target>Bonjour</trans-unit>\n", 5, "element <target> closed by </trans-unit>"},
+		{catalog.JSON, "{\"de\": [\n{\"register\": \"formal\"}\n]}", 2, `variant of "de" has no greeting`},
+		{catalog.JSON, "{\n  \"en\": \"Hello\",\n  \"fr\": \"Bon\xffjour\"\n}", 3, "invalid UTF-8 byte 0xff"},
+		{catalog.CSV, "en,Hello\nfr,Bon\xc3jour\n", 2, "invalid UTF-8 byte 0xc3"},
+		{catalog.JSON, "{\"de\": [\n{\"greeting\": \"Hallo\", \"tone\": \"warm\"}\n]}", 2, `unknown tag "tone" in variant of "de"`},
+		{catalog.YAML, "de:\n  - greeting: Hallo\n    register: polite\n", 2, `invalid register "polite" (expected formal or informal)`},
+		{catalog.TOML, "[[de]]\ngreeting = \"Hallo\"\ngreeting = \"Hi\"\n", 3, `duplicate key "greeting" (first defined on line 2)`},
+		{catalog.TOML, "[[de]]\ntime = \"noon\"\ngreeting = \"Hallo\"\n", 1, `invalid time of day "noon" (expected morning, afternoon, evening or night)`},
+		{catalog.TOML, "de = \"Hallo\"\n[[de]]\ngreeting = \"Hi\"\n", 2, `duplicate language "de" (first defined on line 1)`},
+		{catalog.CSV, "language,greeting,mood\nde,Hallo,happy\n", 1, `unknown column "mood"`},
+		{catalog.CSV, "language,greeting,weight\nde,Hallo,1\nde,Hi,0\n", 3, `invalid weight "0" (expected a positive integer)`},
+		{catalog.JSON, "{\"de\": [\n{\"greeting\": \"Hallo\", \"weight\": 1.5}\n]}", 2, `invalid weight "1.5" (expected a positive integer) in variant of "de"`},
+		{catalog.TOML, "[[de]]\ngreeting = \"Hallo\"\nweight = -1\n", 3, `invalid weight of variant of "de": expected a positive integer`},
+		{catalog.CSV, "language,register\nde,formal\n", 1, "missing greeting column"},
+		{catalog.CSV, "language,greeting,context\nde,Hallo,e mail\n", 2, `invalid context "e mail" (expected letters, digits, '.', '_' or '-')`},
+		{catalog.PO, "msgctxt \"de|formal\"\nmsgid \"Hello\"\nmsgstr \"Guten Tag\"\n", 1, `invalid msgctxt: invalid tag "formal" (expected name=value)`},
+		{catalog.PO, "msgctxt \"de|register=formal\"\nmsgid \"Hello\"\nmsgstr \"Guten Tag\"\n\nmsgctxt \"de|register=formal\"\nmsgid \"Hello\"\nmsgstr \"Sehr geehrte\"\n", 5, `duplicate variant "de|register=formal" (first defined on line 1)`},
+		{catalog.XLIFF, "<xliff version=\"1.2\">\n<file>\n<body>\n<trans-unit id=\"greeting\">\n<target>Bonjour</target>\n</trans-unit></body></file></xliff>", 4, "translation unit has no target language"},
+	}
+
+	for _, tc := range testCases {
+		_, err := catalog.Decode(strings.NewReader(tc.input), tc.format)
+		require.Error(t, err, "expected an error decoding %s: %q", tc.format, tc.input)
+
+		var cerr *catalog.Error
+		require.True(t, errors.As(err, &cerr), "expected a catalog error decoding %s: %q", tc.format, tc.input)
+		require.Equal(t, tc.format, cerr.Format)
+		require.Equal(t, tc.line, cerr.Line, "wrong line for %s error %q", tc.format, err)
+		require.Equal(t, tc.msg, cerr.Msg, "wrong message for %s: %q", tc.format, tc.input)
+	}
+}
@@ -0,0 +1,161 @@
+package catalog
+
+import (
+	"bytes"
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+)
+
+// Columns of a CSV catalog. Without a header row a catalog has only the language and
+// greeting columns; the header row names the columns in any order and is required to
+// include the variant tags.
+const (
+	languageColumn = "language"
+	greetingColumn = greetingKey
+)
+
+var csvColumns = []string{languageColumn, greetingColumn, registerTag, timeTag, contextTag, weightTag}
+
+func decodeCSV(data []byte) (_ *entries, err error) {
+	reader := csv.NewReader(bytes.NewReader(data))
+	reader.FieldsPerRecord = -1
+	reader.Comment = '#'
+
+	catalog := newEntries(CSV)
+	columns := csvColumns[:2]
+	for first := true; ; first = false {
+		var record []string
+		if record, err = reader.Read(); err != nil {
+			if errors.Is(err, io.EOF) {
+				break
+			}
+
+			var perr *csv.ParseError
+			if errors.As(err, &perr) {
+				return nil, errorf(CSV, perr.Line, "%s", perr.Err)
+			}
+			return nil, err
+		}
+
+		line, _ := reader.FieldPos(0)
+		if first && strings.EqualFold(strings.TrimSpace(record[0]), languageColumn) {
+			if columns, err = csvHeader(record); err != nil {
+				return nil, errorf(CSV, line, "%s", err)
+			}
+			continue
+		}
+
+		if len(record) != len(columns) {
+			return nil, errorf(CSV, line, "wrong number of fields (expected %s)", strings.Join(columns, ", "))
+		}
+
+		var (
+			lang string
+			v    Variant
+		)
+		for i, column := range columns {
+			switch column {
+			case languageColumn:
+				lang = strings.TrimSpace(record[i])
+			case greetingColumn:
+				v.Greeting = record[i]
+			default:
+				// Empty tag columns leave the tag unset for any value
+				if value := strings.TrimSpace(record[i]); value != "" {
+					if err = v.set(column, value); err != nil {
+						return nil, errorf(CSV, line, "%s", err)
+					}
+				}
+			}
+		}
+
+		if err = catalog.add(lang, v, line); err != nil {
+			return nil, err
+		}
+	}
+	return catalog, nil
+}
+
+// Parse the names of the columns from the header row.
+func csvHeader(record []string) (columns []string, err error) {
+	seen := make(map[string]bool)
+	for _, name := range record {
+		name = strings.ToLower(strings.TrimSpace(name))
+		known := false
+		for _, column := range csvColumns {
+			known = known || name == column
+		}
+
+		if !known {
+			return nil, fmt.Errorf("unknown column %q", name)
+		}
+
+		if seen[name] {
+			return nil, fmt.Errorf("duplicate column %q", name)
+		}
+		seen[name] = true
+		columns = append(columns, name)
+	}
+
+	if !seen[greetingColumn] {
+		return nil, fmt.Errorf("missing %s column", greetingColumn)
+	}
+	return columns, nil
+}
+
+func encodeCSV(w io.Writer, messages Catalog) (err error) {
+	// Only include the tag columns that are used by the variants
+	used := make(map[string]bool)
+	for _, variants := range messages {
+		for _, v := range variants {
+			for _, tag := range v.tags() {
+				used[tag[0]] = true
+			}
+		}
+	}
+
+	columns := csvColumns[:2]
+	for _, column := range csvColumns[2:] {
+		if used[column] {
+			columns = append(columns, column)
+		}
+	}
+
+	writer := csv.NewWriter(w)
+	if err = writer.Write(columns); err != nil {
+		return err
+	}
+
+	for _, lang := range messages.Languages() {
+		for _, v := range messages[lang] {
+			record := []string{lang, v.Greeting}
+			for _, column := range columns[2:] {
+				switch column {
+				case registerTag:
+					record = append(record, v.Register)
+				case timeTag:
+					record = append(record, v.TimeOfDay)
+				case contextTag:
+					record = append(record, v.Context)
+				case weightTag:
+					if v.Weight != 0 {
+						record = append(record, strconv.Itoa(v.Weight))
+					} else {
+						record = append(record, "")
+					}
+				}
+			}
+
+			if err = writer.Write(record); err != nil {
+				return err
+			}
+		}
+	}
+
+	writer.Flush()
+	return writer.Error()
+}
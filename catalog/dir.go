@@ -0,0 +1,156 @@
+package catalog
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+)
+
+// Dir is a catalog split across the files in a directory, e.g. one file per locale such
+// as locales/fr.json and locales/de.po, so that translators can work on their own files
+// in parallel. The files are merged into a single catalog and a language that is
+// defined in more than one file is an error.
+//
+// The format of each file is determined by its extension and files with an unknown
+// extension are ignored, unless the format of the directory is specified in which case
+// every file is read in that format. Hidden files are always ignored.
+type Dir struct {
+	path     string
+	format   Format
+	files    map[string]*dirFile
+	messages Catalog
+}
+
+// The state of a file when it was last read, used to detect changes.
+type dirFile struct {
+	format  Format
+	modTime time.Time
+	size    int64
+	catalog *entries
+}
+
+// OpenDir reads and merges all of the catalog files in the directory.
+func OpenDir(path string, format Format) (d *Dir, err error) {
+	d = &Dir{path: path, format: format}
+	if _, err = d.Reload(); err != nil {
+		return nil, err
+	}
+	return d, nil
+}
+
+// Path of the directory.
+func (d *Dir) Path() string {
+	return d.path
+}
+
+// Messages returns the merged catalog. The catalog must not be modified.
+func (d *Dir) Messages() Catalog {
+	return d.messages
+}
+
+// Files returns the sorted names of the catalog files in the directory.
+func (d *Dir) Files() []string {
+	names := make([]string, 0, len(d.files))
+	for name := range d.files {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// Reload reads only the files that have been added or modified since the directory was
+// last read and drops the files that have been removed, returning the sorted names of
+// the changed files. If any file cannot be read or the merged catalog is invalid the
+// directory is left unchanged.
+func (d *Dir) Reload() (changed []string, err error) {
+	var dirents []os.DirEntry
+	if dirents, err = os.ReadDir(d.path); err != nil {
+		return nil, err
+	}
+
+	files := make(map[string]*dirFile, len(dirents))
+	for _, dirent := range dirents {
+		name := dirent.Name()
+		if dirent.IsDir() || strings.HasPrefix(name, ".") {
+			continue
+		}
+
+		format := d.format
+		if format == "" {
+			if format, err = FormatOf(name); err != nil {
+				continue
+			}
+		}
+
+		var info os.FileInfo
+		if info, err = dirent.Info(); err != nil {
+			return nil, err
+		}
+
+		// Modified files are detected by their modification time and size
+		if prev, ok := d.files[name]; ok && prev.format == format && prev.modTime.Equal(info.ModTime()) && prev.size == info.Size() {
+			files[name] = prev
+			continue
+		}
+
+		file := &dirFile{format: format, modTime: info.ModTime(), size: info.Size()}
+		if file.catalog, err = readFile(filepath.Join(d.path, name), format); err != nil {
+			return nil, err
+		}
+
+		files[name] = file
+		changed = append(changed, name)
+	}
+
+	for name := range d.files {
+		if _, ok := files[name]; !ok {
+			changed = append(changed, name)
+		}
+	}
+
+	// Nothing to merge if the files have not changed since the last reload
+	if len(changed) == 0 && d.messages != nil {
+		return nil, nil
+	}
+
+	var messages Catalog
+	if messages, err = d.merge(files); err != nil {
+		return nil, err
+	}
+
+	d.files, d.messages = files, messages
+	sort.Strings(changed)
+	return changed, nil
+}
+
+// Merge the files in name order so that duplicates are reported deterministically.
+func (d *Dir) merge(files map[string]*dirFile) (Catalog, error) {
+	names := make([]string, 0, len(files))
+	for name := range files {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	messages := make(Catalog)
+	defined := make(map[string]string)
+	for _, name := range names {
+		file := files[name]
+		for _, lang := range file.catalog.catalog.Languages() {
+			if first, ok := defined[lang]; ok {
+				return nil, &Error{
+					Path:   filepath.Join(d.path, name),
+					Format: file.format,
+					Line:   file.catalog.lines[lang],
+					Msg:    fmt.Sprintf("duplicate language %q (first defined in %s:%d)", lang, filepath.Join(d.path, first), files[first].catalog.lines[lang]),
+				}
+			}
+
+			messages[lang] = file.catalog.catalog[lang]
+			defined[lang] = name
+		}
+	}
+	return messages, nil
+}
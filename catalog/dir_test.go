@@ -0,0 +1,74 @@
+package catalog_test
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/pdeziel/grpc-example/catalog"
+	"github.com/stretchr/testify/require"
+)
+
+// Write the file with a modification time in the future so that changes are detected
+// regardless of the resolution of the filesystem clock.
+func writeFile(t *testing.T, path, data string, age time.Duration) {
+	require.NoError(t, os.WriteFile(path, []byte(data), 0644))
+	mod := time.Now().Add(age)
+	require.NoError(t, os.Chtimes(path, mod, mod))
+}
+
+func TestDir(t *testing.T) {
+	dir := t.TempDir()
+	writeFile(t, filepath.Join(dir, "en.json"), `{"en": "Hello"}`, 0)
+	writeFile(t, filepath.Join(dir, "fr.po"), "msgid \"\"\nmsgstr \"Language: fr\\n\"\n\nmsgid \"Hello\"\nmsgstr \"Bonjour\"\n", 0)
+	writeFile(t, filepath.Join(dir, "de.yaml"), "de: Hallo\nde-AT: Servus\n", 0)
+	writeFile(t, filepath.Join(dir, "README.md"), "# Locales", 0)
+	writeFile(t, filepath.Join(dir, ".fr.po.swp"), "garbage", 0)
+	require.NoError(t, os.Mkdir(filepath.Join(dir, "drafts"), 0755))
+
+	locales, err := catalog.OpenDir(dir, "")
+	require.NoError(t, err, "could not open the directory")
+	require.Equal(t, []string{"de.yaml", "en.json", "fr.po"}, locales.Files())
+	require.Equal(t, catalog.Flat(map[string]string{"en": "Hello", "fr": "Bonjour", "de": "Hallo", "de-AT": "Servus"}), locales.Messages())
+
+	changed, err := locales.Reload()
+	require.NoError(t, err)
+	require.Empty(t, changed, "expected no changes without modifying the files")
+
+	// Only the modified, added and removed files are changed
+	writeFile(t, filepath.Join(dir, "fr.po"), "msgid \"\"\nmsgstr \"Language: fr\\n\"\n\nmsgid \"Hello\"\nmsgstr \"Salut\"\n", time.Minute)
+	writeFile(t, filepath.Join(dir, "es.csv"), "es,Hola\n", time.Minute)
+	require.NoError(t, os.Remove(filepath.Join(dir, "de.yaml")))
+
+	changed, err = locales.Reload()
+	require.NoError(t, err)
+	require.Equal(t, []string{"de.yaml", "es.csv", "fr.po"}, changed)
+	require.Equal(t, catalog.Flat(map[string]string{"en": "Hello", "fr": "Salut", "es": "Hola"}), locales.Messages())
+
+	// A language defined in two files is reported with both locations and the
+	// directory is left unchanged
+	writeFile(t, filepath.Join(dir, "fr-ca.toml"), "fr-CA = \"Allô\"\nfr = \"Bonjour\"\n", 2*time.Minute)
+	_, err = locales.Reload()
+	require.EqualError(t, err, filepath.Join(dir, "fr.po")+`:4: duplicate language "fr" (first defined in `+filepath.Join(dir, "fr-ca.toml")+`:2)`)
+	require.Equal(t, catalog.Flat(map[string]string{"en": "Hello", "fr": "Salut", "es": "Hola"}), locales.Messages())
+
+	// Errors in a file are reported until the file is fixed
+	writeFile(t, filepath.Join(dir, "fr-ca.toml"), "fr-CA = Allô\n", 3*time.Minute)
+	_, err = locales.Reload()
+	require.EqualError(t, err, filepath.Join(dir, "fr-ca.toml")+`:1: invalid greeting for "fr-CA": greetings must be strings`)
+
+	writeFile(t, filepath.Join(dir, "fr-ca.toml"), "fr-CA = \"Allô\"\n", 4*time.Minute)
+	changed, err = locales.Reload()
+	require.NoError(t, err)
+	require.Equal(t, []string{"fr-ca.toml"}, changed)
+	require.Equal(t, []catalog.Variant{{Greeting: "Allô"}}, locales.Messages()["fr-CA"])
+
+	// A format for the directory reads every file in that format
+	writeFile(t, filepath.Join(dir, "README.md"), `{"x-readme": "Hi"}`, 0)
+	_, err = catalog.OpenDir(dir, catalog.JSON)
+	require.Error(t, err, "expected the non-JSON files to fail")
+
+	_, err = catalog.OpenDir(filepath.Join(dir, "missing"), "")
+	require.ErrorIs(t, err, os.ErrNotExist)
+}
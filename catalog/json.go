@@ -0,0 +1,229 @@
+package catalog
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"io"
+)
+
+// Key of the greeting of a variant object in JSON and YAML catalogs.
+const greetingKey = "greeting"
+
+// JSON is decoded token by token to report the line of invalid and duplicate entries.
+func decodeJSON(data []byte) (_ *entries, err error) {
+	d := &jsonDecoder{data: data, dec: json.NewDecoder(bytes.NewReader(data))}
+	d.dec.UseNumber()
+
+	var tok json.Token
+	if tok, err = d.token(); err != nil {
+		return nil, err
+	}
+
+	if delim, ok := tok.(json.Delim); !ok || delim != '{' {
+		return nil, errorf(JSON, d.line(), "expected an object of language codes to greetings")
+	}
+
+	catalog := newEntries(JSON)
+	for d.dec.More() {
+		if tok, err = d.token(); err != nil {
+			return nil, err
+		}
+		lang := tok.(string)
+		line := d.line()
+
+		if err = catalog.declare(lang, line); err != nil {
+			return nil, err
+		}
+
+		if tok, err = d.token(); err != nil {
+			return nil, err
+		}
+
+		switch tok := tok.(type) {
+		case string:
+			err = catalog.add(lang, Variant{Greeting: tok}, line)
+		case json.Delim:
+			if tok == '[' {
+				err = d.variants(catalog, lang)
+			} else {
+				err = d.variant(catalog, lang, line)
+			}
+		default:
+			err = errorf(JSON, line, "greeting for %q must be a string or a list of variants", lang)
+		}
+
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	// Consume the closing brace and ensure there is nothing after the object
+	if _, err = d.token(); err != nil {
+		return nil, err
+	}
+
+	if _, err = d.dec.Token(); !errors.Is(err, io.EOF) {
+		return nil, errorf(JSON, d.line(), "unexpected data after the catalog")
+	}
+	return catalog, nil
+}
+
+type jsonDecoder struct {
+	data []byte
+	dec  *json.Decoder
+}
+
+// Read the next token, converting syntax errors into errors with the line number.
+func (d *jsonDecoder) token() (tok json.Token, err error) {
+	if tok, err = d.dec.Token(); err != nil {
+		offset := d.dec.InputOffset()
+		var serr *json.SyntaxError
+		if errors.As(err, &serr) {
+			offset = serr.Offset
+		}
+		return nil, errorf(JSON, lineOf(d.data, offset), "%s", err)
+	}
+	return tok, nil
+}
+
+func (d *jsonDecoder) line() int {
+	return lineOf(d.data, d.dec.InputOffset())
+}
+
+// Decode a list of variants after its opening bracket, each a string or an object.
+func (d *jsonDecoder) variants(catalog *entries, lang string) (err error) {
+	for d.dec.More() {
+		var tok json.Token
+		if tok, err = d.token(); err != nil {
+			return err
+		}
+
+		line := d.line()
+		switch tok := tok.(type) {
+		case string:
+			err = catalog.add(lang, Variant{Greeting: tok}, line)
+		case json.Delim:
+			if tok != '{' {
+				return errorf(JSON, line, "variant of %q must be a string or an object", lang)
+			}
+			err = d.variant(catalog, lang, line)
+		default:
+			return errorf(JSON, line, "variant of %q must be a string or an object", lang)
+		}
+
+		if err != nil {
+			return err
+		}
+	}
+
+	_, err = d.token()
+	return err
+}
+
+// Decode a variant object after its opening brace.
+func (d *jsonDecoder) variant(catalog *entries, lang string, line int) (err error) {
+	var (
+		v        Variant
+		greeting bool
+		tok      json.Token
+	)
+
+	for d.dec.More() {
+		if tok, err = d.token(); err != nil {
+			return err
+		}
+		key := tok.(string)
+
+		if tok, err = d.token(); err != nil {
+			return err
+		}
+
+		// Weights are numbers but may also be strings like the other tags
+		val, ok := tok.(string)
+		if num, isNum := tok.(json.Number); isNum && key == weightTag {
+			val, ok = num.String(), true
+		}
+
+		if !ok {
+			return errorf(JSON, d.line(), "%s of variant of %q must be a string", key, lang)
+		}
+
+		if key == greetingKey {
+			v.Greeting, greeting = val, true
+			continue
+		}
+
+		if err = v.set(key, val); err != nil {
+			return errorf(JSON, d.line(), "%s in variant of %q", err, lang)
+		}
+	}
+
+	if _, err = d.token(); err != nil {
+		return err
+	}
+
+	if !greeting {
+		return errorf(JSON, line, "variant of %q has no greeting", lang)
+	}
+	return catalog.add(lang, v, line)
+}
+
+func encodeJSON(w io.Writer, messages Catalog) error {
+	buf := &bytes.Buffer{}
+	buf.WriteString("{\n")
+	for i, lang := range messages.Languages() {
+		key, _ := marshalString(lang)
+		buf.WriteString("    ")
+		buf.Write(key)
+		buf.WriteString(": ")
+
+		variants := messages[lang]
+		if len(variants) == 1 && !variants[0].Tagged() {
+			val, _ := marshalString(variants[0].Greeting)
+			buf.Write(val)
+		} else {
+			buf.WriteString("[\n")
+			for j, v := range variants {
+				val, _ := marshalString(v.Greeting)
+				buf.WriteString("        {\"" + greetingKey + "\": ")
+				buf.Write(val)
+				for _, tag := range v.tags() {
+					buf.WriteString(", \"" + tag[0] + "\": ")
+					if tag[0] == weightTag {
+						buf.WriteString(tag[1])
+						continue
+					}
+					val, _ = marshalString(tag[1])
+					buf.Write(val)
+				}
+				buf.WriteByte('}')
+				if j < len(variants)-1 {
+					buf.WriteByte(',')
+				}
+				buf.WriteByte('\n')
+			}
+			buf.WriteString("    ]")
+		}
+
+		if i < len(messages)-1 {
+			buf.WriteByte(',')
+		}
+		buf.WriteByte('\n')
+	}
+	buf.WriteString("}\n")
+
+	_, err := w.Write(buf.Bytes())
+	return err
+}
+
+// Marshal a string without escaping HTML characters.
+func marshalString(s string) ([]byte, error) {
+	buf := &bytes.Buffer{}
+	enc := json.NewEncoder(buf)
+	enc.SetEscapeHTML(false)
+	if err := enc.Encode(s); err != nil {
+		return nil, err
+	}
+	return bytes.TrimRight(buf.Bytes(), "\n"), nil
+}
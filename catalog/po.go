@@ -0,0 +1,224 @@
+package catalog
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+)
+
+// A gettext entry being parsed from a PO file.
+type poEntry struct {
+	line    int
+	fuzzy   bool
+	ctxt    *string
+	id      *string
+	str     *string
+	current *string
+}
+
+func (e *poEntry) empty() bool {
+	return e.ctxt == nil && e.id == nil && e.str == nil
+}
+
+// PO files are either exported per language, where the Language header identifies the
+// language of every entry, or contain every language with the language code as the
+// msgctxt of its entry. The msgctxt may also have the tags of the variant, e.g.
+// "de|register=formal" or just "register=formal" in a per language file. Fuzzy and
+// untranslated entries are skipped.
+func decodePO(data []byte) (_ *entries, err error) {
+	var (
+		language string
+		entry    = &poEntry{}
+		catalog  = newEntries(PO)
+	)
+
+	flush := func() error {
+		defer func() { entry = &poEntry{} }()
+		if entry.empty() {
+			return nil
+		}
+
+		if entry.id == nil {
+			return errorf(PO, entry.line, "entry is missing msgid")
+		}
+
+		if entry.str == nil {
+			return errorf(PO, entry.line, "entry is missing msgstr")
+		}
+
+		// The header entry has an empty msgid and carries the file's metadata
+		if *entry.id == "" && entry.ctxt == nil {
+			for _, header := range strings.Split(*entry.str, "\n") {
+				if key, val, ok := strings.Cut(header, ":"); ok && strings.TrimSpace(key) == "Language" {
+					language = strings.TrimSpace(val)
+				}
+			}
+			return nil
+		}
+
+		if entry.fuzzy || *entry.str == "" {
+			return nil
+		}
+
+		var (
+			lang string
+			v    Variant
+			key  string
+			err  error
+		)
+		if entry.ctxt != nil {
+			key = *entry.ctxt
+			if lang, v, err = parseKey(key); err != nil {
+				return errorf(PO, entry.line, "invalid msgctxt: %s", err)
+			}
+		}
+
+		if lang == "" {
+			if language == "" {
+				return errorf(PO, entry.line, "entry has no msgctxt and the file has no Language header")
+			}
+
+			lang = language
+			if key == "" {
+				key = lang
+			} else {
+				key = lang + "|" + key
+			}
+		}
+
+		if err = catalog.claim(key, entry.line); err != nil {
+			return err
+		}
+
+		v.Greeting = *entry.str
+		return catalog.add(lang, v, entry.line)
+	}
+
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	for line := 1; scanner.Scan(); line++ {
+		text := strings.TrimSpace(scanner.Text())
+		switch {
+		case text == "":
+			if err = flush(); err != nil {
+				return nil, err
+			}
+
+		case strings.HasPrefix(text, "#"):
+			// Obsolete entries (#~) and comments are ignored apart from the fuzzy flag
+			if strings.HasPrefix(text, "#,") && strings.Contains(text, "fuzzy") {
+				if entry.str != nil {
+					if err = flush(); err != nil {
+						return nil, err
+					}
+				}
+				entry.fuzzy = true
+			}
+
+		case strings.HasPrefix(text, `"`):
+			if entry.current == nil {
+				return nil, errorf(PO, line, "string continuation without a keyword")
+			}
+
+			var s string
+			if s, err = poUnquote(text); err != nil {
+				return nil, errorf(PO, line, "%s", err)
+			}
+			*entry.current += s
+
+		default:
+			keyword, rest, _ := strings.Cut(text, " ")
+			var s string
+			if s, err = poUnquote(strings.TrimSpace(rest)); err != nil {
+				return nil, errorf(PO, line, "%s", err)
+			}
+
+			// A new msgctxt or msgid after a msgstr starts the next entry
+			if (keyword == "msgctxt" || keyword == "msgid") && entry.str != nil {
+				if err = flush(); err != nil {
+					return nil, err
+				}
+			}
+
+			if entry.empty() {
+				entry.line = line
+			}
+
+			switch keyword {
+			case "msgctxt":
+				if entry.ctxt != nil || entry.id != nil {
+					return nil, errorf(PO, line, "unexpected msgctxt")
+				}
+				entry.ctxt, entry.current = &s, &s
+			case "msgid":
+				if entry.id != nil {
+					return nil, errorf(PO, line, "duplicate msgid in entry")
+				}
+				entry.id, entry.current = &s, &s
+			case "msgid_plural":
+				if entry.id == nil {
+					return nil, errorf(PO, line, "msgid_plural without msgid")
+				}
+				entry.current = new(string)
+			case "msgstr", "msgstr[0]":
+				if entry.id == nil {
+					return nil, errorf(PO, line, "%s without msgid", keyword)
+				}
+				entry.str, entry.current = &s, &s
+			default:
+				if strings.HasPrefix(keyword, "msgstr[") && entry.str != nil {
+					// Only the singular form of a plural entry is used as the greeting
+					entry.current = new(string)
+					continue
+				}
+				return nil, errorf(PO, line, "unknown keyword %q", keyword)
+			}
+		}
+	}
+
+	if err = scanner.Err(); err != nil {
+		return nil, err
+	}
+
+	if err = flush(); err != nil {
+		return nil, err
+	}
+	return catalog, nil
+}
+
+func poUnquote(s string) (string, error) {
+	if len(s) < 2 || s[0] != '"' || s[len(s)-1] != '"' {
+		return "", fmt.Errorf("expected a quoted string")
+	}
+
+	val, err := strconv.Unquote(s)
+	if err != nil {
+		return "", fmt.Errorf("invalid string %s", s)
+	}
+	return val, nil
+}
+
+func poQuote(s string) string {
+	replacer := strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`, "\t", `\t`, "\r", `\r`)
+	return `"` + replacer.Replace(s) + `"`
+}
+
+func encodePO(w io.Writer, messages Catalog) error {
+	buf := &bytes.Buffer{}
+	buf.WriteString("msgid \"\"\nmsgstr \"\"\n")
+	buf.WriteString("\"Content-Type: text/plain; charset=UTF-8\\n\"\n")
+	fmt.Fprintf(buf, "\"X-Source-Language: %s\\n\"\n", SourceLanguage)
+
+	msgid := poQuote(source(messages))
+	for _, lang := range messages.Languages() {
+		keys := variantKeys(lang, messages[lang])
+		for i, v := range messages[lang] {
+			fmt.Fprintf(buf, "\nmsgctxt %s\nmsgid %s\nmsgstr %s\n", poQuote(keys[i]), msgid, poQuote(v.Greeting))
+		}
+	}
+
+	_, err := w.Write(buf.Bytes())
+	return err
+}
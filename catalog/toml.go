@@ -0,0 +1,310 @@
+package catalog
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"io"
+	"regexp"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+)
+
+// A catalog only needs the subset of TOML with top level key/value pairs of strings and
+// arrays of tables for the variants of a language (with an integer weight), so rather
+// than depending on a full TOML library they are parsed directly. Other tables and
+// value types are reported as errors.
+var (
+	bareKey     = regexp.MustCompile(`^[A-Za-z0-9_-]+$`)
+	tomlInteger = regexp.MustCompile(`^[0-9]+`)
+)
+
+func decodeTOML(data []byte) (_ *entries, err error) {
+	var (
+		catalog = newEntries(TOML)
+		scanner = bufio.NewScanner(bytes.NewReader(data))
+		tables  = make(map[string]bool)
+		table   *tomlTable
+	)
+
+	// Add the variant of the table that has ended
+	finish := func() error {
+		if table == nil {
+			return nil
+		}
+
+		if _, ok := table.keys[greetingKey]; !ok {
+			return errorf(TOML, table.line, "variant of %q has no greeting", table.lang)
+		}
+		return catalog.add(table.lang, table.variant, table.line)
+	}
+
+	for line := 1; scanner.Scan(); line++ {
+		text := strings.TrimSpace(scanner.Text())
+		if text == "" || strings.HasPrefix(text, "#") {
+			continue
+		}
+
+		if strings.HasPrefix(text, "[") {
+			if err = finish(); err != nil {
+				return nil, err
+			}
+
+			var lang string
+			if lang, err = tomlHeader(text); err != nil {
+				return nil, errorf(TOML, line, "%s", err)
+			}
+
+			// The first table of a language declares it, the others are more variants
+			if !tables[lang] {
+				if err = catalog.declare(lang, line); err != nil {
+					return nil, err
+				}
+				tables[lang] = true
+			}
+
+			table = &tomlTable{lang: lang, line: line, keys: make(map[string]int)}
+			continue
+		}
+
+		var key, rest string
+		if key, rest, err = tomlString(text, true); err != nil {
+			return nil, errorf(TOML, line, "invalid key: %s", err)
+		}
+
+		rest = strings.TrimSpace(rest)
+		if !strings.HasPrefix(rest, "=") {
+			return nil, errorf(TOML, line, "expected = after key %q", key)
+		}
+
+		// The weight of a variant is the only value that may be an integer
+		var val string
+		rest = strings.TrimSpace(rest[1:])
+		if num := tomlInteger.FindString(rest); num != "" && table != nil && key == weightTag {
+			val, rest = num, rest[len(num):]
+		} else if val, rest, err = tomlString(rest, false); err != nil {
+			switch {
+			case table != nil && key == weightTag:
+				return nil, errorf(TOML, line, "invalid weight of variant of %q: expected a positive integer", table.lang)
+			case table != nil:
+				return nil, errorf(TOML, line, "invalid %s of variant of %q: %s", key, table.lang, err)
+			}
+			return nil, errorf(TOML, line, "invalid greeting for %q: %s", key, err)
+		}
+
+		if rest = strings.TrimSpace(rest); rest != "" && !strings.HasPrefix(rest, "#") {
+			return nil, errorf(TOML, line, "unexpected %q after greeting for %q", rest, key)
+		}
+
+		if table == nil {
+			if err = catalog.declare(key, line); err != nil {
+				return nil, err
+			}
+
+			if err = catalog.add(key, Variant{Greeting: val}, line); err != nil {
+				return nil, err
+			}
+			continue
+		}
+
+		if prev, ok := table.keys[key]; ok {
+			return nil, errorf(TOML, line, "duplicate key %q (first defined on line %d)", key, prev)
+		}
+		table.keys[key] = line
+
+		if key == greetingKey {
+			table.variant.Greeting = val
+		} else if err = table.variant.set(key, val); err != nil {
+			return nil, errorf(TOML, line, "%s in variant of %q", err, table.lang)
+		}
+	}
+
+	if err = scanner.Err(); err != nil {
+		return nil, err
+	}
+
+	if err = finish(); err != nil {
+		return nil, err
+	}
+	return catalog, nil
+}
+
+// A table in the array of tables of the variants of a language.
+type tomlTable struct {
+	lang    string
+	line    int
+	keys    map[string]int
+	variant Variant
+}
+
+// Parse the language code from an array of tables header such as [[de]].
+func tomlHeader(text string) (lang string, err error) {
+	if !strings.HasPrefix(text, "[[") {
+		return "", fmt.Errorf("tables are not supported in a catalog, use an array of tables for variants")
+	}
+
+	end := strings.LastIndex(text, "]]")
+	if end < 2 {
+		return "", fmt.Errorf("unterminated table header")
+	}
+
+	if rest := strings.TrimSpace(text[end+2:]); rest != "" && !strings.HasPrefix(rest, "#") {
+		return "", fmt.Errorf("unexpected %q after table header", rest)
+	}
+
+	var rest string
+	if lang, rest, err = tomlString(strings.TrimSpace(text[2:end]), true); err != nil {
+		return "", fmt.Errorf("invalid table name: %s", err)
+	}
+
+	if strings.TrimSpace(rest) != "" {
+		return "", fmt.Errorf("dotted table names are not supported in a catalog")
+	}
+	return lang, nil
+}
+
+// Parse a basic or literal string (or a bare key if key is true) from the start of s,
+// returning the value and the remainder of s.
+func tomlString(s string, key bool) (val, rest string, err error) {
+	if s == "" {
+		return "", "", fmt.Errorf("missing value")
+	}
+
+	switch s[0] {
+	case '\'':
+		end := strings.IndexByte(s[1:], '\'')
+		if end < 0 {
+			return "", "", fmt.Errorf("unterminated literal string")
+		}
+		return s[1 : end+1], s[end+2:], nil
+
+	case '"':
+		buf := &strings.Builder{}
+		for i := 1; i < len(s); i++ {
+			switch c := s[i]; c {
+			case '"':
+				return buf.String(), s[i+1:], nil
+			case '\\':
+				if i+1 >= len(s) {
+					return "", "", fmt.Errorf("unterminated string")
+				}
+
+				i++
+				switch s[i] {
+				case 'b':
+					buf.WriteByte('\b')
+				case 't':
+					buf.WriteByte('\t')
+				case 'n':
+					buf.WriteByte('\n')
+				case 'f':
+					buf.WriteByte('\f')
+				case 'r':
+					buf.WriteByte('\r')
+				case '"', '\\':
+					buf.WriteByte(s[i])
+				case 'u', 'U':
+					size := 4
+					if s[i] == 'U' {
+						size = 8
+					}
+
+					if i+size >= len(s) {
+						return "", "", fmt.Errorf("invalid unicode escape")
+					}
+
+					var code uint64
+					if code, err = strconv.ParseUint(s[i+1:i+1+size], 16, 32); err != nil || !utf8.ValidRune(rune(code)) {
+						return "", "", fmt.Errorf("invalid unicode escape \\%s", s[i:i+1+size])
+					}
+					buf.WriteRune(rune(code))
+					i += size
+				default:
+					return "", "", fmt.Errorf("invalid escape \\%c", s[i])
+				}
+			default:
+				buf.WriteByte(c)
+			}
+		}
+		return "", "", fmt.Errorf("unterminated string")
+
+	default:
+		if !key {
+			return "", "", fmt.Errorf("greetings must be strings")
+		}
+
+		end := strings.IndexAny(s, " \t=")
+		if end < 0 {
+			end = len(s)
+		}
+
+		if !bareKey.MatchString(s[:end]) {
+			return "", "", fmt.Errorf("%q is not a valid bare key", s[:end])
+		}
+		return s[:end], s[end:], nil
+	}
+}
+
+func encodeTOML(w io.Writer, messages Catalog) error {
+	key := func(s string) string {
+		if bareKey.MatchString(s) {
+			return s
+		}
+		return tomlQuote(s)
+	}
+
+	// Languages with variants are written as tables after all of the top level keys
+	var tables []string
+	buf := &bytes.Buffer{}
+	for _, lang := range messages.Languages() {
+		variants := messages[lang]
+		if len(variants) == 1 && !variants[0].Tagged() {
+			fmt.Fprintf(buf, "%s = %s\n", key(lang), tomlQuote(variants[0].Greeting))
+			continue
+		}
+		tables = append(tables, lang)
+	}
+
+	for _, lang := range tables {
+		for _, v := range messages[lang] {
+			if buf.Len() > 0 {
+				buf.WriteByte('\n')
+			}
+			fmt.Fprintf(buf, "[[%s]]\n%s = %s\n", key(lang), greetingKey, tomlQuote(v.Greeting))
+			for _, tag := range v.tags() {
+				if tag[0] == weightTag {
+					fmt.Fprintf(buf, "%s = %s\n", tag[0], tag[1])
+					continue
+				}
+				fmt.Fprintf(buf, "%s = %s\n", tag[0], tomlQuote(tag[1]))
+			}
+		}
+	}
+
+	_, err := w.Write(buf.Bytes())
+	return err
+}
+
+// Quote a TOML basic string, escaping only the characters that TOML requires.
+func tomlQuote(s string) string {
+	buf := &strings.Builder{}
+	buf.WriteByte('"')
+	for _, r := range s {
+		switch {
+		case r == '"' || r == '\\':
+			buf.WriteByte('\\')
+			buf.WriteRune(r)
+		case r == '\n':
+			buf.WriteString(`\n`)
+		case r == '\t':
+			buf.WriteString(`\t`)
+		case r < 0x20 || r == 0x7f:
+			fmt.Fprintf(buf, `\u%04X`, r)
+		default:
+			buf.WriteRune(r)
+		}
+	}
+	buf.WriteByte('"')
+	return buf.String()
+}
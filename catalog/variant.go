@@ -0,0 +1,202 @@
+package catalog
+
+import (
+	"fmt"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// Catalog maps language codes to the variants of the greeting in that language.
+type Catalog map[string][]Variant
+
+// Variant is one of the greetings for a language. Its tags describe when the greeting
+// is appropriate; an empty tag means that the variant is suitable for any value.
+type Variant struct {
+	Greeting  string
+	Register  string // formal or informal
+	TimeOfDay string // morning, afternoon, evening or night
+	Context   string // an application specific context such as onboarding or email
+	Weight    int    // relative weight when choosing among equally suitable variants
+}
+
+// Registers and times of day that variants may be tagged with.
+const (
+	Formal   = "formal"
+	Informal = "informal"
+
+	Morning   = "morning"
+	Afternoon = "afternoon"
+	Evening   = "evening"
+	Night     = "night"
+)
+
+// Names of the variant tags in catalog files.
+const (
+	registerTag = "register"
+	timeTag     = "time"
+	contextTag  = "context"
+	weightTag   = "weight"
+	ordinalTag  = "n"
+)
+
+var contextName = regexp.MustCompile(`^[A-Za-z0-9_.-]*$`)
+
+// Flat creates a catalog with a single untagged variant for each language from a map
+// of language codes to greetings, i.e. the original messages.json layout.
+func Flat(messages map[string]string) Catalog {
+	c := make(Catalog, len(messages))
+	for lang, greeting := range messages {
+		c[lang] = []Variant{{Greeting: greeting}}
+	}
+	return c
+}
+
+// Languages returns the sorted language codes in the catalog.
+func (c Catalog) Languages() []string {
+	langs := make([]string, 0, len(c))
+	for lang := range c {
+		langs = append(langs, lang)
+	}
+	sort.Strings(langs)
+	return langs
+}
+
+// Clone returns a deep copy of the catalog.
+func (c Catalog) Clone() Catalog {
+	clone := make(Catalog, len(c))
+	for lang, variants := range c {
+		clone[lang] = append([]Variant(nil), variants...)
+	}
+	return clone
+}
+
+// Tagged returns true if any of the tags of the variant, including its weight, are set.
+func (v Variant) Tagged() bool {
+	return v.Register != "" || v.TimeOfDay != "" || v.Context != "" || v.Weight != 0
+}
+
+// Weighted returns the weight of the variant, which defaults to 1 if it is not set.
+func (v Variant) Weighted() int {
+	if v.Weight <= 0 {
+		return 1
+	}
+	return v.Weight
+}
+
+// Check that the tags of the variant have valid values.
+func (v Variant) validate() error {
+	switch v.Register {
+	case "", Formal, Informal:
+	default:
+		return fmt.Errorf("invalid register %q (expected %s or %s)", v.Register, Formal, Informal)
+	}
+
+	switch v.TimeOfDay {
+	case "", Morning, Afternoon, Evening, Night:
+	default:
+		return fmt.Errorf("invalid time of day %q (expected %s, %s, %s or %s)", v.TimeOfDay, Morning, Afternoon, Evening, Night)
+	}
+
+	if !contextName.MatchString(v.Context) {
+		return fmt.Errorf("invalid context %q (expected letters, digits, '.', '_' or '-')", v.Context)
+	}
+
+	if v.Weight < 0 {
+		return fmt.Errorf("invalid weight %d (expected a positive integer)", v.Weight)
+	}
+	return nil
+}
+
+// Set the tag of a variant by name.
+func (v *Variant) set(tag, value string) error {
+	switch tag {
+	case registerTag:
+		v.Register = value
+	case timeTag:
+		v.TimeOfDay = value
+	case contextTag:
+		v.Context = value
+	case weightTag:
+		weight, err := strconv.Atoi(value)
+		if err != nil || weight < 1 {
+			return fmt.Errorf("invalid weight %q (expected a positive integer)", value)
+		}
+		v.Weight = weight
+	default:
+		return fmt.Errorf("unknown tag %q", tag)
+	}
+	return nil
+}
+
+// The tags of a variant as name/value pairs in a fixed order.
+func (v Variant) tags() [][2]string {
+	var tags [][2]string
+	if v.Register != "" {
+		tags = append(tags, [2]string{registerTag, v.Register})
+	}
+	if v.TimeOfDay != "" {
+		tags = append(tags, [2]string{timeTag, v.TimeOfDay})
+	}
+	if v.Context != "" {
+		tags = append(tags, [2]string{contextTag, v.Context})
+	}
+	if v.Weight != 0 {
+		tags = append(tags, [2]string{weightTag, strconv.Itoa(v.Weight)})
+	}
+	return tags
+}
+
+// Formats that identify a variant by a single string, such as the msgctxt of a PO
+// entry, use the language code followed by the tags separated by pipes, e.g.
+// "de|register=formal|time=morning". The ordinal tag n distinguishes variants that
+// would otherwise have the same key and is ignored when the key is parsed.
+func formatKey(lang string, v Variant, ordinal int) string {
+	parts := []string{lang}
+	for _, tag := range v.tags() {
+		parts = append(parts, tag[0]+"="+tag[1])
+	}
+
+	if ordinal > 1 {
+		parts = append(parts, fmt.Sprintf("%s=%d", ordinalTag, ordinal))
+	}
+	return strings.Join(parts, "|")
+}
+
+// Parse a key into its language code, which is empty if the key only has tags, and
+// a variant with the tags set.
+func parseKey(key string) (lang string, v Variant, err error) {
+	parts := strings.Split(key, "|")
+	if !strings.Contains(parts[0], "=") {
+		lang, parts = parts[0], parts[1:]
+	}
+
+	for _, part := range parts {
+		tag, value, ok := strings.Cut(part, "=")
+		if !ok {
+			return "", v, fmt.Errorf("invalid tag %q (expected name=value)", part)
+		}
+
+		if tag == ordinalTag {
+			continue
+		}
+
+		if err = v.set(tag, value); err != nil {
+			return "", v, err
+		}
+	}
+	return lang, v, nil
+}
+
+// Keys of the variants of a language, with ordinals added to keys that repeat.
+func variantKeys(lang string, variants []Variant) []string {
+	keys := make([]string, len(variants))
+	seen := make(map[string]int)
+	for i, v := range variants {
+		base := formatKey(lang, v, 0)
+		seen[base]++
+		keys[i] = formatKey(lang, v, seen[base])
+	}
+	return keys
+}
@@ -0,0 +1,167 @@
+package catalog
+
+import (
+	"bytes"
+	"encoding/xml"
+	"errors"
+	"io"
+	"strings"
+)
+
+// XLIFF 1.2 documents identify the language of each translation unit by the
+// target-language of its enclosing file, so a catalog is written as one file element
+// per language. XLIFF 2.0 documents, which have a single trgLang, are also read. The
+// variants of a language are separate units whose ids have the tags of the variant,
+// e.g. "greeting|register=formal".
+func decodeXLIFF(data []byte) (_ *entries, err error) {
+	var (
+		language string
+		unit     int
+		id       string
+		target   *strings.Builder
+		catalog  = newEntries(XLIFF)
+		dec      = xml.NewDecoder(bytes.NewReader(data))
+	)
+
+	for {
+		var tok xml.Token
+		if tok, err = dec.Token(); err != nil {
+			if errors.Is(err, io.EOF) {
+				break
+			}
+
+			var serr *xml.SyntaxError
+			if errors.As(err, &serr) {
+				return nil, errorf(XLIFF, serr.Line, "%s", serr.Msg)
+			}
+			line, _ := dec.InputPos()
+			return nil, errorf(XLIFF, line, "%s", err)
+		}
+
+		line, _ := dec.InputPos()
+		switch tok := tok.(type) {
+		case xml.StartElement:
+			switch tok.Name.Local {
+			case "xliff":
+				if lang := xliffAttr(tok, "trgLang"); lang != "" {
+					language = lang
+				}
+			case "file":
+				if lang := xliffAttr(tok, "target-language"); lang != "" {
+					language = lang
+				}
+			case "trans-unit", "unit":
+				if unit != 0 {
+					return nil, errorf(XLIFF, line, "nested %s", tok.Name.Local)
+				}
+				unit, id = line, xliffAttr(tok, "id")
+			case "target":
+				if unit == 0 {
+					return nil, errorf(XLIFF, line, "target outside of a translation unit")
+				}
+				target = &strings.Builder{}
+			}
+
+		case xml.CharData:
+			if target != nil {
+				target.Write(tok)
+			}
+
+		case xml.EndElement:
+			switch tok.Name.Local {
+			case "target":
+				if target != nil && language == "" {
+					return nil, errorf(XLIFF, unit, "translation unit has no target language")
+				}
+
+				if target != nil && target.Len() > 0 {
+					// The first part of the id names the unit, the rest are the tags
+					_, tags, _ := strings.Cut(id, "|")
+
+					var v Variant
+					if tags != "" {
+						if _, v, err = parseKey("|" + tags); err != nil {
+							return nil, errorf(XLIFF, unit, "invalid unit id: %s", err)
+						}
+					}
+
+					if err = catalog.claim(language+"|"+id, unit); err != nil {
+						return nil, err
+					}
+
+					v.Greeting = target.String()
+					if err = catalog.add(language, v, unit); err != nil {
+						return nil, err
+					}
+				}
+				target = nil
+			case "trans-unit", "unit":
+				unit = 0
+			}
+		}
+	}
+	return catalog, nil
+}
+
+func xliffAttr(el xml.StartElement, name string) string {
+	for _, attr := range el.Attr {
+		if attr.Name.Local == name {
+			return attr.Value
+		}
+	}
+	return ""
+}
+
+// The id of the unit of the untagged variant of a language.
+const xliffUnitID = "greeting"
+
+type xliffDocument struct {
+	XMLName xml.Name    `xml:"urn:oasis:names:tc:xliff:document:1.2 xliff"`
+	Version string      `xml:"version,attr"`
+	Files   []xliffFile `xml:"file"`
+}
+
+type xliffFile struct {
+	Original       string      `xml:"original,attr"`
+	SourceLanguage string      `xml:"source-language,attr"`
+	TargetLanguage string      `xml:"target-language,attr"`
+	Datatype       string      `xml:"datatype,attr"`
+	Units          []xliffUnit `xml:"body>trans-unit"`
+}
+
+type xliffUnit struct {
+	ID     string `xml:"id,attr"`
+	Source string `xml:"source"`
+	Target string `xml:"target"`
+}
+
+func encodeXLIFF(w io.Writer, messages Catalog) (err error) {
+	doc := &xliffDocument{Version: "1.2"}
+	src := source(messages)
+	for _, lang := range messages.Languages() {
+		file := xliffFile{
+			Original:       "messages",
+			SourceLanguage: SourceLanguage,
+			TargetLanguage: lang,
+			Datatype:       "plaintext",
+		}
+
+		for i, key := range variantKeys(xliffUnitID, messages[lang]) {
+			file.Units = append(file.Units, xliffUnit{ID: key, Source: src, Target: messages[lang][i].Greeting})
+		}
+		doc.Files = append(doc.Files, file)
+	}
+
+	if _, err = io.WriteString(w, xml.Header); err != nil {
+		return err
+	}
+
+	enc := xml.NewEncoder(w)
+	enc.Indent("", "  ")
+	if err = enc.Encode(doc); err != nil {
+		return err
+	}
+
+	_, err = io.WriteString(w, "\n")
+	return err
+}
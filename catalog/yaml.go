@@ -0,0 +1,140 @@
+package catalog
+
+import (
+	"io"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+var yamlLine = regexp.MustCompile(`line (\d+): `)
+
+func decodeYAML(data []byte) (_ *entries, err error) {
+	var doc yaml.Node
+	if err = yaml.Unmarshal(data, &doc); err != nil {
+		// Move the line number reported by the parser into the error
+		msg := strings.TrimPrefix(err.Error(), "yaml: ")
+		line := 0
+		if match := yamlLine.FindStringSubmatch(msg); match != nil {
+			line, _ = strconv.Atoi(match[1])
+			msg = strings.Replace(msg, match[0], "", 1)
+		}
+		return nil, errorf(YAML, line, "%s", msg)
+	}
+
+	catalog := newEntries(YAML)
+	if len(doc.Content) == 0 {
+		return catalog, nil
+	}
+
+	root := doc.Content[0]
+	if root.Kind != yaml.MappingNode {
+		return nil, errorf(YAML, root.Line, "expected a mapping of language codes to greetings")
+	}
+
+	for i := 0; i+1 < len(root.Content); i += 2 {
+		key, val := root.Content[i], root.Content[i+1]
+		if key.Kind != yaml.ScalarNode {
+			return nil, errorf(YAML, key.Line, "language code must be a string")
+		}
+
+		if err = catalog.declare(key.Value, key.Line); err != nil {
+			return nil, err
+		}
+
+		switch val.Kind {
+		case yaml.ScalarNode:
+			err = catalog.add(key.Value, Variant{Greeting: val.Value}, key.Line)
+		case yaml.MappingNode:
+			err = yamlVariant(catalog, key.Value, val)
+		case yaml.SequenceNode:
+			for _, item := range val.Content {
+				switch item.Kind {
+				case yaml.ScalarNode:
+					err = catalog.add(key.Value, Variant{Greeting: item.Value}, item.Line)
+				case yaml.MappingNode:
+					err = yamlVariant(catalog, key.Value, item)
+				default:
+					err = errorf(YAML, item.Line, "variant of %q must be a string or a mapping", key.Value)
+				}
+
+				if err != nil {
+					break
+				}
+			}
+		default:
+			err = errorf(YAML, val.Line, "greeting for %q must be a string or a list of variants", key.Value)
+		}
+
+		if err != nil {
+			return nil, err
+		}
+	}
+	return catalog, nil
+}
+
+func yamlVariant(catalog *entries, lang string, node *yaml.Node) (err error) {
+	var (
+		v        Variant
+		greeting bool
+	)
+
+	for i := 0; i+1 < len(node.Content); i += 2 {
+		key, val := node.Content[i], node.Content[i+1]
+		if val.Kind != yaml.ScalarNode {
+			return errorf(YAML, val.Line, "%s of variant of %q must be a string", key.Value, lang)
+		}
+
+		if key.Value == greetingKey {
+			v.Greeting, greeting = val.Value, true
+			continue
+		}
+
+		if err = v.set(key.Value, val.Value); err != nil {
+			return errorf(YAML, key.Line, "%s in variant of %q", err, lang)
+		}
+	}
+
+	if !greeting {
+		return errorf(YAML, node.Line, "variant of %q has no greeting", lang)
+	}
+	return catalog.add(lang, v, node.Line)
+}
+
+func encodeYAML(w io.Writer, messages Catalog) error {
+	str := func(s string) *yaml.Node {
+		return &yaml.Node{Kind: yaml.ScalarNode, Tag: "!!str", Value: s}
+	}
+
+	root := &yaml.Node{Kind: yaml.MappingNode}
+	for _, lang := range messages.Languages() {
+		variants := messages[lang]
+		if len(variants) == 1 && !variants[0].Tagged() {
+			root.Content = append(root.Content, str(lang), str(variants[0].Greeting))
+			continue
+		}
+
+		list := &yaml.Node{Kind: yaml.SequenceNode}
+		for _, v := range variants {
+			item := &yaml.Node{Kind: yaml.MappingNode, Content: []*yaml.Node{str(greetingKey), str(v.Greeting)}}
+			for _, tag := range v.tags() {
+				val := str(tag[1])
+				if tag[0] == weightTag {
+					val.Tag = "!!int"
+				}
+				item.Content = append(item.Content, str(tag[0]), val)
+			}
+			list.Content = append(list.Content, item)
+		}
+		root.Content = append(root.Content, str(lang), list)
+	}
+
+	enc := yaml.NewEncoder(w)
+	enc.SetIndent(2)
+	if err := enc.Encode(root); err != nil {
+		return err
+	}
+	return enc.Close()
+}
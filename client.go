@@ -4,22 +4,30 @@ import (
 	"context"
 	"errors"
 	"io"
-	"sync"
+	"time"
 
 	"github.com/pdeziel/grpc-example/mock"
 	"github.com/pdeziel/grpc-example/pb"
+	"github.com/pdeziel/grpc-example/validate"
 	"google.golang.org/grpc"
 )
 
 // Client wraps a generated gRPC client with the connection
 type Client struct {
-	api pb.HelloClient
-	cc  *grpc.ClientConn
+	api     pb.HelloClient
+	cc      *grpc.ClientConn
+	limits  *validate.Limits
+	timeout *time.Duration
 }
 
-// Create a new client from client options
+// Create a new client from client options. The client sends keepalive pings using the
+// DefaultKeepalive parameters unless grpc.WithKeepaliveParams is specified or they are
+// disabled with WithoutKeepalive.
 func NewClient(endpoint string, opts ...grpc.DialOption) (c *Client, err error) {
 	c = &Client{}
+	if keepaliveEnabled(opts) {
+		opts = append([]grpc.DialOption{grpc.WithKeepaliveParams(DefaultKeepalive())}, opts...)
+	}
 
 	// "Dial" the server, by default this is a non-blocking call which establishes a
 	// connection in the background but doesn't do anything with it yet.
@@ -45,109 +53,267 @@ func (c *Client) ConnectMock(mock *mock.HelloService, opts ...grpc.DialOption) (
 }
 
 func (c *Client) SayHello(ctx context.Context, langCode string) (_ string, err error) {
-	req := &pb.HelloRequest{
-		IsoLanguageCode: langCode,
-	}
-
 	var rep *pb.HelloReply
-	if rep, err = c.api.SayHello(ctx, req); err != nil {
+	if rep, err = c.Unary(ctx, langCode); err != nil {
 		return "", err
 	}
-
 	return rep.Greeting, nil
 }
 
-func (c *Client) SayClientStream(ctx context.Context, langs <-chan string) (greetings []string, err error) {
-	var stream pb.Hello_SayClientStreamClient
-	if stream, err = c.api.SayClientStream(ctx); err != nil {
-		return nil, err
+// Unary requests a greeting in the language and returns the complete reply, including
+// its ID and timestamp. Options such as WithName configure the request; PartialSuccess
+// is ignored since there is only a single greeting.
+func (c *Client) Unary(ctx context.Context, langCode string, opts ...StreamOption) (rep *pb.HelloReply, err error) {
+	req := newStreamOptions(opts).request(langCode)
+	req.PartialSuccess = false
+
+	// Validate the request before sending to avoid an unnecessary round trip
+	if err = c.validate().Message(req); err != nil {
+		return nil, wrapError(err)
 	}
 
-	for langCode := range langs {
-		req := &pb.HelloRequest{
-			IsoLanguageCode: langCode,
-		}
+	ctx, cancel := c.withTimeout(ctx)
+	defer cancel()
 
-		if err = stream.Send(req); err != nil {
-			return nil, err
-		}
+	if rep, err = c.api.SayHello(ctx, req); err != nil {
+		return nil, wrapError(err)
 	}
+	return rep, nil
+}
 
-	var rep *pb.HelloManyReply
-	if rep, err = stream.CloseAndRecv(); err != nil {
+// Languages returns the language codes that the server has greetings for.
+func (c *Client) Languages(ctx context.Context) (_ []string, err error) {
+	ctx, cancel := c.withTimeout(ctx)
+	defer cancel()
+
+	var rep *pb.Languages
+	if rep, err = c.api.ListLanguages(ctx, &pb.ListLanguagesRequest{}); err != nil {
+		return nil, wrapError(err)
+	}
+	return rep.IsoLanguageCodes, nil
+}
+
+// SayClientStream sends every language code received on langs to the server and
+// returns the greetings once the langs channel is closed.
+func (c *Client) SayClientStream(ctx context.Context, langs <-chan string) (greetings []string, err error) {
+	var replies *Replies
+	if replies, err = c.ClientStream(ctx, langs); err != nil {
 		return nil, err
 	}
+	defer replies.Close()
 
-	greetings = make([]string, 0, len(rep.Greetings))
-	for _, greeting := range rep.Greetings {
-		greetings = append(greetings, greeting.Greeting)
+	for replies.Next() {
+		greetings = append(greetings, replies.Reply().Greeting)
 	}
 
+	if err = replies.Err(); err != nil {
+		return nil, err
+	}
 	return greetings, nil
 }
 
+// SayServerStream requests greetings for all of the language codes and returns them
+// once the server has finished streaming.
 func (c *Client) SayServerStream(ctx context.Context, langCodes []string) (greetings []string, err error) {
-	req := &pb.HelloManyRequest{
-		IsoLanguageCodes: langCodes,
+	var replies *Replies
+	if replies, err = c.ServerStream(ctx, langCodes); err != nil {
+		return nil, err
 	}
+	defer replies.Close()
 
-	var stream pb.Hello_SayServerStreamClient
-	if stream, err = c.api.SayServerStream(ctx, req); err != nil {
+	for replies.Next() {
+		greetings = append(greetings, replies.Reply().Greeting)
+	}
+
+	if err = replies.Err(); err != nil {
 		return nil, err
 	}
+	return greetings, nil
+}
+
+// ClientStream sends every language code received on langs to the server until the
+// channel is closed, then returns an iterator over the full replies. Sending stops
+// early if the context is cancelled or the client's timeout expires.
+func (c *Client) ClientStream(parent context.Context, langs <-chan string, opts ...StreamOption) (_ *Replies, err error) {
+	conf := newStreamOptions(opts)
 
+	// Cancel the stream if a request fails validation before it is sent
+	ctx, cancel := c.withTimeout(parent)
+	defer cancel()
+
+	var stream pb.Hello_SayClientStreamClient
+	if stream, err = c.api.SayClientStream(ctx); err != nil {
+		return nil, wrapError(err)
+	}
+
+	limits := c.validate()
+	var sent int
+
+sending:
 	for {
-		var rep *pb.HelloReply
-		if rep, err = stream.Recv(); err != nil {
-			if errors.Is(err, io.EOF) {
-				break
+		select {
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		case langCode, ok := <-langs:
+			if !ok {
+				break sending
+			}
+
+			req := conf.request(langCode)
+
+			sent++
+			if err = limits.Stream(sent); err != nil {
+				return nil, wrapError(err)
+			}
+
+			if err = limits.Message(req); err != nil {
+				return nil, wrapError(err)
+			}
+
+			if err = stream.Send(req); err != nil {
+				// An EOF means the server closed the stream, the actual error is
+				// returned by CloseAndRecv.
+				if errors.Is(err, io.EOF) {
+					break sending
+				}
+				return nil, wrapError(err)
 			}
-			return nil, err
 		}
+	}
 
-		greetings = append(greetings, rep.Greeting)
+	var rep *pb.HelloManyReply
+	if rep, err = stream.CloseAndRecv(); err != nil {
+		if ctx.Err() != nil {
+			return nil, ctx.Err()
+		}
+		return nil, wrapError(err)
 	}
 
-	return greetings, nil
+	// The stream context is cancelled on return so the iterator uses the parent
+	return sliceReplies(parent, rep.Greetings), nil
+}
+
+// ServerStream requests greetings for all of the language codes and returns an
+// iterator that yields each reply as it arrives from the server. The client's timeout
+// applies to the entire stream.
+func (c *Client) ServerStream(ctx context.Context, langCodes []string, opts ...StreamOption) (_ *Replies, err error) {
+	conf := newStreamOptions(opts)
+	req := &pb.HelloManyRequest{
+		IsoLanguageCodes: langCodes,
+		PartialSuccess:   conf.partial,
+		Name:             conf.name,
+		Params:           conf.params,
+		Register:         conf.register,
+		Timezone:         conf.timezone,
+		Context:          conf.context,
+		Selection:        string(conf.selection),
+		UserId:           conf.userID,
+	}
+
+	if err = c.validate().Message(req); err != nil {
+		return nil, wrapError(err)
+	}
+
+	ctx, cancel := c.withTimeout(ctx)
+	var stream pb.Hello_SayServerStreamClient
+	if stream, err = c.api.SayServerStream(ctx, req); err != nil {
+		cancel()
+		return nil, wrapError(err)
+	}
+
+	return newReplies(ctx, cancel, stream.Recv), nil
 }
 
+// SayBidirectional sends every language code received on langs to the server and
+// delivers the greetings on the greetings channel as they arrive. The greetings channel
+// is closed by SayBidirectional when it returns, whether or not an error occurred.
 func (c *Client) SayBidirectional(ctx context.Context, langs <-chan string, greetings chan<- string) (err error) {
-	var stream pb.Hello_SayBidirectionalClient
-	if stream, err = c.api.SayBidirectional(ctx); err != nil {
+	defer close(greetings)
+
+	var session *Session
+	if session, err = c.Bidirectional(ctx); err != nil {
 		return err
 	}
+	defer session.Close()
 
-	var wg sync.WaitGroup
-	wg.Add(1)
+	// Send language codes until the channel is closed or the session ends
+	sent := make(chan error, 1)
 	go func() {
-		defer wg.Done()
-		for langCode := range langs {
-			req := &pb.HelloRequest{
-				IsoLanguageCode: langCode,
-			}
-
-			if err = stream.Send(req); err != nil {
+		for {
+			select {
+			case <-session.Done():
+				sent <- nil
 				return
+			case langCode, ok := <-langs:
+				if !ok {
+					sent <- session.CloseSend()
+					return
+				}
+
+				if err := session.Send(langCode); err != nil {
+					if errors.Is(err, ErrSessionClosed) {
+						err = nil
+					} else {
+						// An invalid request is not sent and does not affect the session,
+						// so stop sending and receive the replies to the requests that
+						// were already sent before reporting the error.
+						session.CloseSend()
+					}
+					sent <- err
+					return
+				}
 			}
 		}
-
-		stream.CloseSend()
 	}()
 
-	for {
-		var rep *pb.HelloReply
-		if rep, err = stream.Recv(); err != nil {
-			if errors.Is(err, io.EOF) {
-				break
-			}
-			return err
+	for rep := range session.Replies() {
+		select {
+		case greetings <- rep.Greeting:
+		case <-ctx.Done():
+			session.Close()
 		}
+	}
 
-		greetings <- rep.Greeting
+	// Wait for the sender go routine to finish before reporting errors
+	if err = <-sent; err != nil {
+		return err
 	}
+	return session.Err()
+}
 
-	// Wait for the sender goroutine to send all the responses
-	wg.Wait()
+// Returns the limits used to validate requests before they are sent to the server.
+func (c *Client) validate() validate.Limits {
+	if c.limits == nil {
+		return validate.DefaultLimits()
+	}
+	return *c.limits
+}
 
-	return nil
+// SetLimits changes the limits used to validate requests before they are sent, which
+// should match the limits enforced by the server.
+func (c *Client) SetLimits(limits validate.Limits) {
+	c.limits = &limits
+}
+
+// Returns the timeout of unary and client and server streaming RPCs.
+func (c *Client) callTimeout() time.Duration {
+	if c.timeout == nil {
+		return DefaultTimeout
+	}
+	return *c.timeout
+}
+
+// SetTimeout changes the default timeout of RPCs made by the client; a zero timeout
+// means that RPCs are only bounded by the deadline of their context. The deadline of
+// the context is used instead if it is earlier than the timeout.
+func (c *Client) SetTimeout(timeout time.Duration) {
+	c.timeout = &timeout
+}
+
+// Create a context for an RPC that is cancelled when the client's timeout expires.
+func (c *Client) withTimeout(ctx context.Context) (context.Context, context.CancelFunc) {
+	if timeout := c.callTimeout(); timeout > 0 {
+		return context.WithTimeout(ctx, timeout)
+	}
+	return context.WithCancel(ctx)
 }
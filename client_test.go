@@ -6,11 +6,16 @@ import (
 	"io"
 	"sync"
 	"testing"
+	"time"
 
 	hello "github.com/pdeziel/grpc-example"
+	"github.com/pdeziel/grpc-example/hellotest"
 	"github.com/pdeziel/grpc-example/mock"
 	"github.com/pdeziel/grpc-example/pb"
+	"github.com/pdeziel/grpc-example/validate"
+	"github.com/stretchr/testify/require"
 	"github.com/stretchr/testify/suite"
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
 	"google.golang.org/grpc"
 	"google.golang.org/grpc/codes"
 	"google.golang.org/grpc/credentials/insecure"
@@ -40,7 +45,7 @@ func (s *clientTestSuite) TearDownSuite() {
 	s.server.Shutdown()
 }
 
-func (s *clientTestSuite) AfterTest() {
+func (s *clientTestSuite) AfterTest(suiteName, testName string) {
 	s.server.Reset()
 }
 
@@ -68,6 +73,218 @@ func (s *clientTestSuite) TestSayHello() {
 	greeting, err := s.client.SayHello(context.Background(), "fr")
 	require.NoError(err, "could not call the service")
 	require.Equal("Bonjour", greeting)
+
+	// Ensure the mock recorded the request
+	require.Equal(1, s.server.CallCount(mock.SayHelloRPC))
+	require.Equal(0, s.server.CallCount(mock.SayServerStreamRPC))
+
+	calls := s.server.Calls(mock.SayHelloRPC)
+	require.Len(calls, 1)
+	require.Len(calls[0].Requests(), 1)
+	require.Equal("fr", calls[0].Requests()[0].(*pb.HelloRequest).IsoLanguageCode)
+}
+
+func (s *clientTestSuite) TestUnary() {
+	require := s.Require()
+
+	s.server.OnSayHello = func(ctx context.Context, req *pb.HelloRequest) (*pb.HelloReply, error) {
+		return &pb.HelloReply{
+			Greeting:        "Hola",
+			IsoLanguageCode: req.IsoLanguageCode,
+			Id:              42,
+			CreatedAt:       "2023-04-01T12:00:00Z",
+		}, nil
+	}
+
+	rep, err := s.client.Unary(context.Background(), "es")
+	require.NoError(err, "could not call the service")
+	require.Equal("Hola", rep.Greeting)
+	require.Equal("es", rep.IsoLanguageCode)
+	require.Equal(uint64(42), rep.Id)
+	require.Equal("2023-04-01T12:00:00Z", rep.CreatedAt)
+
+	// Invalid requests are not sent
+	_, err = s.client.Unary(context.Background(), "")
+	require.ErrorIs(err, hello.ErrInvalidRequest)
+	require.Equal(1, s.server.CallCount(mock.SayHelloRPC))
+}
+
+func (s *clientTestSuite) TestLanguages() {
+	require := s.Require()
+
+	s.server.ExpectListLanguages().ReturnLanguages("en", "fr")
+	langs, err := s.client.Languages(context.Background())
+	require.NoError(err, "could not call the service")
+	require.Equal([]string{"en", "fr"}, langs)
+
+	s.server.ExpectListLanguages().ReturnError(codes.Unavailable, "try again later")
+	_, err = s.client.Languages(context.Background())
+	require.ErrorIs(err, hello.ErrUnavailable)
+	require.True(s.server.AssertExpectations(s.T()))
+}
+
+func (s *clientTestSuite) TestTimeouts() {
+	require := s.Require()
+	defer s.client.SetTimeout(hello.DefaultTimeout)
+
+	// The handler reports the deadline it receives
+	deadlines := make(chan time.Duration, 1)
+	s.server.OnSayHello = func(ctx context.Context, req *pb.HelloRequest) (*pb.HelloReply, error) {
+		remaining := time.Duration(-1)
+		if deadline, ok := ctx.Deadline(); ok {
+			remaining = time.Until(deadline)
+		}
+		deadlines <- remaining
+		return &pb.HelloReply{Greeting: "Hello"}, nil
+	}
+
+	// The client's timeout is propagated to the server
+	s.client.SetTimeout(time.Second)
+	_, err := s.client.SayHello(context.Background(), "en")
+	require.NoError(err, "could not call the service")
+	remaining := <-deadlines
+	require.Greater(remaining, time.Duration(0))
+	require.LessOrEqual(remaining, time.Second)
+
+	// An earlier deadline on the context takes precedence
+	ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+	defer cancel()
+	_, err = s.client.SayHello(ctx, "en")
+	require.NoError(err, "could not call the service")
+	require.LessOrEqual(<-deadlines, 100*time.Millisecond)
+
+	// Without a timeout the handler has no deadline
+	s.client.SetTimeout(0)
+	_, err = s.client.SayHello(context.Background(), "en")
+	require.NoError(err, "could not call the service")
+	require.Equal(time.Duration(-1), <-deadlines)
+
+	// Server streams are bounded by the timeout
+	s.client.SetTimeout(time.Second)
+	s.server.OnSayServerStream = func(req *pb.HelloManyRequest, stream pb.Hello_SayServerStreamServer) error {
+		_, ok := stream.Context().Deadline()
+		if !ok {
+			return status.Error(codes.FailedPrecondition, "no deadline")
+		}
+		return stream.Send(&pb.HelloReply{Greeting: "Hello"})
+	}
+
+	greetings, err := s.client.SayServerStream(context.Background(), []string{"en"})
+	require.NoError(err, "expected the stream to have a deadline")
+	require.Equal([]string{"Hello"}, greetings)
+}
+
+func (s *clientTestSuite) TestTimeoutExpired() {
+	require := s.Require()
+	defer s.client.SetTimeout(hello.DefaultTimeout)
+
+	// Block until the deadline propagated to the handler expires
+	done := make(chan struct{})
+	s.server.OnSayHello = func(ctx context.Context, req *pb.HelloRequest) (*pb.HelloReply, error) {
+		defer close(done)
+		<-ctx.Done()
+		return nil, status.FromContextError(ctx.Err()).Err()
+	}
+
+	s.client.SetTimeout(50 * time.Millisecond)
+	_, err := s.client.SayHello(context.Background(), "en")
+	require.ErrorIs(err, context.DeadlineExceeded)
+	require.Equal(codes.DeadlineExceeded, status.Code(err))
+
+	select {
+	case <-done:
+	case <-time.After(5 * time.Second):
+		require.Fail("the deadline was not propagated to the handler")
+	}
+}
+
+func (s *clientTestSuite) TestSayHelloErrors() {
+	require := s.Require()
+
+	// Configure the server mock to return a not found error with details
+	s.server.OnSayHello = func(ctx context.Context, req *pb.HelloRequest) (*pb.HelloReply, error) {
+		st, err := status.New(codes.NotFound, "language not found").WithDetails(&errdetails.ResourceInfo{
+			ResourceType: "language",
+			ResourceName: req.IsoLanguageCode,
+		})
+		if err != nil {
+			return nil, err
+		}
+		return nil, st.Err()
+	}
+
+	_, err := s.client.SayHello(context.Background(), "xx")
+	require.ErrorIs(err, hello.ErrLanguageNotFound)
+	require.Equal(codes.NotFound, status.Code(err), "expected error to be compatible with status")
+
+	var herr *hello.Error
+	require.ErrorAs(err, &herr)
+	require.Equal("xx", herr.Language)
+	require.Equal(codes.NotFound, herr.Code)
+
+	// Other status codes should map to the other sentinel errors
+	testCases := []struct {
+		code     codes.Code
+		expected error
+	}{
+		{codes.Unavailable, hello.ErrUnavailable},
+		{codes.Unauthenticated, hello.ErrUnauthenticated},
+	}
+
+	for _, tc := range testCases {
+		s.server.OnSayHello = func(ctx context.Context, req *pb.HelloRequest) (*pb.HelloReply, error) {
+			return nil, status.Error(tc.code, "something went wrong")
+		}
+
+		_, err = s.client.SayHello(context.Background(), "en")
+		require.ErrorIs(err, tc.expected)
+		require.ErrorAs(err, &herr)
+		require.Empty(herr.Language)
+	}
+
+	// Unmapped codes are still returned as an *Error
+	s.server.OnSayHello = func(ctx context.Context, req *pb.HelloRequest) (*pb.HelloReply, error) {
+		return nil, status.Error(codes.Internal, "something went wrong")
+	}
+
+	_, err = s.client.SayHello(context.Background(), "en")
+	require.ErrorAs(err, &herr)
+	require.Equal(codes.Internal, herr.Code)
+	require.NoError(errors.Unwrap(err))
+
+	// NotFound without the language details, e.g. from a proxy, is not a missing language
+	s.server.OnSayHello = func(ctx context.Context, req *pb.HelloRequest) (*pb.HelloReply, error) {
+		return nil, status.Error(codes.NotFound, "no route to service")
+	}
+
+	_, err = s.client.SayHello(context.Background(), "en")
+	require.ErrorAs(err, &herr)
+	require.Equal(codes.NotFound, herr.Code)
+	require.NotErrorIs(err, hello.ErrLanguageNotFound)
+	require.NoError(errors.Unwrap(err))
+}
+
+func (s *clientTestSuite) TestValidation() {
+	require := s.Require()
+
+	// The mock is never called since requests are validated before they are sent
+	s.server.OnSayHello = func(ctx context.Context, req *pb.HelloRequest) (*pb.HelloReply, error) {
+		return &pb.HelloReply{Greeting: "Hello"}, nil
+	}
+
+	_, err := s.client.SayHello(context.Background(), "")
+	require.ErrorIs(err, hello.ErrInvalidRequest)
+	require.Equal(codes.InvalidArgument, status.Code(err))
+
+	_, err = s.client.ServerStream(context.Background(), []string{"en", "not a language"})
+	require.ErrorIs(err, hello.ErrInvalidRequest)
+
+	// The client can be configured to match the limits of the server
+	s.client.SetLimits(validate.Limits{MaxLanguageCodes: 1})
+	defer s.client.SetLimits(validate.DefaultLimits())
+
+	_, err = s.client.ServerStream(context.Background(), []string{"en", "fr"})
+	require.ErrorIs(err, hello.ErrInvalidRequest)
 }
 
 func (s *clientTestSuite) TestSayClientStream() {
@@ -105,8 +322,8 @@ func (s *clientTestSuite) TestSayClientStream() {
 	wg.Add(1)
 	go func() {
 		defer wg.Done()
-		for _, message := range messages {
-			langs <- message
+		for _, lang := range []string{"en", "fr", "es"} {
+			langs <- lang
 		}
 		close(langs)
 	}()
@@ -175,8 +392,8 @@ func (s *clientTestSuite) TestSayBidirectional() {
 	wg.Add(1)
 	go func() {
 		defer wg.Done()
-		for _, message := range messages {
-			langs <- message
+		for _, lang := range []string{"en", "fr", "es"} {
+			langs <- lang
 		}
 		close(langs)
 	}()
@@ -194,5 +411,409 @@ func (s *clientTestSuite) TestSayBidirectional() {
 
 	err := s.client.SayBidirectional(context.Background(), langs, responses)
 	require.NoError(err, "could not call the service")
+
+	// The responses channel is closed when SayBidirectional returns
+	wg.Wait()
 	require.Equal(messages, greetings)
 }
+
+func (s *clientTestSuite) TestSayBidirectionalError() {
+	require := s.Require()
+
+	// Configure the server mock to fail on the second request
+	s.server.OnSayBidirectional = func(stream pb.Hello_SayBidirectionalServer) (err error) {
+		if _, err = stream.Recv(); err != nil {
+			return err
+		}
+
+		if err = stream.Send(&pb.HelloReply{Greeting: "Hello"}); err != nil {
+			return err
+		}
+
+		if _, err = stream.Recv(); err != nil {
+			return err
+		}
+		return status.Error(codes.NotFound, "language not found")
+	}
+
+	// Never close the langs channel to ensure the sender does not block the return
+	langs := make(chan string, 2)
+	langs <- "en"
+	langs <- "xx"
+
+	responses := make(chan string, 2)
+	err := s.client.SayBidirectional(context.Background(), langs, responses)
+	require.Equal(codes.NotFound, status.Code(err), "expected the server error to be returned")
+
+	greetings := make([]string, 0, 1)
+	for msg := range responses {
+		greetings = append(greetings, msg)
+	}
+	require.Equal([]string{"Hello"}, greetings)
+}
+
+func (s *clientTestSuite) TestSayBidirectionalInvalid() {
+	require := s.Require()
+	s.server.OnSayBidirectional = mock.NewScript().
+		Expect("en", "fr").
+		Send(&pb.HelloReply{Greeting: "Hello"}, &pb.HelloReply{Greeting: "Bonjour"}).
+		Bidirectional
+
+	// An invalid language code is not sent but the replies to the requests that were
+	// already sent are still received
+	langs := make(chan string, 4)
+	for _, lang := range []string{"en", "fr", "not a language", "es"} {
+		langs <- lang
+	}
+
+	responses := make(chan string, 3)
+	err := s.client.SayBidirectional(context.Background(), langs, responses)
+	require.ErrorIs(err, hello.ErrInvalidRequest)
+
+	greetings := make([]string, 0, 2)
+	for msg := range responses {
+		greetings = append(greetings, msg)
+	}
+	require.Equal([]string{"Hello", "Bonjour"}, greetings)
+}
+
+func (s *clientTestSuite) TestSayBidirectionalScript() {
+	require := s.Require()
+
+	// The server replies out of step with the requests and then fails mid-stream
+	script := mock.NewScript().
+		Expect("en", "fr").
+		Send(&pb.HelloReply{Greeting: "Hello"}, &pb.HelloReply{Greeting: "Bonjour"}).
+		Expect("es").
+		Sleep(10*time.Millisecond).
+		CloseWith(codes.Unavailable, "server going away")
+	s.server.OnSayBidirectional = script.Bidirectional
+
+	langs := make(chan string, 3)
+	for _, lang := range []string{"en", "fr", "es"} {
+		langs <- lang
+	}
+
+	responses := make(chan string, 3)
+	err := s.client.SayBidirectional(context.Background(), langs, responses)
+	require.ErrorIs(err, hello.ErrUnavailable)
+
+	greetings := make([]string, 0, 2)
+	for msg := range responses {
+		greetings = append(greetings, msg)
+	}
+	require.Equal([]string{"Hello", "Bonjour"}, greetings)
+	script.AssertDone(s.T())
+}
+
+func (s *clientTestSuite) TestSession() {
+	require := s.Require()
+
+	// Configure the server mock to echo the language codes back
+	s.server.OnSayBidirectional = func(stream pb.Hello_SayBidirectionalServer) (err error) {
+		for {
+			var req *pb.HelloRequest
+			if req, err = stream.Recv(); err != nil {
+				if errors.Is(err, io.EOF) {
+					return nil
+				}
+				return err
+			}
+
+			if err = stream.Send(&pb.HelloReply{
+				Greeting:        "Hello",
+				IsoLanguageCode: req.IsoLanguageCode,
+			}); err != nil {
+				return err
+			}
+		}
+	}
+
+	session, err := s.client.Bidirectional(context.Background())
+	require.NoError(err, "could not open a session")
+	defer session.Close()
+
+	// Send concurrently from multiple go routines
+	langs := []string{"en", "fr", "es", "de"}
+	var wg sync.WaitGroup
+	for _, lang := range langs {
+		wg.Add(1)
+		go func(lang string) {
+			defer wg.Done()
+			s.NoError(session.Send(lang))
+		}(lang)
+	}
+
+	received := make(map[string]bool)
+	for range langs {
+		rep, err := session.Recv()
+		require.NoError(err, "could not receive a reply")
+		received[rep.IsoLanguageCode] = true
+	}
+
+	wg.Wait()
+	require.NoError(session.CloseSend())
+	require.ErrorIs(session.Send("it"), hello.ErrSessionClosed)
+
+	_, err = session.Recv()
+	require.ErrorIs(err, io.EOF, "expected the session to end normally")
+	require.NoError(session.Err())
+	require.Len(received, len(langs))
+
+	_, ok := <-session.Replies()
+	require.False(ok, "expected the replies channel to be closed")
+}
+
+func (s *clientTestSuite) TestSessionError() {
+	require := s.Require()
+
+	s.server.OnSayBidirectional = func(stream pb.Hello_SayBidirectionalServer) (err error) {
+		return status.Error(codes.Unavailable, "service unavailable")
+	}
+
+	session, err := s.client.Bidirectional(context.Background())
+	require.NoError(err, "could not open a session")
+	defer session.Close()
+
+	_, err = session.Recv()
+	require.Equal(codes.Unavailable, status.Code(err))
+	require.Equal(codes.Unavailable, status.Code(session.Err()))
+
+	// Once the server has ended the stream sending is no longer possible
+	<-session.Done()
+	require.Eventually(func() bool {
+		return errors.Is(session.Send("en"), hello.ErrSessionClosed)
+	}, time.Second, 10*time.Millisecond)
+}
+
+func (s *clientTestSuite) TestSessionCancel() {
+	require := s.Require()
+
+	// Wait for the handler to exit so that it does not interfere with other tests
+	started, done := make(chan struct{}), make(chan struct{})
+	s.server.OnSayBidirectional = func(stream pb.Hello_SayBidirectionalServer) (err error) {
+		defer close(done)
+		close(started)
+		<-stream.Context().Done()
+		return stream.Context().Err()
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	session, err := s.client.Bidirectional(ctx)
+	require.NoError(err, "could not open a session")
+	defer session.Close()
+
+	<-started
+	cancel()
+	_, err = session.Recv()
+	require.ErrorIs(err, context.Canceled)
+	<-done
+}
+
+func (s *clientTestSuite) TestServerStream() {
+	require := s.Require()
+	langs := []string{"en", "fr", "es"}
+	messages := []string{"Hello", "Bonjour", "Hola"}
+
+	// Configure the server mock
+	s.server.OnSayServerStream = func(req *pb.HelloManyRequest, stream pb.Hello_SayServerStreamServer) (err error) {
+		for i, lang := range req.IsoLanguageCodes {
+			if err = stream.Send(&pb.HelloReply{
+				Greeting:        messages[i],
+				IsoLanguageCode: lang,
+				Id:              uint64(i + 1),
+				CreatedAt:       "2023-04-01T12:00:00Z",
+			}); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+
+	replies, err := s.client.ServerStream(context.Background(), langs)
+	require.NoError(err, "could not call the service")
+	defer replies.Close()
+
+	var i int
+	for replies.Next() {
+		rep := replies.Reply()
+		require.Equal(messages[i], rep.Greeting)
+		require.Equal(langs[i], rep.IsoLanguageCode)
+		require.Equal(uint64(i+1), rep.Id)
+		require.Equal("2023-04-01T12:00:00Z", rep.CreatedAt)
+		i++
+	}
+	require.NoError(replies.Err(), "expected the stream to end without error")
+	require.Equal(len(messages), i, "unexpected number of replies")
+	require.False(replies.Next(), "expected the iterator to be exhausted")
+}
+
+func (s *clientTestSuite) TestServerStreamPartialSuccess() {
+	require := s.Require()
+
+	// Configure the server mock to fail unknown languages when partial success is set
+	s.server.OnSayServerStream = func(req *pb.HelloManyRequest, stream pb.Hello_SayServerStreamServer) (err error) {
+		if !req.PartialSuccess {
+			return status.Error(codes.FailedPrecondition, "expected partial success")
+		}
+
+		for _, lang := range req.IsoLanguageCodes {
+			rep := &pb.HelloReply{IsoLanguageCode: lang}
+			if lang == "xx" {
+				rep.Error = &pb.HelloError{Code: uint32(codes.NotFound), Message: "language not found"}
+			} else {
+				rep.Greeting = "Hello"
+			}
+
+			if err = stream.Send(rep); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+
+	replies, err := s.client.ServerStream(context.Background(), []string{"en", "xx", "fr"}, hello.PartialSuccess())
+	require.NoError(err, "could not call the service")
+	defer replies.Close()
+
+	var n int
+	for replies.Next() {
+		n++
+	}
+	require.NoError(replies.Err())
+	require.Equal(3, n, "expected failed replies to be yielded")
+
+	failures := replies.Failures()
+	require.Len(failures, 1)
+	require.ErrorIs(failures[0], hello.ErrLanguageNotFound)
+
+	var herr *hello.Error
+	require.ErrorAs(failures[0], &herr)
+	require.Equal("xx", herr.Language)
+	require.Equal(codes.NotFound, status.Code(failures[0]))
+}
+
+func (s *clientTestSuite) TestServerStreamError() {
+	require := s.Require()
+
+	// Configure the server mock to fail after sending one message
+	s.server.OnSayServerStream = func(req *pb.HelloManyRequest, stream pb.Hello_SayServerStreamServer) (err error) {
+		if err = stream.Send(&pb.HelloReply{Greeting: "Hello"}); err != nil {
+			return err
+		}
+		return status.Error(codes.NotFound, "language not found")
+	}
+
+	replies, err := s.client.ServerStream(context.Background(), []string{"en", "xx"})
+	require.NoError(err, "could not call the service")
+	defer replies.Close()
+
+	require.True(replies.Next(), "expected a reply before the error")
+	require.Equal("Hello", replies.Reply().Greeting)
+	require.False(replies.Next(), "expected the stream to be terminated")
+	require.Equal(codes.NotFound, status.Code(replies.Err()))
+}
+
+func (s *clientTestSuite) TestServerStreamCancel() {
+	require := s.Require()
+
+	// Configure the server mock to send one message then block until cancelled
+	done := make(chan struct{})
+	s.server.OnSayServerStream = func(req *pb.HelloManyRequest, stream pb.Hello_SayServerStreamServer) (err error) {
+		defer close(done)
+		if err = stream.Send(&pb.HelloReply{Greeting: "Hello"}); err != nil {
+			return err
+		}
+		<-stream.Context().Done()
+		return stream.Context().Err()
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	replies, err := s.client.ServerStream(ctx, []string{"en", "fr"})
+	require.NoError(err, "could not call the service")
+	defer replies.Close()
+
+	require.True(replies.Next(), "expected a reply before cancellation")
+	cancel()
+	require.False(replies.Next(), "expected the iterator to stop after cancellation")
+	require.ErrorIs(replies.Err(), context.Canceled)
+	<-done
+}
+
+func (s *clientTestSuite) TestClientStream() {
+	require := s.Require()
+
+	// Configure the server mock to echo the language codes back
+	s.server.OnSayClientStream = func(ctx context.Context, stream pb.Hello_SayClientStreamServer) (err error) {
+		reply := &pb.HelloManyReply{}
+		for {
+			var req *pb.HelloRequest
+			if req, err = stream.Recv(); err != nil {
+				if errors.Is(err, io.EOF) {
+					return stream.SendAndClose(reply)
+				}
+				return err
+			}
+
+			reply.Greetings = append(reply.Greetings, &pb.HelloReply{
+				Greeting:        "Hello",
+				IsoLanguageCode: req.IsoLanguageCode,
+				Id:              uint64(len(reply.Greetings) + 1),
+			})
+		}
+	}
+
+	langs := make(chan string, 2)
+	langs <- "en"
+	langs <- "fr"
+	close(langs)
+
+	replies, err := s.client.ClientStream(context.Background(), langs)
+	require.NoError(err, "could not call the service")
+	defer replies.Close()
+
+	actual := make([]string, 0, 2)
+	for replies.Next() {
+		rep := replies.Reply()
+		require.Equal(uint64(len(actual)+1), rep.Id)
+		actual = append(actual, rep.IsoLanguageCode)
+	}
+	require.NoError(replies.Err())
+	require.Equal([]string{"en", "fr"}, actual)
+}
+
+func (s *clientTestSuite) TestClientStreamCancel() {
+	require := s.Require()
+
+	started, done := make(chan struct{}), make(chan struct{})
+	s.server.OnSayClientStream = func(ctx context.Context, stream pb.Hello_SayClientStreamServer) (err error) {
+		defer close(done)
+		close(started)
+		<-ctx.Done()
+		return ctx.Err()
+	}
+
+	// The langs channel is never closed so only cancellation can stop the send
+	ctx, cancel := context.WithCancel(context.Background())
+	langs := make(chan string)
+	go func() {
+		<-started
+		cancel()
+	}()
+
+	_, err := s.client.ClientStream(ctx, langs)
+	require.ErrorIs(err, context.Canceled)
+	<-done
+}
+
+func TestWithoutKeepalive(t *testing.T) {
+	// The option is only interpreted by NewClient, gRPC ignores it when dialing
+	client := hellotest.NewClient(t, hellotest.WithDialOptions(
+		grpc.WithTransportCredentials(insecure.NewCredentials()),
+		hello.WithoutKeepalive(),
+	))
+
+	greeting, err := client.SayHello(context.Background(), "en")
+	require.NoError(t, err, "could not call the service")
+	require.Equal(t, "Hello", greeting)
+}
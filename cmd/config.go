@@ -0,0 +1,260 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+
+	"github.com/urfave/cli/v2"
+	"gopkg.in/yaml.v3"
+)
+
+// The CLI is configured from (in order of precedence): command line flags, GRPC_EXAMPLE_*
+// environment variables, the selected profile in the config file, the top level of the
+// config file, and finally the flag defaults. The config file is YAML whose keys are
+// the names of the global flags, with the flags of a command nested under its name and
+// named profiles that override the top level values:
+//
+//	profile: dev
+//	endpoint: localhost:443
+//	serve:
+//	  bindaddr: ":443"
+//	profiles:
+//	  dev:
+//	    endpoint: localhost:8443
+//	  prod:
+//	    endpoint: hello.example.com:443
+//	    output: json
+//
+// Values from the config file are only applied to flags that were not set on the
+// command line or from the environment.
+type config map[string]interface{}
+
+// Reserved keys in the config file that are not flags.
+const (
+	profileKey  = "profile"
+	profilesKey = "profiles"
+)
+
+// The configuration loaded by the app before any command is run.
+var conf config
+
+// Path to the default config file, which is ignored if it does not exist.
+func defaultConfigPath() string {
+	dir, err := os.UserConfigDir()
+	if err != nil {
+		return ""
+	}
+	return filepath.Join(dir, "grpc-example", "config.yaml")
+}
+
+// Load the config file and select the profile, then apply it to the global flags.
+func loadConfig(c *cli.Context) (err error) {
+	// The default config file is optional but one that is specified must exist
+	path := c.String("config")
+	optional := path == "" || path == defaultConfigPath()
+	if path == "" {
+		path = defaultConfigPath()
+	}
+
+	var file config
+	if file, err = readConfig(path); err != nil {
+		if optional && errors.Is(err, fs.ErrNotExist) {
+			file = config{}
+		} else {
+			return cli.Exit(err, 1)
+		}
+	}
+
+	if conf, err = file.profile(c.String("profile")); err != nil {
+		return cli.Exit(fmt.Errorf("%s: %w", path, err), 1)
+	}
+
+	if err = conf.check(c.App); err != nil {
+		return cli.Exit(fmt.Errorf("%s: %w", path, err), 1)
+	}
+	return conf.apply(c, c.App.Flags)
+}
+
+// Wrap the Before function of every command so that its flags are configured before
+// it runs.
+func configureCommands(cmds []*cli.Command) {
+	for _, cmd := range cmds {
+		before := cmd.Before
+		cmd.Before = func(c *cli.Context) (err error) {
+			if section, ok := conf[c.Command.Name].(config); ok {
+				if err = section.apply(c, c.Command.Flags); err != nil {
+					return err
+				}
+			}
+
+			if before != nil {
+				return before(c)
+			}
+			return nil
+		}
+	}
+}
+
+func readConfig(path string) (conf config, err error) {
+	var data []byte
+	if data, err = os.ReadFile(path); err != nil {
+		return nil, err
+	}
+
+	conf = make(config)
+	if err = yaml.Unmarshal(data, &conf); err != nil {
+		return nil, fmt.Errorf("could not parse %s: %w", path, err)
+	}
+	return conf.normalize(), nil
+}
+
+// Convert the nested maps decoded from YAML to configs so they can be merged.
+func (c config) normalize() config {
+	for key, val := range c {
+		if section, ok := val.(map[string]interface{}); ok {
+			c[key] = config(section).normalize()
+		}
+	}
+	return c
+}
+
+// Merge the named profile (or the profile named in the file if name is empty) over the
+// top level values of the config file.
+func (c config) profile(name string) (_ config, err error) {
+	if name == "" {
+		if name, err = c.str(profileKey); err != nil {
+			return nil, err
+		}
+	}
+
+	profiles := config{}
+	if val, ok := c[profilesKey]; ok {
+		if profiles, ok = val.(config); !ok {
+			return nil, fmt.Errorf("%q must be a map of profile names to options", profilesKey)
+		}
+	}
+
+	merged := make(config)
+	for key, val := range c {
+		if key != profileKey && key != profilesKey {
+			merged[key] = val
+		}
+	}
+
+	if name == "" {
+		return merged, nil
+	}
+
+	profile, ok := profiles[name].(config)
+	if !ok {
+		return nil, fmt.Errorf("unknown profile %q", name)
+	}
+
+	for key, val := range profile {
+		// Command sections are merged rather than replaced
+		if section, ok := val.(config); ok {
+			if base, ok := merged[key].(config); ok {
+				combined := make(config, len(base)+len(section))
+				for k, v := range base {
+					combined[k] = v
+				}
+				for k, v := range section {
+					combined[k] = v
+				}
+				val = combined
+			}
+		}
+		merged[key] = val
+	}
+	return merged, nil
+}
+
+// Ensure that every key in the config is a global flag or the flag of a command so
+// that typos are not silently ignored.
+func (c config) check(app *cli.App) error {
+	for _, key := range c.keys() {
+		if hasFlag(app.Flags, key) {
+			continue
+		}
+
+		cmd := app.Command(key)
+		if cmd == nil {
+			return fmt.Errorf("unknown option %q", key)
+		}
+
+		section, ok := c[key].(config)
+		if !ok {
+			return fmt.Errorf("options for command %q must be a map", key)
+		}
+
+		for _, name := range section.keys() {
+			if !hasFlag(cmd.Flags, name) {
+				return fmt.Errorf("unknown option %q for command %q", name, key)
+			}
+		}
+	}
+	return nil
+}
+
+// Set the value of every flag that was not set on the command line or from the
+// environment and that has a value in the config.
+func (c config) apply(ctx *cli.Context, flags []cli.Flag) (err error) {
+	for _, flag := range flags {
+		name := flag.Names()[0]
+		val, ok := c[name]
+		if !ok || ctx.IsSet(name) {
+			continue
+		}
+
+		// Lists are set one item at a time for slice flags
+		values := []interface{}{val}
+		if list, ok := val.([]interface{}); ok {
+			values = list
+		}
+
+		for _, v := range values {
+			if err = ctx.Set(name, fmt.Sprint(v)); err != nil {
+				return cli.Exit(fmt.Errorf("invalid value %v for option %q: %w", v, name, err), 1)
+			}
+		}
+	}
+	return nil
+}
+
+func (c config) str(key string) (string, error) {
+	val, ok := c[key]
+	if !ok {
+		return "", nil
+	}
+
+	s, ok := val.(string)
+	if !ok {
+		return "", fmt.Errorf("%q must be a string", key)
+	}
+	return s, nil
+}
+
+// Sorted keys so that errors are reported deterministically.
+func (c config) keys() []string {
+	keys := make([]string, 0, len(c))
+	for key := range c {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+func hasFlag(flags []cli.Flag, name string) bool {
+	for _, flag := range flags {
+		for _, n := range flag.Names() {
+			if n == name {
+				return true
+			}
+		}
+	}
+	return false
+}
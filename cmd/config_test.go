@@ -0,0 +1,118 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"github.com/urfave/cli/v2"
+)
+
+const testConfig = `
+profile: dev
+endpoint: localhost:443
+output: table
+serve:
+  bindaddr: ":443"
+bench:
+  langs: [en=3, fr]
+profiles:
+  dev:
+    endpoint: localhost:8443
+  prod:
+    endpoint: hello.example.com:443
+    serve:
+      record: traffic.jsonl
+`
+
+// Run the serve command with the args and return the options it was configured with.
+func run(t *testing.T, args ...string) (opts map[string]string, err error) {
+	t.Helper()
+	app := newApp()
+	app.ExitErrHandler = func(*cli.Context, error) {}
+
+	opts = make(map[string]string)
+	app.Command("serve").Action = func(c *cli.Context) error {
+		for _, name := range []string{"endpoint", "output", "bindaddr", "record"} {
+			opts[name] = c.String(name)
+		}
+		return nil
+	}
+
+	err = app.Run(append([]string{"grpc-example"}, args...))
+	return opts, err
+}
+
+func writeConfig(t *testing.T, data string) string {
+	path := filepath.Join(t.TempDir(), "config.yaml")
+	require.NoError(t, os.WriteFile(path, []byte(data), 0644))
+	return path
+}
+
+func TestConfigPrecedence(t *testing.T) {
+	path := writeConfig(t, testConfig)
+
+	// The profile named in the config file overrides the top level values
+	opts, err := run(t, "--config", path, "serve")
+	require.NoError(t, err)
+	require.Equal(t, map[string]string{"endpoint": "localhost:8443", "output": "table", "bindaddr": ":443", "record": ""}, opts)
+
+	// Profiles can be selected by flag and merge command sections
+	opts, err = run(t, "--config", path, "--profile", "prod", "serve")
+	require.NoError(t, err)
+	require.Equal(t, map[string]string{"endpoint": "hello.example.com:443", "output": "table", "bindaddr": ":443", "record": "traffic.jsonl"}, opts)
+
+	// Environment variables override the config file
+	t.Setenv("GRPC_EXAMPLE_ENDPOINT", "env:443")
+	t.Setenv("GRPC_EXAMPLE_SERVE_BINDADDR", ":8000")
+	opts, err = run(t, "--config", path, "serve")
+	require.NoError(t, err)
+	require.Equal(t, "env:443", opts["endpoint"])
+	require.Equal(t, ":8000", opts["bindaddr"])
+
+	// Flags override the environment
+	opts, err = run(t, "--config", path, "-e", "flag:443", "serve", "-a", ":9000")
+	require.NoError(t, err)
+	require.Equal(t, "flag:443", opts["endpoint"])
+	require.Equal(t, ":9000", opts["bindaddr"])
+}
+
+func TestConfigDefaults(t *testing.T) {
+	// A missing default config file is ignored
+	t.Setenv("XDG_CONFIG_HOME", t.TempDir())
+	opts, err := run(t, "serve")
+	require.NoError(t, err)
+	require.Equal(t, map[string]string{"endpoint": "localhost:443", "output": "plain", "bindaddr": ":443", "record": ""}, opts)
+
+	// But a config file that is specified must exist
+	_, err = run(t, "--config", filepath.Join(t.TempDir(), "missing.yaml"), "serve")
+	require.Error(t, err)
+}
+
+func TestConfigErrors(t *testing.T) {
+	testCases := []struct {
+		config string
+		args   []string
+		err    string
+	}{
+		{"endpoint: [", nil, "could not parse"},
+		{"endpont: localhost:443", nil, `unknown option "endpont"`},
+		{"serve:\n  bindadr: :443", nil, `unknown option "bindadr" for command "serve"`},
+		{"serve: :443", nil, `options for command "serve" must be a map`},
+		{"profile: staging", nil, `unknown profile "staging"`},
+		{"profiles:\n  dev: {}", []string{"--profile", "prod"}, `unknown profile "prod"`},
+		{"bench:\n  requests: lots", []string{"bench"}, `invalid value lots for option "requests"`},
+	}
+
+	for _, tc := range testCases {
+		path := writeConfig(t, tc.config)
+		args := append([]string{"--config", path}, tc.args...)
+		if tc.args == nil {
+			args = append(args, "serve")
+		}
+
+		_, err := run(t, args...)
+		require.ErrorContains(t, err, tc.err, "config: %q", tc.config)
+	}
+}
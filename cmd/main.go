@@ -4,27 +4,80 @@ import (
 	"bufio"
 	"context"
 	"fmt"
+	"io"
 	"os"
 	"strings"
 	"sync"
+	"time"
 
 	hello "github.com/pdeziel/grpc-example"
+	"github.com/pdeziel/grpc-example/bench"
+	"github.com/pdeziel/grpc-example/catalog"
+	"github.com/pdeziel/grpc-example/pb"
+	"github.com/pdeziel/grpc-example/record"
 	"github.com/urfave/cli/v2"
+	"golang.org/x/term"
 	"google.golang.org/grpc"
 	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/keepalive"
 )
 
 func main() {
+	newApp().Run(os.Args)
+}
+
+// Create the CLI application with all of its commands and flags
+func newApp() *cli.App {
 	app := cli.NewApp()
 	app.Name = "grpc-example"
 	app.Usage = "A simple gRPC client"
+	app.Description = "Options are read from (in order of precedence) command line flags, GRPC_EXAMPLE_* environment\n" +
+		"variables, the selected profile of the config file, the top level of the config file and the defaults."
 	app.Flags = []cli.Flag{
+		&cli.StringFlag{
+			Name:    "config",
+			EnvVars: []string{"GRPC_EXAMPLE_CONFIG"},
+			Usage:   "Path to the YAML config file",
+			Value:   defaultConfigPath(),
+		},
+		&cli.StringFlag{
+			Name:    "profile",
+			EnvVars: []string{"GRPC_EXAMPLE_PROFILE"},
+			Usage:   "Named profile in the config file to use",
+		},
 		&cli.StringFlag{
 			Name:    "endpoint",
 			Aliases: []string{"e"},
+			EnvVars: []string{"GRPC_EXAMPLE_ENDPOINT"},
 			Usage:   "gRPC server endpoint",
 			Value:   "localhost:443",
 		},
+		&cli.StringFlag{
+			Name:    "output",
+			Aliases: []string{"o"},
+			EnvVars: []string{"GRPC_EXAMPLE_OUTPUT"},
+			Usage:   "Output format: plain, json, ndjson, yaml or table",
+			Value:   formatPlain,
+		},
+		&cli.DurationFlag{
+			Name:    "timeout",
+			Aliases: []string{"t"},
+			EnvVars: []string{"GRPC_EXAMPLE_TIMEOUT"},
+			Usage:   "Timeout of each RPC except chat sessions (0 for no timeout)",
+			Value:   hello.DefaultTimeout,
+		},
+		&cli.DurationFlag{
+			Name:    "keepalive",
+			EnvVars: []string{"GRPC_EXAMPLE_KEEPALIVE"},
+			Usage:   "Interval between keepalive pings while streams are active (0 to disable)",
+			Value:   hello.DefaultKeepalive().Time,
+		},
+		&cli.DurationFlag{
+			Name:    "keepalive-timeout",
+			EnvVars: []string{"GRPC_EXAMPLE_KEEPALIVE_TIMEOUT"},
+			Usage:   "How long to wait for a keepalive ping to be acknowledged",
+			Value:   hello.DefaultKeepalive().Timeout,
+		},
 	}
 	app.Commands = []*cli.Command{
 		{
@@ -36,66 +89,321 @@ func main() {
 				&cli.StringFlag{
 					Name:    "bindaddr",
 					Aliases: []string{"a"},
+					EnvVars: []string{"GRPC_EXAMPLE_SERVE_BINDADDR"},
 					Usage:   "Address to bind the server to",
 					Value:   ":443",
 				},
+				&cli.StringFlag{
+					Name:    "record",
+					Aliases: []string{"r"},
+					EnvVars: []string{"GRPC_EXAMPLE_SERVE_RECORD"},
+					Usage:   "Record all RPCs to a JSON-lines file for replay",
+				},
+				&cli.StringFlag{
+					Name:    "messages",
+					Aliases: []string{"m"},
+					EnvVars: []string{"GRPC_EXAMPLE_SERVE_MESSAGES"},
+					Usage:   "Catalog of greetings in JSON, YAML, TOML, CSV, PO or XLIFF, or a directory of catalogs",
+					Value:   "messages.json",
+				},
+				&cli.StringFlag{
+					Name:    "messages-format",
+					EnvVars: []string{"GRPC_EXAMPLE_SERVE_MESSAGES_FORMAT"},
+					Usage:   "Format of the catalog if it cannot be determined from the file extension",
+				},
+				&cli.DurationFlag{
+					Name:    "reload",
+					EnvVars: []string{"GRPC_EXAMPLE_SERVE_RELOAD"},
+					Usage:   "Interval to check the catalog for changes and reload it (0 to disable)",
+				},
+				&cli.BoolFlag{
+					Name:    "strict",
+					EnvVars: []string{"GRPC_EXAMPLE_SERVE_STRICT"},
+					Usage:   "Refuse to start or reload if the catalog has errors (see catalog:lint)",
+				},
+				&cli.DurationFlag{
+					Name:    "keepalive-min-time",
+					EnvVars: []string{"GRPC_EXAMPLE_SERVE_KEEPALIVE_MIN_TIME"},
+					Usage:   "Minimum interval between client keepalive pings before the connection is closed",
+					Value:   hello.DefaultEnforcementPolicy().MinTime,
+				},
+				&cli.BoolFlag{
+					Name:    "keepalive-permit-without-stream",
+					EnvVars: []string{"GRPC_EXAMPLE_SERVE_KEEPALIVE_PERMIT_WITHOUT_STREAM"},
+					Usage:   "Allow clients to send keepalive pings when there are no active streams",
+				},
+				&cli.DurationFlag{
+					Name:    "keepalive-time",
+					EnvVars: []string{"GRPC_EXAMPLE_SERVE_KEEPALIVE_TIME"},
+					Usage:   "Interval between server keepalive pings on idle connections (0 for the gRPC default)",
+				},
+				&cli.DurationFlag{
+					Name:    "keepalive-timeout",
+					EnvVars: []string{"GRPC_EXAMPLE_SERVE_KEEPALIVE_TIMEOUT"},
+					Usage:   "How long to wait for a server keepalive ping to be acknowledged (0 for the gRPC default)",
+				},
+				&cli.DurationFlag{
+					Name:    "max-connection-idle",
+					EnvVars: []string{"GRPC_EXAMPLE_SERVE_MAX_CONNECTION_IDLE"},
+					Usage:   "Close connections that have been idle for this long (0 for no limit)",
+				},
+				&cli.DurationFlag{
+					Name:    "max-connection-age",
+					EnvVars: []string{"GRPC_EXAMPLE_SERVE_MAX_CONNECTION_AGE"},
+					Usage:   "Gracefully close connections after this long (0 for no limit)",
+				},
 			},
 		},
 		{
-			Name:   "hello",
-			Usage:  "Say hello in a given language",
-			Before: initClient,
-			Action: getHello,
-			Flags: []cli.Flag{
+			Name:     "hello",
+			Usage:    "Say hello in a given language",
+			Category: "client",
+			Before:   initClient,
+			Action:   getHello,
+			Flags: append([]cli.Flag{
 				&cli.StringFlag{
 					Name:    "lang",
 					Aliases: []string{"l"},
+					EnvVars: []string{"GRPC_EXAMPLE_HELLO_LANG"},
 					Usage:   "Language code",
 					Value:   "en",
 				},
-			},
+			}, greetingFlags("GRPC_EXAMPLE_HELLO")...),
 		},
 		{
-			Name:   "hello:many",
-			Usage:  "Say hello in many languages",
-			Before: initClient,
-			Action: getManyHellos,
-			Flags: []cli.Flag{
+			Name:     "hello:many",
+			Usage:    "Say hello in many languages",
+			Category: "client",
+			Before:   initClient,
+			Action:   getManyHellos,
+			Flags: append([]cli.Flag{
 				&cli.StringSliceFlag{
 					Name:    "langs",
 					Aliases: []string{"l"},
+					EnvVars: []string{"GRPC_EXAMPLE_HELLO_MANY_LANGS"},
 					Usage:   "Language codes",
 					Value:   cli.NewStringSlice("en", "fr", "es"),
 				},
+				&cli.BoolFlag{
+					Name:    "partial",
+					Aliases: []string{"p"},
+					EnvVars: []string{"GRPC_EXAMPLE_HELLO_MANY_PARTIAL"},
+					Usage:   "Report unknown languages instead of failing the stream",
+				},
+			}, greetingFlags("GRPC_EXAMPLE_HELLO_MANY")...),
+		},
+		{
+			Name:     "hello:stream",
+			Usage:    "Say hello incrementally",
+			Category: "client",
+			Before:   initClient,
+			Action:   getStreamHellos,
+			Flags: append([]cli.Flag{
+				&cli.BoolFlag{
+					Name:    "partial",
+					Aliases: []string{"p"},
+					EnvVars: []string{"GRPC_EXAMPLE_HELLO_STREAM_PARTIAL"},
+					Usage:   "Report unknown languages instead of failing the stream",
+				},
+			}, greetingFlags("GRPC_EXAMPLE_HELLO_STREAM")...),
+		},
+		{
+			Name:     "hello:chat",
+			Usage:    "Say hello in real-time",
+			Category: "client",
+			Before:   initClient,
+			Action:   getChatHellos,
+			Flags: append([]cli.Flag{
+				&cli.BoolFlag{
+					Name:    "partial",
+					Aliases: []string{"p"},
+					EnvVars: []string{"GRPC_EXAMPLE_HELLO_CHAT_PARTIAL"},
+					Usage:   "Report unknown languages instead of failing the stream",
+				},
+				&cli.StringFlag{
+					Name:    "history",
+					EnvVars: []string{"GRPC_EXAMPLE_HELLO_CHAT_HISTORY"},
+					Usage:   "File to persist the interactive history to (empty to disable)",
+					Value:   defaultHistoryPath(),
+				},
+			}, greetingFlags("GRPC_EXAMPLE_HELLO_CHAT")...),
+		},
+		{
+			Name:     "bench",
+			Usage:    "Measure the throughput and latency of the gRPC server",
+			Category: "client",
+			Action:   runBench,
+			Flags: []cli.Flag{
+				&cli.StringFlag{
+					Name:    "rpc",
+					Aliases: []string{"m"},
+					EnvVars: []string{"GRPC_EXAMPLE_BENCH_RPC"},
+					Usage:   "RPC to benchmark: unary, server, client or bidi",
+					Value:   string(bench.Unary),
+				},
+				&cli.IntFlag{
+					Name:    "concurrency",
+					Aliases: []string{"c"},
+					EnvVars: []string{"GRPC_EXAMPLE_BENCH_CONCURRENCY"},
+					Usage:   "Number of concurrent workers",
+					Value:   bench.DefaultConcurrency,
+				},
+				&cli.IntFlag{
+					Name:    "connections",
+					Aliases: []string{"n"},
+					EnvVars: []string{"GRPC_EXAMPLE_BENCH_CONNECTIONS"},
+					Usage:   "Number of connections shared by the workers",
+					Value:   1,
+				},
+				&cli.DurationFlag{
+					Name:    "duration",
+					Aliases: []string{"d"},
+					EnvVars: []string{"GRPC_EXAMPLE_BENCH_DURATION"},
+					Usage:   "How long to run the benchmark",
+					Value:   bench.DefaultDuration,
+				},
+				&cli.IntFlag{
+					Name:    "requests",
+					Aliases: []string{"r"},
+					EnvVars: []string{"GRPC_EXAMPLE_BENCH_REQUESTS"},
+					Usage:   "Stop after this many requests instead of after the duration",
+				},
+				&cli.Float64Flag{
+					Name:    "qps",
+					Aliases: []string{"q"},
+					EnvVars: []string{"GRPC_EXAMPLE_BENCH_QPS"},
+					Usage:   "Target requests per second across all workers (0 for unlimited)",
+				},
+				&cli.StringSliceFlag{
+					Name:    "langs",
+					Aliases: []string{"l"},
+					EnvVars: []string{"GRPC_EXAMPLE_BENCH_LANGS"},
+					Usage:   "Language mix as codes with optional weights, e.g. en=3,fr=1",
+					Value:   cli.NewStringSlice("en"),
+				},
+				&cli.IntFlag{
+					Name:    "batch",
+					Aliases: []string{"b"},
+					EnvVars: []string{"GRPC_EXAMPLE_BENCH_BATCH"},
+					Usage:   "Number of greetings per streaming RPC",
+					Value:   bench.DefaultBatch,
+				},
+				&cli.StringFlag{
+					Name:    "format",
+					Aliases: []string{"f"},
+					EnvVars: []string{"GRPC_EXAMPLE_BENCH_FORMAT"},
+					Usage:   "Report format: text or json (json if --output is json or ndjson)",
+					Value:   "text",
+				},
 			},
 		},
 		{
-			Name:   "hello:stream",
-			Usage:  "Say hello incrementally",
-			Before: initClient,
-			Action: getStreamHellos,
-			Flags:  []cli.Flag{},
+			Name:      "catalog:lint",
+			Usage:     "Check catalogs for invalid language codes, greetings and duplicates",
+			ArgsUsage: "PATH...",
+			Category:  "catalog",
+			Action:    lintCatalogs,
+			Flags: []cli.Flag{
+				&cli.StringFlag{
+					Name:    "format",
+					Aliases: []string{"f"},
+					EnvVars: []string{"GRPC_EXAMPLE_CATALOG_LINT_FORMAT"},
+					Usage:   "Format of the catalogs (json, yaml, toml, csv, po or xliff) if not their extension",
+				},
+				&cli.BoolFlag{
+					Name:    "strict",
+					EnvVars: []string{"GRPC_EXAMPLE_CATALOG_LINT_STRICT"},
+					Usage:   "Fail if there are warnings as well as errors",
+				},
+			},
 		},
 		{
-			Name:   "hello:chat",
-			Usage:  "Say hello in real-time",
-			Before: initClient,
-			Action: getChatHellos,
-			Flags:  []cli.Flag{},
+			Name:      "catalog:convert",
+			Usage:     "Convert a catalog of greetings between formats",
+			ArgsUsage: "SRC DST",
+			Category:  "catalog",
+			Action:    convertCatalog,
+			Flags: []cli.Flag{
+				&cli.StringFlag{
+					Name:    "from",
+					Aliases: []string{"f"},
+					EnvVars: []string{"GRPC_EXAMPLE_CATALOG_CONVERT_FROM"},
+					Usage:   "Format of SRC (json, yaml, toml, csv, po or xliff) if not its extension",
+				},
+				&cli.StringFlag{
+					Name:    "to",
+					Aliases: []string{"t"},
+					EnvVars: []string{"GRPC_EXAMPLE_CATALOG_CONVERT_TO"},
+					Usage:   "Format of DST (json, yaml, toml, csv, po or xliff) if not its extension",
+				},
+			},
 		},
 	}
 
-	app.Run(os.Args)
+	// Configure flags from the environment and config file before commands are run
+	app.Before = loadConfig
+	configureCommands(app.Commands)
+	return app
 }
 
 // Start the gRPC server and block until stopped
 func serve(c *cli.Context) (err error) {
 	addr := c.String("bindaddr")
 
-	var server *hello.Server
-	if server, err = hello.NewServer(); err != nil {
+	opts := []grpc.ServerOption{
+		grpc.KeepaliveEnforcementPolicy(keepalive.EnforcementPolicy{
+			MinTime:             c.Duration("keepalive-min-time"),
+			PermitWithoutStream: c.Bool("keepalive-permit-without-stream"),
+		}),
+		grpc.KeepaliveParams(keepalive.ServerParameters{
+			Time:              c.Duration("keepalive-time"),
+			Timeout:           c.Duration("keepalive-timeout"),
+			MaxConnectionIdle: c.Duration("max-connection-idle"),
+			MaxConnectionAge:  c.Duration("max-connection-age"),
+		}),
+	}
+
+	if path := c.String("record"); path != "" {
+		var rec *record.Recorder
+		if rec, err = record.Create(path); err != nil {
+			return cli.Exit(err, 1)
+		}
+		defer rec.Close()
+		opts = append(opts, rec.ServerOptions()...)
+	}
+
+	var format catalog.Format
+	if name := c.String("messages-format"); name != "" {
+		if format, err = catalog.ParseFormat(name); err != nil {
+			return cli.Exit(err, 1)
+		}
+	}
+
+	loadOpts := []hello.LoadOption{hello.WithFormat(format)}
+	if c.Bool("strict") {
+		loadOpts = append(loadOpts, hello.Strict())
+	}
+
+	messages := &hello.Messages{}
+	if err = messages.Load(c.String("messages"), loadOpts...); err != nil {
 		return cli.Exit(err, 1)
 	}
+	printIssues(os.Stderr, c.String("messages"), messages.Validate())
+
+	if interval := c.Duration("reload"); interval > 0 {
+		ctx, cancel := context.WithCancel(context.Background())
+		defer cancel()
+		go messages.Watch(ctx, interval, func(changed []string, err error) {
+			if err != nil {
+				fmt.Fprintln(os.Stderr, "could not reload the catalog:", err)
+				return
+			}
+			fmt.Println("Reloaded", strings.Join(changed, ", "))
+			printIssues(os.Stderr, c.String("messages"), messages.Validate())
+		})
+	}
+
+	server := hello.NewServerWithMessages(messages, opts...)
 
 	fmt.Println("Starting the server on", addr)
 	if err = server.Serve(addr); err != nil {
@@ -105,19 +413,168 @@ func serve(c *cli.Context) (err error) {
 	return nil
 }
 
+// Lint the catalogs, which may be files or directories, printing every issue and
+// failing if any catalog has errors (or warnings in strict mode) or cannot be read.
+func lintCatalogs(c *cli.Context) (err error) {
+	if c.NArg() == 0 {
+		return cli.Exit("specify the catalogs to lint", 1)
+	}
+
+	var format catalog.Format
+	if name := c.String("format"); name != "" {
+		if format, err = catalog.ParseFormat(name); err != nil {
+			return cli.Exit(err, 1)
+		}
+	}
+
+	var errs, warnings int
+	for _, path := range c.Args().Slice() {
+		messages := &hello.Messages{}
+		if err = messages.Load(path, hello.WithFormat(format)); err != nil {
+			fmt.Printf("%s: %s\n", path, err)
+			errs++
+			continue
+		}
+
+		issues := messages.Validate()
+		printIssues(os.Stdout, path, issues)
+		errs += len(issues.Errors())
+		warnings += len(issues.Warnings())
+	}
+
+	fmt.Printf("%d errors, %d warnings\n", errs, warnings)
+	if errs > 0 || (c.Bool("strict") && warnings > 0) {
+		return cli.Exit("", 1)
+	}
+	return nil
+}
+
+// Print the issues found in a catalog, one per line prefixed with the path
+func printIssues(w io.Writer, path string, issues hello.Issues) {
+	for _, issue := range issues {
+		fmt.Fprintf(w, "%s: %s\n", path, issue)
+	}
+}
+
+// Convert a catalog from one format to another. Either path may be - for stdin or
+// stdout, in which case its format must be specified.
+func convertCatalog(c *cli.Context) (err error) {
+	if c.NArg() != 2 {
+		return cli.Exit("specify the source and destination catalogs", 1)
+	}
+	src, dst := c.Args().Get(0), c.Args().Get(1)
+
+	var from, to catalog.Format
+	if from, err = catalogFormat(src, c.String("from")); err != nil {
+		return cli.Exit(err, 1)
+	}
+
+	if to, err = catalogFormat(dst, c.String("to")); err != nil {
+		return cli.Exit(err, 1)
+	}
+
+	var messages catalog.Catalog
+	if src == "-" {
+		messages, err = catalog.Decode(os.Stdin, from)
+	} else {
+		messages, err = catalog.ReadFile(src, from)
+	}
+	if err != nil {
+		return cli.Exit(err, 1)
+	}
+
+	if dst == "-" {
+		err = catalog.Encode(os.Stdout, to, messages)
+	} else {
+		err = catalog.WriteFile(dst, to, messages)
+	}
+	if err != nil {
+		return cli.Exit(err, 1)
+	}
+	return nil
+}
+
+// The format of a catalog is the named format or is determined by the path extension.
+func catalogFormat(path, name string) (catalog.Format, error) {
+	if name != "" {
+		return catalog.ParseFormat(name)
+	}
+
+	if path == "-" {
+		return "", fmt.Errorf("the format must be specified to use stdin or stdout")
+	}
+	return catalog.FormatOf(path)
+}
+
+// Run a load test against the server and print a report of the results
+func runBench(c *cli.Context) (err error) {
+	opts := bench.Options{
+		RPC:         bench.RPC(c.String("rpc")),
+		Concurrency: c.Int("concurrency"),
+		Connections: c.Int("connections"),
+		Duration:    c.Duration("duration"),
+		Requests:    c.Int("requests"),
+		QPS:         c.Float64("qps"),
+		Batch:       c.Int("batch"),
+		Seed:        time.Now().UnixNano(),
+	}
+
+	if opts.Languages, err = bench.ParseLanguages(c.StringSlice("langs")); err != nil {
+		return cli.Exit(err, 1)
+	}
+
+	format := c.String("format")
+	if !c.IsSet("format") {
+		switch c.String("output") {
+		case formatJSON, formatNDJSON:
+			format = "json"
+		}
+	}
+
+	if format != "text" && format != "json" {
+		return cli.Exit(fmt.Errorf("unknown format %q: must be text or json", format), 1)
+	}
+
+	var report *bench.Report
+	if report, err = bench.Run(c.Context, c.String("endpoint"), opts, grpc.WithTransportCredentials(insecure.NewCredentials())); err != nil {
+		return cli.Exit(err, 1)
+	}
+
+	if format == "json" {
+		err = report.WriteJSON(os.Stdout)
+	} else {
+		err = report.WriteText(os.Stdout)
+	}
+
+	if err != nil {
+		return cli.Exit(err, 1)
+	}
+	return nil
+}
+
 var client *hello.Client
 
 // Initialize a gRPC client
 func initClient(c *cli.Context) (err error) {
 	endpoint := c.String("endpoint")
 
-	opts := []grpc.DialOption{
-		grpc.WithTransportCredentials(insecure.NewCredentials()),
+	opts := []grpc.DialOption{grpc.WithTransportCredentials(insecure.NewCredentials())}
+
+	// A zero interval disables keepalive pings
+	if interval := c.Duration("keepalive"); interval > 0 {
+		opts = append(opts, grpc.WithKeepaliveParams(keepalive.ClientParameters{
+			Time:    interval,
+			Timeout: c.Duration("keepalive-timeout"),
+		}))
+	} else {
+		opts = append(opts, hello.WithoutKeepalive())
 	}
+
 	if client, err = hello.NewClient(endpoint, opts...); err != nil {
 		return cli.Exit(err, 1)
 	}
 
+	client.SetTimeout(c.Duration("timeout"))
 	return nil
 }
 
@@ -126,35 +583,72 @@ func getHello(c *cli.Context) (err error) {
 	ctx := context.Background()
 	lang := c.String("lang")
 
-	var msg string
-	if msg, err = client.SayHello(ctx, lang); err != nil {
+	var opts []hello.StreamOption
+	if opts, err = streamOptions(c); err != nil {
 		return cli.Exit(err, 1)
 	}
 
-	fmt.Println(msg)
-	return nil
+	var out *printer
+	if out, err = newPrinter(c, false); err != nil {
+		return err
+	}
+
+	warnDeprecated(lang)
+
+	var rep *pb.HelloReply
+	if rep, err = client.Unary(ctx, lang, opts...); err != nil {
+		return cli.Exit(err, 1)
+	}
+
+	if err = out.Print(rep); err != nil {
+		return cli.Exit(err, 1)
+	}
+	return printed(out)
 }
 
 // Get hello messages in many languages
 func getManyHellos(c *cli.Context) (err error) {
 	ctx := context.Background()
+
+	var opts []hello.StreamOption
+	if opts, err = streamOptions(c); err != nil {
+		return cli.Exit(err, 1)
+	}
 	langs := c.StringSlice("langs")
+	warnDeprecated(langs...)
+
+	var out *printer
+	if out, err = newPrinter(c, true); err != nil {
+		return err
+	}
 
-	var msgs []string
-	if msgs, err = client.SayServerStream(ctx, langs); err != nil {
+	var replies *hello.Replies
+	if replies, err = client.ServerStream(ctx, langs, opts...); err != nil {
 		return cli.Exit(err, 1)
 	}
+	defer replies.Close()
 
-	for _, msg := range msgs {
-		fmt.Println(msg)
+	// Print the greetings as they arrive from the server
+	if err = printReplies(out, replies); err != nil {
+		return err
 	}
-	return nil
+	return printed(out)
 }
 
 // Stream hello messages to the server and retrieve them all at once
 func getStreamHellos(c *cli.Context) (err error) {
 	ctx := context.Background()
 
+	var opts []hello.StreamOption
+	if opts, err = streamOptions(c); err != nil {
+		return cli.Exit(err, 1)
+	}
+
+	var out *printer
+	if out, err = newPrinter(c, true); err != nil {
+		return err
+	}
+
 	langs := make(chan string)
 	var wg sync.WaitGroup
 	wg.Add(1)
@@ -162,7 +656,7 @@ func getStreamHellos(c *cli.Context) (err error) {
 		defer wg.Done()
 		reader := bufio.NewReader(os.Stdin)
 		for {
-			fmt.Print("Enter a language code: ")
+			fmt.Fprint(os.Stderr, "Enter a language code: ")
 			input, _ := reader.ReadString('\n')
 			lang := strings.TrimSpace(input)
 			if lang == "" {
@@ -173,56 +667,229 @@ func getStreamHellos(c *cli.Context) (err error) {
 		close(langs)
 	}()
 
-	var msgs []string
-	if msgs, err = client.SayClientStream(ctx, langs); err != nil {
+	var replies *hello.Replies
+	if replies, err = client.ClientStream(ctx, langs, opts...); err != nil {
 		return cli.Exit(err, 1)
 	}
+	defer replies.Close()
 
-	for _, msg := range msgs {
-		fmt.Println(msg)
+	if err = printReplies(out, replies); err != nil {
+		return err
 	}
 
 	wg.Wait()
-	return nil
+	return printed(out)
 }
 
 // Stream hello messages in real time
 func getChatHellos(c *cli.Context) (err error) {
-	var wg sync.WaitGroup
 	ctx := context.Background()
 
-	// Go routine to send language codes to the server
-	langs := make(chan string)
-	wg.Add(1)
+	var opts []hello.StreamOption
+	if opts, err = streamOptions(c); err != nil {
+		return cli.Exit(err, 1)
+	}
+
+	var out *printer
+	if out, err = newPrinter(c, true); err != nil {
+		return err
+	}
+
+	var session *hello.Session
+	if session, err = client.Bidirectional(ctx, opts...); err != nil {
+		return cli.Exit(err, 1)
+	}
+	defer session.Close()
+
+	// Start the interactive chat if attached to a terminal
+	if term.IsTerminal(int(os.Stdin.Fd())) && term.IsTerminal(int(os.Stdout.Fd())) {
+		return chat(c, session)
+	}
+
+	// Go routine to send language codes to the server until an empty line is entered
 	go func() {
-		defer wg.Done()
 		reader := bufio.NewReader(os.Stdin)
 		for {
 			input, _ := reader.ReadString('\n')
 			lang := strings.TrimSpace(input)
 			if lang == "" {
-				break
+				session.CloseSend()
+				return
+			}
+
+			if err := session.Send(lang); err != nil {
+				return
 			}
-			langs <- strings.TrimSpace(lang)
 		}
-		close(langs)
 	}()
 
-	// Go routine to receive messages from the server
-	messages := make(chan string)
-	wg.Add(1)
-	go func() {
-		defer wg.Done()
-		for msg := range messages {
-			fmt.Println(msg)
+	// Print messages from the server until the session ends
+	fmt.Fprintln(os.Stderr, "Enter some language codes")
+	for rep := range session.Replies() {
+		if err = out.Print(rep); err != nil {
+			return cli.Exit(err, 1)
 		}
-	}()
+	}
 
-	fmt.Println("Enter some language codes")
-	if err = client.SayBidirectional(ctx, langs, messages); err != nil {
+	if err = session.Err(); err != nil {
+		return failed(out, err)
+	}
+	return printed(out)
+}
+
+// Run the interactive chat with the terminal in raw mode
+func chat(c *cli.Context, session *hello.Session) (err error) {
+	var hist *history
+	if hist, err = loadHistory(c.String("history")); err != nil {
 		return cli.Exit(err, 1)
 	}
 
-	wg.Wait()
+	var state *term.State
+	if state, err = term.MakeRaw(int(os.Stdin.Fd())); err != nil {
+		return cli.Exit(err, 1)
+	}
+	defer term.Restore(int(os.Stdin.Fd()), state)
+
+	stdio := struct {
+		io.Reader
+		io.Writer
+	}{os.Stdin, os.Stdout}
+
+	repl := newREPL(stdio, client, session, hist)
+	for _, format := range replFormats {
+		if format == c.String("output") {
+			repl.format = format
+		}
+	}
+
+	if err = repl.Run(context.Background()); err != nil {
+		return cli.Exit(err, 1)
+	}
+	return nil
+}
+
+// Warn about deprecated language codes, which the server answers with the canonical code
+func warnDeprecated(langs ...string) {
+	for _, lang := range langs {
+		if canonical, deprecated := hello.Canonical(lang); deprecated {
+			fmt.Fprintf(os.Stderr, "warning: the language code %q is deprecated, use %q\n", lang, canonical)
+		}
+	}
+}
+
+// The flags of the hello commands that determine the greeting, with environment
+// variables prefixed by the name of the command, e.g. GRPC_EXAMPLE_HELLO_MANY.
+func greetingFlags(envPrefix string) []cli.Flag {
+	return []cli.Flag{
+		&cli.StringFlag{
+			Name:    "name",
+			EnvVars: []string{envPrefix + "_NAME"},
+			Usage:   "Name of the person to greet in greeting templates",
+		},
+		&cli.StringSliceFlag{
+			Name:    "param",
+			Aliases: []string{"P"},
+			EnvVars: []string{envPrefix + "_PARAM"},
+			Usage:   "Greeting template parameter as key=value, e.g. gender=female (repeatable)",
+		},
+		&cli.StringFlag{
+			Name:    "register",
+			EnvVars: []string{envPrefix + "_REGISTER"},
+			Usage:   "Prefer the formal or informal variant of the greeting",
+		},
+		&cli.StringFlag{
+			Name:    "timezone",
+			Aliases: []string{"tz"},
+			EnvVars: []string{envPrefix + "_TIMEZONE"},
+			Usage:   "IANA timezone used to select a variant for the time of day, e.g. Europe/Berlin",
+		},
+		&cli.StringFlag{
+			Name:    "context",
+			EnvVars: []string{envPrefix + "_CONTEXT"},
+			Usage:   "Prefer the variant of the greeting for the context, e.g. email",
+		},
+		&cli.StringFlag{
+			Name:    "select",
+			EnvVars: []string{envPrefix + "_SELECT"},
+			Usage:   "Choose among equally suitable variants: deterministic, round-robin or random",
+		},
+		&cli.StringFlag{
+			Name:    "user-id",
+			EnvVars: []string{envPrefix + "_USER_ID"},
+			Usage:   "ID of the user being greeted, which seeds the deterministic selection",
+		},
+	}
+}
+
+// Get the streaming options from the command line flags
+func streamOptions(c *cli.Context) (opts []hello.StreamOption, err error) {
+	if c.Bool("partial") {
+		opts = append(opts, hello.PartialSuccess())
+	}
+
+	if name := c.String("name"); name != "" {
+		opts = append(opts, hello.WithName(name))
+	}
+
+	if values := c.StringSlice("param"); len(values) > 0 {
+		params := make(map[string]string, len(values))
+		for _, value := range values {
+			key, val, ok := strings.Cut(value, "=")
+			if !ok || key == "" {
+				return nil, fmt.Errorf("invalid parameter %q: expected key=value", value)
+			}
+			params[key] = val
+		}
+		opts = append(opts, hello.WithParams(params))
+	}
+
+	if register := c.String("register"); register != "" {
+		opts = append(opts, hello.WithRegister(register))
+	}
+
+	if timezone := c.String("timezone"); timezone != "" {
+		opts = append(opts, hello.WithTimezone(timezone))
+	}
+
+	if tag := c.String("context"); tag != "" {
+		opts = append(opts, hello.WithContext(tag))
+	}
+
+	if selection := c.String("select"); selection != "" {
+		opts = append(opts, hello.WithSelection(hello.Selection(selection)))
+	}
+
+	if userID := c.String("user-id"); userID != "" {
+		opts = append(opts, hello.WithUserID(userID))
+	}
+	return opts, nil
+}
+
+// Print every reply from the iterator, returning the terminal error of the stream
+func printReplies(out *printer, replies *hello.Replies) (err error) {
+	for replies.Next() {
+		if err = out.Print(replies.Reply()); err != nil {
+			return cli.Exit(err, 1)
+		}
+	}
+
+	if err = replies.Err(); err != nil {
+		return failed(out, err)
+	}
+	return nil
+}
+
+// Flush the replies that were received before the stream failed so that they are not
+// lost from buffered output, then return the error of the stream
+func failed(out *printer, err error) error {
+	out.Flush()
+	return cli.Exit(err, 1)
+}
+
+// Flush the buffered output once all the replies have been printed
+func printed(out *printer) error {
+	if err := out.Flush(); err != nil {
+		return cli.Exit(err, 1)
+	}
 	return nil
 }
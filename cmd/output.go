@@ -0,0 +1,153 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/pdeziel/grpc-example/pb"
+	"github.com/urfave/cli/v2"
+	"google.golang.org/grpc/codes"
+	"gopkg.in/yaml.v3"
+)
+
+// Output formats supported by the --output flag.
+const (
+	formatPlain  = "plain"
+	formatJSON   = "json"
+	formatNDJSON = "ndjson"
+	formatYAML   = "yaml"
+	formatTable  = "table"
+)
+
+var formats = []string{formatPlain, formatJSON, formatNDJSON, formatYAML, formatTable}
+
+// The reply data that is printed in machine readable formats.
+type reply struct {
+	Language     string      `json:"iso_language_code" yaml:"iso_language_code"`
+	Greeting     string      `json:"greeting,omitempty" yaml:"greeting,omitempty"`
+	Script       string      `json:"script,omitempty" yaml:"script,omitempty"`
+	Direction    string      `json:"direction,omitempty" yaml:"direction,omitempty"`
+	Romanization string      `json:"romanization,omitempty" yaml:"romanization,omitempty"`
+	ID           uint64      `json:"id" yaml:"id"`
+	CreatedAt    string      `json:"created_at,omitempty" yaml:"created_at,omitempty"`
+	Error        *replyError `json:"error,omitempty" yaml:"error,omitempty"`
+}
+
+type replyError struct {
+	Code    string `json:"code" yaml:"code"`
+	Message string `json:"message" yaml:"message"`
+}
+
+func newReply(rep *pb.HelloReply) *reply {
+	out := &reply{
+		Language:     rep.IsoLanguageCode,
+		Greeting:     rep.Greeting,
+		Script:       rep.Script,
+		Direction:    rep.Direction,
+		Romanization: rep.Romanization,
+		ID:           rep.Id,
+		CreatedAt:    rep.CreatedAt,
+	}
+
+	if rep.Error != nil {
+		out.Error = &replyError{
+			Code:    codes.Code(rep.Error.Code).String(),
+			Message: rep.Error.Message,
+		}
+	}
+	return out
+}
+
+// Printer writes replies to stdout in the format selected by the --output flag. Replies
+// from a single RPC are printed as a single value, while replies from streams are
+// printed as a list; NDJSON and plain output are written as each reply arrives but the
+// other formats are buffered until Flush so that they are valid and aligned.
+type printer struct {
+	format  string
+	out     io.Writer
+	errw    io.Writer
+	stream  bool
+	replies []*reply
+}
+
+// Create a printer from the global --output flag; if stream is true the replies are
+// printed as a list even if there is only one.
+func newPrinter(c *cli.Context, stream bool) (_ *printer, err error) {
+	format := strings.ToLower(c.String("output"))
+	for _, f := range formats {
+		if f == format {
+			return &printer{format: format, out: os.Stdout, errw: os.Stderr, stream: stream}, nil
+		}
+	}
+	return nil, cli.Exit(fmt.Errorf("unknown output format %q: must be one of %s", format, strings.Join(formats, ", ")), 1)
+}
+
+// Print a reply or buffer it until the output is flushed.
+func (p *printer) Print(rep *pb.HelloReply) error {
+	switch p.format {
+	case formatPlain:
+		// In partial success mode print the reason a greeting could not be produced
+		if rep.Error != nil {
+			_, err := fmt.Fprintf(p.errw, "%s: %s\n", rep.IsoLanguageCode, rep.Error.Message)
+			return err
+		}
+		_, err := fmt.Fprintln(p.out, rep.Greeting)
+		return err
+	case formatNDJSON:
+		return json.NewEncoder(p.out).Encode(newReply(rep))
+	default:
+		p.replies = append(p.replies, newReply(rep))
+		return nil
+	}
+}
+
+// Flush writes any buffered replies; it must be called once all replies are printed.
+func (p *printer) Flush() (err error) {
+	switch p.format {
+	case formatJSON:
+		enc := json.NewEncoder(p.out)
+		enc.SetIndent("", "  ")
+		return enc.Encode(p.value())
+	case formatYAML:
+		enc := yaml.NewEncoder(p.out)
+		enc.SetIndent(2)
+		if err = enc.Encode(p.value()); err != nil {
+			return err
+		}
+		return enc.Close()
+	case formatTable:
+		return p.table()
+	default:
+		return nil
+	}
+}
+
+// The buffered replies as a single value or list for JSON and YAML.
+func (p *printer) value() interface{} {
+	if !p.stream && len(p.replies) == 1 {
+		return p.replies[0]
+	}
+
+	if p.replies == nil {
+		return []*reply{}
+	}
+	return p.replies
+}
+
+func (p *printer) table() error {
+	tw := tabwriter.NewWriter(p.out, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(tw, "LANGUAGE\tGREETING\tSCRIPT\tDIRECTION\tROMANIZATION\tID\tCREATED AT\tERROR")
+	for _, rep := range p.replies {
+		var errmsg string
+		if rep.Error != nil {
+			errmsg = rep.Error.Code + ": " + rep.Error.Message
+		}
+		fmt.Fprintf(tw, "%s\t%s\t%s\t%s\t%s\t%s\t%s\t%s\n", rep.Language, rep.Greeting, rep.Script, rep.Direction, rep.Romanization, strconv.FormatUint(rep.ID, 10), rep.CreatedAt, errmsg)
+	}
+	return tw.Flush()
+}
@@ -0,0 +1,128 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"regexp"
+	"strings"
+	"testing"
+	"time"
+
+	hello "github.com/pdeziel/grpc-example"
+	"github.com/pdeziel/grpc-example/hellotest"
+	"github.com/stretchr/testify/require"
+)
+
+// Print the replies of a server stream for the languages in the format, returning the
+// output, the errors printed to stderr and the error of the stream.
+func printStream(t *testing.T, format string, langs []string, opts ...hello.StreamOption) (out, errs string, err error) {
+	t.Helper()
+	now := time.Date(2023, 6, 1, 9, 30, 0, 0, time.UTC)
+	client := hellotest.NewClient(t, hellotest.WithClock(func() time.Time { return now }))
+
+	replies, err := client.ServerStream(context.Background(), langs, opts...)
+	require.NoError(t, err)
+	defer replies.Close()
+
+	var stdout, stderr bytes.Buffer
+	p := &printer{format: format, out: &stdout, errw: &stderr, stream: true}
+	if err = printReplies(p, replies); err == nil {
+		require.NoError(t, printed(p))
+	}
+
+	// Tables pad the empty error column with trailing spaces
+	out = trailingSpace.ReplaceAllString(stdout.String(), "\n")
+	return out, stderr.String(), err
+}
+
+var trailingSpace = regexp.MustCompile(` +\n`)
+
+func TestPrinter(t *testing.T) {
+	testCases := []struct {
+		format string
+		output string
+	}{
+		{formatPlain, "Hello\nBonjour\n"},
+		{
+			formatJSON,
+			`[
+  {
+    "iso_language_code": "en",
+    "greeting": "Hello",
+    "script": "Latn",
+    "direction": "ltr",
+    "id": 0,
+    "created_at": "2023-06-01T09:30:00Z"
+  },
+  {
+    "iso_language_code": "fr",
+    "greeting": "Bonjour",
+    "script": "Latn",
+    "direction": "ltr",
+    "id": 0,
+    "created_at": "2023-06-01T09:30:00Z"
+  }
+]
+`,
+		},
+		{
+			formatNDJSON,
+			`{"iso_language_code":"en","greeting":"Hello","script":"Latn","direction":"ltr","id":0,"created_at":"2023-06-01T09:30:00Z"}
+{"iso_language_code":"fr","greeting":"Bonjour","script":"Latn","direction":"ltr","id":0,"created_at":"2023-06-01T09:30:00Z"}
+`,
+		},
+		{
+			formatYAML,
+			`- iso_language_code: en
+  greeting: Hello
+  script: Latn
+  direction: ltr
+  id: 0
+  created_at: "2023-06-01T09:30:00Z"
+- iso_language_code: fr
+  greeting: Bonjour
+  script: Latn
+  direction: ltr
+  id: 0
+  created_at: "2023-06-01T09:30:00Z"
+`,
+		},
+		{
+			formatTable,
+			`LANGUAGE  GREETING  SCRIPT  DIRECTION  ROMANIZATION  ID  CREATED AT            ERROR
+en        Hello     Latn    ltr                      0   2023-06-01T09:30:00Z
+fr        Bonjour   Latn    ltr                      0   2023-06-01T09:30:00Z
+`,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.format, func(t *testing.T) {
+			out, _, err := printStream(t, tc.format, []string{"en", "fr"})
+			require.NoError(t, err)
+			require.Equal(t, tc.output, out)
+
+			// If the stream fails the replies received before the failure are printed
+			failed, _, err := printStream(t, tc.format, []string{"en", "fr", "xx"})
+			require.Error(t, err)
+			require.Equal(t, out, failed)
+		})
+	}
+}
+
+func TestPrinterPartialSuccess(t *testing.T) {
+	out, errs, err := printStream(t, formatPlain, []string{"en", "xx"}, hello.PartialSuccess())
+	require.NoError(t, err)
+	require.Equal(t, "Hello\n", out)
+	require.Equal(t, "xx: language \"xx\" not found\n", errs)
+
+	out, _, err = printStream(t, formatNDJSON, []string{"xx"}, hello.PartialSuccess())
+	require.NoError(t, err)
+	require.Equal(t, `{"iso_language_code":"xx","id":0,"created_at":"2023-06-01T09:30:00Z","error":{"code":"NotFound","message":"language \"xx\" not found"}}`+"\n", out)
+
+	out, _, err = printStream(t, formatTable, []string{"xx"}, hello.PartialSuccess())
+	require.NoError(t, err)
+	lines := strings.Split(strings.TrimSpace(out), "\n")
+	require.Len(t, lines, 2)
+	require.Equal(t, []string{"xx", "0", "2023-06-01T09:30:00Z", "NotFound:", "language", `"xx"`, "not", "found"}, strings.Fields(lines[1]))
+}
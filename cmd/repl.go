@@ -0,0 +1,383 @@
+package main
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+
+	hello "github.com/pdeziel/grpc-example"
+	"github.com/pdeziel/grpc-example/pb"
+	"golang.org/x/term"
+	"google.golang.org/grpc/codes"
+	"gopkg.in/yaml.v3"
+)
+
+const (
+	prompt     = "hello> "
+	maxHistory = 500
+)
+
+// Slash commands available in the interactive chat.
+var commands = []string{"/format", "/help", "/langs", "/quit"}
+
+// Formats that replies can be displayed in as they arrive in the interactive chat.
+var replFormats = []string{formatPlain, formatJSON, formatYAML}
+
+// The interactive mode of hello:chat reads language codes with line editing, history
+// and tab completion of the languages that the server has greetings for. Replies are
+// printed with their metadata as they arrive without disrupting the line being edited.
+type repl struct {
+	client  *hello.Client
+	session *hello.Session
+	term    *term.Terminal
+	history *history
+
+	sync.Mutex
+	format string
+	langs  []string
+}
+
+func newREPL(rw io.ReadWriter, client *hello.Client, session *hello.Session, history *history) *repl {
+	replay := &replayer{ReadWriter: rw}
+	r := &repl{
+		client:  client,
+		session: session,
+		term:    term.NewTerminal(replay, prompt),
+		history: history,
+		format:  formatPlain,
+	}
+
+	if history != nil {
+		replay.seed(r.term, history.entries)
+	}
+	r.term.AutoCompleteCallback = r.complete
+	return r
+}
+
+// The terminal has no way to set its line editing history, so the persisted history
+// is seeded by replaying the entries as lines of input with the output discarded.
+type replayer struct {
+	io.ReadWriter
+	input *strings.Reader
+	muted bool
+}
+
+func (p *replayer) seed(t *term.Terminal, entries []string) {
+	if len(entries) == 0 {
+		return
+	}
+
+	p.input = strings.NewReader(strings.Join(entries, "\r") + "\r")
+	p.muted = true
+	for range entries {
+		if _, err := t.ReadLine(); err != nil {
+			break
+		}
+	}
+	p.input, p.muted = nil, false
+}
+
+func (p *replayer) Read(b []byte) (int, error) {
+	if p.input != nil && p.input.Len() > 0 {
+		return p.input.Read(b)
+	}
+	return p.ReadWriter.Read(b)
+}
+
+func (p *replayer) Write(b []byte) (int, error) {
+	if p.muted {
+		return len(b), nil
+	}
+	return p.ReadWriter.Write(b)
+}
+
+// Run the chat until the user quits, input ends, or the session is closed by the server.
+func (r *repl) Run(ctx context.Context) error {
+	if err := r.refreshLanguages(ctx); err != nil {
+		fmt.Fprintf(r.term, "could not fetch languages for completion: %s\n", err)
+	}
+	fmt.Fprintln(r.term, "Enter language codes to say hello, /help for commands")
+
+	// Print replies as they arrive until the session ends
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		for rep := range r.session.Replies() {
+			r.print(rep)
+		}
+	}()
+
+	lines := make(chan string)
+	go func() {
+		defer close(lines)
+		for {
+			line, err := r.term.ReadLine()
+			if err != nil {
+				return
+			}
+
+			if r.history != nil {
+				r.history.Add(line)
+			}
+
+			select {
+			case lines <- line:
+			case <-done:
+				return
+			}
+		}
+	}()
+
+	for {
+		select {
+		case <-done:
+			return r.session.Err()
+		case line, ok := <-lines:
+			if !ok || r.handle(ctx, line) {
+				// Wait for the replies to requests that have already been sent
+				r.session.CloseSend()
+				<-done
+				return r.session.Err()
+			}
+		}
+	}
+}
+
+// Handle a line of input, returning true if the user asked to quit.
+func (r *repl) handle(ctx context.Context, line string) (quit bool) {
+	line = strings.TrimSpace(line)
+	if line == "" {
+		return false
+	}
+
+	if !strings.HasPrefix(line, "/") {
+		if err := r.session.Send(line); err != nil {
+			if errors.Is(err, hello.ErrSessionClosed) {
+				return true
+			}
+			fmt.Fprintln(r.term, err)
+		}
+		return false
+	}
+
+	args := strings.Fields(line)
+	switch args[0] {
+	case "/quit", "/exit":
+		return true
+	case "/help":
+		fmt.Fprintln(r.term, "Commands:")
+		fmt.Fprintln(r.term, "  /langs             list the languages the server can greet in")
+		fmt.Fprintf(r.term, "  /format [format]   show or set the reply format (%s)\n", strings.Join(replFormats, ", "))
+		fmt.Fprintln(r.term, "  /quit              close the session once all replies are received")
+	case "/langs":
+		if err := r.refreshLanguages(ctx); err != nil {
+			fmt.Fprintln(r.term, err)
+			return false
+		}
+		fmt.Fprintln(r.term, strings.Join(r.languages(), " "))
+	case "/format":
+		r.setFormat(args[1:])
+	default:
+		fmt.Fprintf(r.term, "unknown command %s, try /help\n", args[0])
+	}
+	return false
+}
+
+func (r *repl) setFormat(args []string) {
+	r.Lock()
+	defer r.Unlock()
+
+	if len(args) == 0 {
+		fmt.Fprintln(r.term, r.format)
+		return
+	}
+
+	for _, format := range replFormats {
+		if format == args[0] {
+			r.format = format
+			return
+		}
+	}
+	fmt.Fprintf(r.term, "unknown format %q: must be one of %s\n", args[0], strings.Join(replFormats, ", "))
+}
+
+// Print a reply with its metadata in the current format.
+func (r *repl) print(rep *pb.HelloReply) {
+	r.Lock()
+	format := r.format
+	r.Unlock()
+
+	switch format {
+	case formatJSON:
+		data, _ := json.Marshal(newReply(rep))
+		fmt.Fprintf(r.term, "%s\n", data)
+	case formatYAML:
+		data, _ := yaml.Marshal(newReply(rep))
+		fmt.Fprintf(r.term, "---\n%s", data)
+	default:
+		if rep.Error != nil {
+			fmt.Fprintf(r.term, "%s: %s [%s]\n", rep.IsoLanguageCode, rep.Error.Message, codes.Code(rep.Error.Code))
+			return
+		}
+		fmt.Fprintf(r.term, "%s  [%s #%d %s]\n", rep.Greeting, rep.IsoLanguageCode, rep.Id, rep.CreatedAt)
+	}
+}
+
+func (r *repl) refreshLanguages(ctx context.Context) (err error) {
+	var langs []string
+	if langs, err = r.client.Languages(ctx); err != nil {
+		return err
+	}
+
+	r.Lock()
+	r.langs = langs
+	r.Unlock()
+	return nil
+}
+
+func (r *repl) languages() []string {
+	r.Lock()
+	defer r.Unlock()
+	return r.langs
+}
+
+// Complete language codes, slash commands and formats when tab is pressed. If there is
+// more than one candidate the line is completed to their common prefix and the
+// candidates are listed.
+func (r *repl) complete(line string, pos int, key rune) (string, int, bool) {
+	if key != '\t' {
+		return "", 0, false
+	}
+
+	prefix := line[:pos]
+	base, word := "", prefix
+	candidates := r.languages()
+
+	switch {
+	case strings.HasPrefix(prefix, "/format "):
+		base, word = "/format ", strings.TrimPrefix(prefix, "/format ")
+		candidates = replFormats
+	case strings.HasPrefix(prefix, "/"):
+		candidates = commands
+	}
+
+	matches := make([]string, 0)
+	for _, candidate := range candidates {
+		if strings.HasPrefix(candidate, word) {
+			matches = append(matches, candidate)
+		}
+	}
+
+	switch len(matches) {
+	case 0:
+		return "", 0, false
+	case 1:
+		completed := base + matches[0]
+		return completed + line[pos:], len(completed), true
+	}
+
+	common := commonPrefix(matches)
+	if len(common) > len(word) {
+		completed := base + common
+		return completed + line[pos:], len(completed), true
+	}
+
+	sort.Strings(matches)
+	fmt.Fprintln(r.term, strings.Join(matches, " "))
+	return line, pos, true
+}
+
+func commonPrefix(words []string) string {
+	prefix := words[0]
+	for _, word := range words[1:] {
+		for !strings.HasPrefix(word, prefix) {
+			prefix = prefix[:len(prefix)-1]
+		}
+	}
+	return prefix
+}
+
+// History of the lines entered in the interactive chat, persisted to a file so that it
+// is available across sessions. Only the most recent entries are kept.
+type history struct {
+	path    string
+	entries []string
+}
+
+// Path to the default history file.
+func defaultHistoryPath() string {
+	dir, err := os.UserConfigDir()
+	if err != nil {
+		return ""
+	}
+	return filepath.Join(dir, "grpc-example", "history")
+}
+
+// Load the history from the file at path; the file is created when the first entry is
+// added. If path is empty the history is not persisted.
+func loadHistory(path string) (h *history, err error) {
+	h = &history{path: path}
+	if path == "" {
+		return h, nil
+	}
+
+	var f *os.File
+	if f, err = os.Open(path); err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return h, nil
+		}
+		return nil, err
+	}
+	defer f.Close()
+
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		if line := scanner.Text(); line != "" {
+			h.entries = append(h.entries, line)
+		}
+	}
+
+	if err = scanner.Err(); err != nil {
+		return nil, err
+	}
+
+	if len(h.entries) > maxHistory {
+		h.entries = h.entries[len(h.entries)-maxHistory:]
+	}
+	return h, nil
+}
+
+// Add an entry to the history and rewrite the file, skipping repeated entries.
+func (h *history) Add(entry string) {
+	entry = strings.TrimSpace(entry)
+	if entry == "" || (len(h.entries) > 0 && h.entries[len(h.entries)-1] == entry) {
+		return
+	}
+
+	h.entries = append(h.entries, entry)
+	if len(h.entries) > maxHistory {
+		h.entries = h.entries[len(h.entries)-maxHistory:]
+	}
+
+	// Failing to persist the history should not interrupt the chat
+	h.save()
+}
+
+func (h *history) save() error {
+	if h.path == "" {
+		return nil
+	}
+
+	if err := os.MkdirAll(filepath.Dir(h.path), 0755); err != nil {
+		return err
+	}
+	return os.WriteFile(h.path, []byte(strings.Join(h.entries, "\n")+"\n"), 0600)
+}
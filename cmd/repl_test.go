@@ -0,0 +1,186 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	hello "github.com/pdeziel/grpc-example"
+	"github.com/pdeziel/grpc-example/hellotest"
+	"github.com/stretchr/testify/require"
+	"golang.org/x/term"
+)
+
+// A terminal that reads from a pipe and writes to a buffer that is safe to read while
+// replies are being printed.
+type testTerminal struct {
+	*io.PipeReader
+	in  *io.PipeWriter
+	mu  sync.Mutex
+	out bytes.Buffer
+}
+
+func newTestTerminal() *testTerminal {
+	r, w := io.Pipe()
+	return &testTerminal{PipeReader: r, in: w}
+}
+
+func (t *testTerminal) Write(p []byte) (int, error) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	return t.out.Write(p)
+}
+
+func (t *testTerminal) Output() string {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	return t.out.String()
+}
+
+// Type a line and wait for the output to contain expected.
+func (t *testTerminal) Type(tb testing.TB, line, expected string) {
+	tb.Helper()
+	_, err := t.in.Write([]byte(line + "\r"))
+	require.NoError(tb, err)
+	require.Eventually(tb, func() bool {
+		return strings.Contains(t.Output(), expected)
+	}, 5*time.Second, 10*time.Millisecond, "expected output to contain %q", expected)
+}
+
+func newTestREPL(t *testing.T, hist *history) (*repl, *testTerminal) {
+	client := hellotest.NewClient(t)
+
+	session, err := client.Bidirectional(context.Background(), hello.PartialSuccess())
+	require.NoError(t, err, "could not open a session")
+	t.Cleanup(func() { session.Close() })
+
+	tt := newTestTerminal()
+	return newREPL(tt, client, session, hist), tt
+}
+
+func TestREPL(t *testing.T) {
+	hist, err := loadHistory(filepath.Join(t.TempDir(), "history"))
+	require.NoError(t, err)
+
+	repl, tt := newTestREPL(t, hist)
+
+	errc := make(chan error, 1)
+	go func() {
+		errc <- repl.Run(context.Background())
+	}()
+
+	// Replies are printed with their metadata
+	tt.Type(t, "en", "Hello  [en #0 ")
+	tt.Type(t, "xx", `xx: language "xx" not found [NotFound]`)
+	tt.Type(t, "/langs", "de en es fr it ja")
+	tt.Type(t, "/format json", "hello> ")
+	tt.Type(t, "fr", `"greeting":"Bonjour"`)
+	tt.Type(t, "/format toml", `unknown format "toml"`)
+	tt.Type(t, "/nope", "unknown command /nope")
+
+	_, err = tt.in.Write([]byte("/quit\r"))
+	require.NoError(t, err)
+
+	select {
+	case err = <-errc:
+		require.NoError(t, err, "expected the session to end normally")
+	case <-time.After(5 * time.Second):
+		t.Fatal("the chat did not quit")
+	}
+
+	// The history is persisted across sessions
+	data, err := os.ReadFile(hist.path)
+	require.NoError(t, err)
+	require.Equal(t, "en\nxx\n/langs\n/format json\nfr\n/format toml\n/nope\n/quit\n", string(data))
+}
+
+func TestREPLHistory(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "history")
+	require.NoError(t, os.WriteFile(path, []byte("en\n/nope\n/quit\n"), 0600))
+
+	hist, err := loadHistory(path)
+	require.NoError(t, err)
+
+	repl, tt := newTestREPL(t, hist)
+	go repl.Run(context.Background())
+
+	// The persisted entries are recalled with the up arrow without being echoed
+	tt.Type(t, "\x1b[A\x1b[A", "unknown command /nope")
+	require.NotContains(t, tt.Output(), "hello> en")
+	tt.Type(t, "\x1b[A\x1b[A\x1b[A\x1b[A", "Hello  [en #0 ")
+}
+
+func TestComplete(t *testing.T) {
+	repl, _ := newTestREPL(t, nil)
+	require.NoError(t, repl.refreshLanguages(context.Background()))
+
+	testCases := []struct {
+		line     string
+		pos      int
+		expected string
+	}{
+		{"j", 1, "ja"},
+		{"/l", 2, "/langs"},
+		{"/q", 2, "/quit"},
+		{"/format y", 9, "/format yaml"},
+		{"e", 1, "e"},
+	}
+
+	for _, tc := range testCases {
+		line, pos, ok := repl.complete(tc.line, tc.pos, '\t')
+		require.True(t, ok, "expected %q to be completed", tc.line)
+		require.Equal(t, tc.expected, line)
+		require.Equal(t, len(tc.expected), pos)
+	}
+
+	// Other keys and unknown prefixes are not completed
+	_, _, ok := repl.complete("e", 1, 'n')
+	require.False(t, ok)
+	_, _, ok = repl.complete("x", 1, '\t')
+	require.False(t, ok)
+}
+
+func TestHistory(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "grpc-example", "history")
+	hist, err := loadHistory(path)
+	require.NoError(t, err)
+	require.Empty(t, hist.entries)
+
+	// Blank and repeated entries are skipped
+	for _, entry := range []string{"en", "fr", "fr", " ", "es"} {
+		hist.Add(entry)
+	}
+
+	hist, err = loadHistory(path)
+	require.NoError(t, err)
+	require.Equal(t, []string{"en", "fr", "es"}, hist.entries)
+
+	// The persisted entries are replayed into the terminal without being echoed so
+	// that they can be recalled with the up arrow
+	tt := newTestTerminal()
+	replay := &replayer{ReadWriter: tt}
+	terminal := term.NewTerminal(replay, "> ")
+	replay.seed(terminal, hist.entries)
+	require.Empty(t, tt.Output())
+
+	go tt.in.Write([]byte("\x1b[A\x1b[A\r"))
+	line, err := terminal.ReadLine()
+	require.NoError(t, err)
+	require.Equal(t, "fr", line)
+
+	// Only the most recent entries are kept
+	for i := 0; i < maxHistory; i++ {
+		hist.Add(strings.Repeat("a", i%2+1))
+	}
+
+	hist, err = loadHistory(path)
+	require.NoError(t, err)
+	require.Len(t, hist.entries, maxHistory)
+	require.Equal(t, "aa", hist.entries[maxHistory-1])
+}
@@ -0,0 +1,206 @@
+package hello
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/pdeziel/grpc-example/pb"
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// Sentinel errors returned by the Client, these can be checked with errors.Is. The
+// status error returned by the server is wrapped in an *Error which carries the
+// additional details sent by the server. Deadline and cancellation errors also match
+// context.DeadlineExceeded and context.Canceled. ErrLanguageNotFound is only matched by
+// NotFound errors whose details identify the language, or by partial success replies.
+var (
+	ErrLanguageNotFound = errors.New("language not found")
+	ErrUnavailable      = errors.New("hello service is unavailable")
+	ErrUnauthenticated  = errors.New("request is not authenticated")
+	ErrInvalidRequest   = errors.New("invalid request")
+)
+
+// The resource type used in the ResourceInfo error details for language codes.
+const languageResource = "language"
+
+// Error is returned by the Client when the server responds with a gRPC status error.
+// It unwraps to one of the sentinel errors (if the status code maps to one) and can
+// be used with status.Code and status.FromError just like the original error.
+//
+//	var herr *hello.Error
+//	if errors.As(err, &herr) && errors.Is(err, hello.ErrLanguageNotFound) {
+//		fmt.Println("unsupported language", herr.Language)
+//	}
+type Error struct {
+	Code     codes.Code
+	Message  string
+	Language string
+	err      error
+	status   *status.Status
+}
+
+// Error implements the error interface.
+func (e *Error) Error() string {
+	return fmt.Sprintf("rpc error: code = %s desc = %s", e.Code, e.Message)
+}
+
+// Unwrap returns the sentinel error associated with the status code, if any.
+func (e *Error) Unwrap() error {
+	return e.err
+}
+
+// GRPCStatus returns the original status from the server so that the error is still
+// compatible with the grpc status package.
+func (e *Error) GRPCStatus() *status.Status {
+	return e.status
+}
+
+// Convert a gRPC status error into an *Error, reconstructing the language code from
+// the error details attached by the server. Errors that are not status errors, such
+// as context errors, are returned unchanged.
+func wrapError(err error) error {
+	if err == nil {
+		return nil
+	}
+
+	// Do not wrap errors that have already been converted
+	var herr *Error
+	if errors.As(err, &herr) {
+		return err
+	}
+
+	st, ok := status.FromError(err)
+	if !ok {
+		return err
+	}
+
+	e := &Error{
+		Code:    st.Code(),
+		Message: st.Message(),
+		err:     sentinel(st.Code()),
+		status:  st,
+	}
+
+	// Only the details attached by the Hello server identify a missing language, other
+	// NotFound errors (e.g. from a proxy or an unknown service) are not wrapped
+	for _, detail := range st.Details() {
+		switch info := detail.(type) {
+		case *errdetails.ResourceInfo:
+			if info.ResourceType == languageResource {
+				e.Language = info.ResourceName
+				if e.Code == codes.NotFound {
+					e.err = ErrLanguageNotFound
+				}
+			}
+		}
+	}
+
+	return e
+}
+
+// ReplyError returns the error for a reply that failed in partial success mode as an
+// *Error carrying the language code of the reply. It returns nil for successful replies.
+func ReplyError(rep *pb.HelloReply) error {
+	if rep == nil || rep.Error == nil {
+		return nil
+	}
+
+	code := codes.Code(rep.Error.Code)
+	e := &Error{
+		Code:     code,
+		Message:  rep.Error.Message,
+		Language: rep.IsoLanguageCode,
+		err:      sentinel(code),
+		status:   status.New(code, rep.Error.Message),
+	}
+
+	// Replies are only NotFound in partial success mode if their language is not found
+	if code == codes.NotFound {
+		e.err = ErrLanguageNotFound
+	}
+	return e
+}
+
+// Map a status code to the sentinel error that it represents, if any. NotFound is not
+// mapped since only the error details show whether it is a language that is not found.
+func sentinel(code codes.Code) error {
+	switch code {
+	case codes.Unavailable:
+		return ErrUnavailable
+	case codes.Unauthenticated:
+		return ErrUnauthenticated
+	case codes.InvalidArgument:
+		return ErrInvalidRequest
+	case codes.DeadlineExceeded:
+		return context.DeadlineExceeded
+	case codes.Canceled:
+		return context.Canceled
+	default:
+		return nil
+	}
+}
+
+// Create the status error returned by the server when a language is not in the
+// catalog, the details allow clients to reconstruct the offending language code.
+func languageNotFound(code string) error {
+	st := status.New(codes.NotFound, fmt.Sprintf("language %q not found", code))
+
+	var err error
+	if st, err = st.WithDetails(
+		&errdetails.ResourceInfo{
+			ResourceType: languageResource,
+			ResourceName: code,
+			Description:  "the language is not available in the greetings catalog",
+		},
+		&errdetails.BadRequest{
+			FieldViolations: []*errdetails.BadRequest_FieldViolation{
+				{
+					Field:       "iso_language_code",
+					Description: "unsupported language code",
+				},
+			},
+		},
+	); err != nil {
+		return status.Error(codes.NotFound, fmt.Sprintf("language %q not found", code))
+	}
+
+	return st.Err()
+}
+
+// Create the status error returned by the server when the greeting template for a
+// language requires parameters that were not specified in the request.
+func missingParams(code string, params []string) error {
+	msg := fmt.Sprintf("the greeting for %q requires the parameters: %s", code, strings.Join(params, ", "))
+	st := status.New(codes.InvalidArgument, msg)
+
+	violations := make([]*errdetails.BadRequest_FieldViolation, 0, len(params))
+	for _, param := range params {
+		field := fmt.Sprintf("params[%s]", param)
+		if param == "name" {
+			field = "name"
+		}
+
+		violations = append(violations, &errdetails.BadRequest_FieldViolation{
+			Field:       field,
+			Description: "parameter is required by the greeting template",
+		})
+	}
+
+	var err error
+	if st, err = st.WithDetails(
+		&errdetails.ResourceInfo{
+			ResourceType: languageResource,
+			ResourceName: code,
+			Description:  "the greeting for the language requires template parameters",
+		},
+		&errdetails.BadRequest{FieldViolations: violations},
+	); err != nil {
+		return status.Error(codes.InvalidArgument, msg)
+	}
+
+	return st.Err()
+}
@@ -0,0 +1,307 @@
+/*
+Package faults injects latency, errors, dropped stream messages, and disconnects into
+a gRPC server to test how callers behave against slow and flaky greeting backends. The
+Injector provides server interceptors that can be installed on both the real
+hello.Server and the mock.HelloService:
+
+	inj := faults.New(42,
+		faults.Rule{Method: mock.SayHelloRPC, Latency: 100 * time.Millisecond},
+		faults.Rule{Language: "fr", Code: codes.Unavailable, Probability: 0.5},
+	)
+	srv := mock.New(mock.NewBufConn(mock.WithListenerWrapper(inj.Listener)), inj.ServerOptions()...)
+
+The real server is configured the same way, wrapping its socket so that connections
+can be cut by rules with Disconnect set:
+
+	server, err := hello.NewServer(inj.ServerOptions()...)
+	go server.Run(inj.Listener(sock))
+
+Rules are evaluated in order and every matching rule is applied. Random decisions are
+made from a source seeded by the caller so that tests are reproducible.
+*/
+package faults
+
+import (
+	"context"
+	"math/rand"
+	"net"
+	"sync"
+	"time"
+
+	"github.com/pdeziel/grpc-example/pb"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// Rule describes a fault to inject into the RPCs that it matches.
+type Rule struct {
+	// Method is the full method name of the RPC (e.g. /hello.Hello/SayHello) that the
+	// rule applies to; if empty the rule applies to all methods.
+	Method string
+
+	// Language restricts the rule to requests for the language code; if empty the rule
+	// applies to every request. On streams language rules are evaluated for each
+	// message received rather than once when the stream is opened.
+	Language string
+
+	// Probability that the rule is applied when it matches, a zero value means that
+	// the rule is always applied.
+	Probability float64
+
+	// Latency is added before the request is handled, with a random amount of
+	// additional jitter up to Jitter.
+	Latency time.Duration
+	Jitter  time.Duration
+
+	// Code is the status code returned instead of handling the request, if OK (the
+	// default) no error is returned.
+	Code    codes.Code
+	Message string
+
+	// DropAfter silently drops all messages sent by the server on a stream after the
+	// first DropAfter messages; zero means messages are not dropped.
+	DropAfter int
+
+	// Disconnect closes all connections accepted by the Injector's listener, cutting
+	// the connection to the client mid-request.
+	Disconnect bool
+}
+
+// Injector applies fault rules to a gRPC server using interceptors.
+type Injector struct {
+	sync.Mutex
+	rng   *rand.Rand
+	rules []Rule
+	conns map[*conn]struct{}
+}
+
+// New creates an Injector with the rules and a random source seeded with seed.
+func New(seed int64, rules ...Rule) *Injector {
+	return &Injector{
+		rng:   rand.New(rand.NewSource(seed)),
+		rules: rules,
+		conns: make(map[*conn]struct{}),
+	}
+}
+
+// ServerOptions returns the options that install the fault interceptors on a server.
+func (i *Injector) ServerOptions() []grpc.ServerOption {
+	return []grpc.ServerOption{
+		grpc.ChainUnaryInterceptor(i.UnaryInterceptor()),
+		grpc.ChainStreamInterceptor(i.StreamInterceptor()),
+	}
+}
+
+// UnaryInterceptor applies matching rules before the unary handler is called.
+func (i *Injector) UnaryInterceptor() grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+		for _, rule := range i.match(info.FullMethod, languages(req), false) {
+			if err := i.apply(ctx, rule); err != nil {
+				return nil, err
+			}
+		}
+		return handler(ctx, req)
+	}
+}
+
+// StreamInterceptor applies method rules when the stream is opened and language rules
+// to each message that is received.
+func (i *Injector) StreamInterceptor() grpc.StreamServerInterceptor {
+	return func(srv interface{}, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+		stream := &stream{ServerStream: ss, inj: i, method: info.FullMethod, drop: -1}
+		for _, rule := range i.match(info.FullMethod, nil, false) {
+			stream.dropAfter(rule.DropAfter)
+			if err := i.apply(ss.Context(), rule); err != nil {
+				return err
+			}
+		}
+		return handler(srv, stream)
+	}
+}
+
+// Listener wraps a listener so that connections it accepts can be cut by rules with
+// Disconnect set.
+func (i *Injector) Listener(lis net.Listener) net.Listener {
+	return &listener{Listener: lis, inj: i}
+}
+
+// Disconnect closes every connection accepted by the Injector's listeners.
+func (i *Injector) Disconnect() {
+	i.Lock()
+	defer i.Unlock()
+	for c := range i.conns {
+		c.Conn.Close()
+		delete(i.conns, c)
+	}
+}
+
+// Return the rules that match the method and languages and that should be applied
+// based on their probability. If languages is nil then only rules without a language
+// are matched; if perMessage is true only rules with a language are matched.
+func (i *Injector) match(method string, langs []string, perMessage bool) []Rule {
+	i.Lock()
+	defer i.Unlock()
+
+	rules := make([]Rule, 0)
+	for _, rule := range i.rules {
+		if rule.Method != "" && rule.Method != method {
+			continue
+		}
+
+		if rule.Language != "" {
+			if !contains(langs, rule.Language) {
+				continue
+			}
+		} else if perMessage {
+			continue
+		}
+
+		if rule.Probability > 0 && i.rng.Float64() >= rule.Probability {
+			continue
+		}
+		rules = append(rules, rule)
+	}
+	return rules
+}
+
+// Apply the latency, disconnect and error of a rule.
+func (i *Injector) apply(ctx context.Context, rule Rule) error {
+	if delay := i.delay(rule); delay > 0 {
+		timer := time.NewTimer(delay)
+		select {
+		case <-timer.C:
+		case <-ctx.Done():
+			timer.Stop()
+			return status.FromContextError(ctx.Err()).Err()
+		}
+	}
+
+	if rule.Disconnect {
+		i.Disconnect()
+		return status.Error(codes.Unavailable, "faults: connection cut")
+	}
+
+	if rule.Code != codes.OK {
+		msg := rule.Message
+		if msg == "" {
+			msg = "faults: injected error"
+		}
+		return status.Error(rule.Code, msg)
+	}
+	return nil
+}
+
+func (i *Injector) delay(rule Rule) time.Duration {
+	delay := rule.Latency
+	if rule.Jitter > 0 {
+		i.Lock()
+		delay += time.Duration(i.rng.Int63n(int64(rule.Jitter)))
+		i.Unlock()
+	}
+	return delay
+}
+
+// Wraps a server stream to apply language rules to received messages and to drop sent
+// messages.
+type stream struct {
+	grpc.ServerStream
+	inj    *Injector
+	method string
+	mu     sync.Mutex
+	drop   int
+	sent   int
+}
+
+func (s *stream) RecvMsg(m interface{}) (err error) {
+	if err = s.ServerStream.RecvMsg(m); err != nil {
+		return err
+	}
+
+	for _, rule := range s.inj.match(s.method, languages(m), true) {
+		s.dropAfter(rule.DropAfter)
+		if err = s.inj.apply(s.Context(), rule); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (s *stream) SendMsg(m interface{}) error {
+	s.mu.Lock()
+	if s.drop >= 0 && s.sent >= s.drop {
+		s.mu.Unlock()
+		return nil
+	}
+	s.sent++
+	s.mu.Unlock()
+
+	return s.ServerStream.SendMsg(m)
+}
+
+// Drop messages after n messages have been sent, keeping the smallest limit.
+func (s *stream) dropAfter(n int) {
+	if n <= 0 {
+		return
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.drop < 0 || n < s.drop {
+		s.drop = n
+	}
+}
+
+// Tracks accepted connections so that they can be closed by the Injector.
+type listener struct {
+	net.Listener
+	inj *Injector
+}
+
+func (l *listener) Accept() (net.Conn, error) {
+	nc, err := l.Listener.Accept()
+	if err != nil {
+		return nil, err
+	}
+
+	c := &conn{Conn: nc, inj: l.inj}
+	l.inj.Lock()
+	l.inj.conns[c] = struct{}{}
+	l.inj.Unlock()
+	return c, nil
+}
+
+// Stops tracking the connection when it is closed so that the Injector does not hold on
+// to connections that the server or client has already closed.
+type conn struct {
+	net.Conn
+	inj *Injector
+}
+
+func (c *conn) Close() error {
+	c.inj.Lock()
+	delete(c.inj.conns, c)
+	c.inj.Unlock()
+	return c.Conn.Close()
+}
+
+// Extract the language codes from a Hello request message.
+func languages(req interface{}) []string {
+	switch msg := req.(type) {
+	case *pb.HelloRequest:
+		return []string{msg.IsoLanguageCode}
+	case *pb.HelloManyRequest:
+		return msg.IsoLanguageCodes
+	default:
+		return nil
+	}
+}
+
+func contains(langs []string, lang string) bool {
+	for _, l := range langs {
+		if l == lang {
+			return true
+		}
+	}
+	return false
+}
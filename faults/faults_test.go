@@ -0,0 +1,127 @@
+package faults_test
+
+import (
+	"context"
+	"io"
+	"testing"
+	"time"
+
+	"github.com/pdeziel/grpc-example/faults"
+	"github.com/pdeziel/grpc-example/mock"
+	"github.com/pdeziel/grpc-example/pb"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+func TestLatency(t *testing.T) {
+	inj := faults.New(42, faults.Rule{Method: mock.SayHelloRPC, Latency: 50 * time.Millisecond, Jitter: 10 * time.Millisecond})
+	srv, client := setup(t, inj)
+	srv.ExpectSayHello().Return(&pb.HelloReply{Greeting: "Hello"}).AnyTimes()
+
+	start := time.Now()
+	_, err := client.SayHello(context.Background(), &pb.HelloRequest{IsoLanguageCode: "en"})
+	require.NoError(t, err, "could not call the mock")
+	require.GreaterOrEqual(t, time.Since(start), 50*time.Millisecond)
+
+	// Latency respects the deadline of the caller
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	_, err = client.SayHello(ctx, &pb.HelloRequest{IsoLanguageCode: "en"})
+	require.Equal(t, codes.DeadlineExceeded, status.Code(err))
+}
+
+func TestProbability(t *testing.T) {
+	// The same seed must produce the same sequence of failures
+	run := func() []codes.Code {
+		inj := faults.New(7, faults.Rule{Code: codes.Unavailable, Probability: 0.5})
+		srv, client := setup(t, inj)
+		srv.ExpectSayHello().Return(&pb.HelloReply{Greeting: "Hello"}).AnyTimes()
+
+		results := make([]codes.Code, 0, 20)
+		for i := 0; i < 20; i++ {
+			_, err := client.SayHello(context.Background(), &pb.HelloRequest{IsoLanguageCode: "en"})
+			results = append(results, status.Code(err))
+		}
+		return results
+	}
+
+	first := run()
+	require.Equal(t, first, run(), "expected the seed to make failures reproducible")
+	require.Contains(t, first, codes.OK)
+	require.Contains(t, first, codes.Unavailable)
+}
+
+func TestLanguageRule(t *testing.T) {
+	inj := faults.New(42, faults.Rule{Language: "fr", Code: codes.ResourceExhausted, Message: "too many bonjours"})
+	srv, client := setup(t, inj)
+	srv.ExpectSayHello().Return(&pb.HelloReply{Greeting: "Hello"}).AnyTimes()
+	srv.ExpectSayBidirectional().Return(&pb.HelloReply{Greeting: "Hello"}, &pb.HelloReply{Greeting: "Hello"})
+
+	_, err := client.SayHello(context.Background(), &pb.HelloRequest{IsoLanguageCode: "en"})
+	require.NoError(t, err)
+
+	_, err = client.SayHello(context.Background(), &pb.HelloRequest{IsoLanguageCode: "fr"})
+	require.Equal(t, codes.ResourceExhausted, status.Code(err))
+
+	// Language rules are evaluated per message on streams
+	stream, err := client.SayBidirectional(context.Background())
+	require.NoError(t, err, "could not call the mock")
+	require.NoError(t, stream.Send(&pb.HelloRequest{IsoLanguageCode: "en"}))
+	_, err = stream.Recv()
+	require.NoError(t, err, "expected the first message to succeed")
+
+	require.NoError(t, stream.Send(&pb.HelloRequest{IsoLanguageCode: "fr"}))
+	_, err = stream.Recv()
+	require.Equal(t, codes.ResourceExhausted, status.Code(err))
+}
+
+func TestDropAfter(t *testing.T) {
+	inj := faults.New(42, faults.Rule{Method: mock.SayServerStreamRPC, DropAfter: 1})
+	srv, client := setup(t, inj)
+	srv.ExpectSayServerStream().Return(
+		&pb.HelloReply{Greeting: "Hello"},
+		&pb.HelloReply{Greeting: "Bonjour"},
+		&pb.HelloReply{Greeting: "Hola"},
+	)
+
+	stream, err := client.SayServerStream(context.Background(), &pb.HelloManyRequest{IsoLanguageCodes: []string{"en", "fr", "es"}})
+	require.NoError(t, err, "could not call the mock")
+
+	var greetings []string
+	for {
+		var rep *pb.HelloReply
+		if rep, err = stream.Recv(); err != nil {
+			break
+		}
+		greetings = append(greetings, rep.Greeting)
+	}
+
+	require.ErrorIs(t, err, io.EOF, "expected the stream to end normally")
+	require.Equal(t, []string{"Hello"}, greetings)
+}
+
+func TestDisconnect(t *testing.T) {
+	inj := faults.New(42, faults.Rule{Language: "xx", Disconnect: true})
+	srv, client := setup(t, inj)
+	srv.ExpectSayHello().Return(&pb.HelloReply{Greeting: "Hello"}).AnyTimes()
+
+	_, err := client.SayHello(context.Background(), &pb.HelloRequest{IsoLanguageCode: "xx"})
+	require.Equal(t, codes.Unavailable, status.Code(err))
+
+	// The client reconnects after the connection has been cut
+	require.Eventually(t, func() bool {
+		_, err := client.SayHello(context.Background(), &pb.HelloRequest{IsoLanguageCode: "en"})
+		return err == nil
+	}, time.Second, 10*time.Millisecond)
+}
+
+func setup(t *testing.T, inj *faults.Injector) (*mock.HelloService, pb.HelloClient) {
+	bufnet := mock.NewBufConn(mock.WithListenerWrapper(inj.Listener))
+	srv := mock.New(bufnet, inj.ServerOptions()...)
+	t.Cleanup(srv.Shutdown)
+
+	client, err := srv.Client(context.Background())
+	require.NoError(t, err, "could not connect to the mock")
+	return srv, client
+}
@@ -0,0 +1,38 @@
+package faults
+
+import (
+	"net"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestListenerConns(t *testing.T) {
+	lis, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+
+	inj := New(42)
+	wrapped := inj.Listener(lis)
+	defer wrapped.Close()
+
+	accept := func() net.Conn {
+		client, err := net.Dial("tcp", lis.Addr().String())
+		require.NoError(t, err)
+		t.Cleanup(func() { client.Close() })
+
+		conn, err := wrapped.Accept()
+		require.NoError(t, err)
+		return conn
+	}
+
+	// Connections are no longer tracked once they are closed
+	first, second := accept(), accept()
+	require.Len(t, inj.conns, 2)
+	require.NoError(t, first.Close())
+	require.Len(t, inj.conns, 1)
+
+	inj.Disconnect()
+	require.Empty(t, inj.conns)
+	second.Close()
+	require.Empty(t, inj.conns)
+}
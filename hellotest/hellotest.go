@@ -0,0 +1,181 @@
+// Package hellotest provides an in-process harness for integration testing against the
+// real hello.Server. The server runs on a bufconn so that no network ports are opened,
+// greets from an in-memory catalog rather than messages.json, and is shut down
+// automatically when the test completes:
+//
+//	func TestGreeting(t *testing.T) {
+//		client := hellotest.NewClient(t, hellotest.WithMessages(map[string]string{"en": "Hello"}))
+//		greeting, err := client.SayHello(context.Background(), "en")
+//		...
+//	}
+package hellotest
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	hello "github.com/pdeziel/grpc-example"
+	"github.com/pdeziel/grpc-example/catalog"
+	"github.com/pdeziel/grpc-example/mock"
+	"github.com/pdeziel/grpc-example/pb"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials/insecure"
+)
+
+// Server is a real hello.Server running on a bufconn for the duration of a test along
+// with a client that is connected to it.
+type Server struct {
+	*hello.Server
+	Listener *mock.Listener
+	Client   *hello.Client
+	dial     []grpc.DialOption
+}
+
+// Option configures the test server.
+type Option func(*config)
+
+type config struct {
+	messages map[string]string
+	catalog  catalog.Catalog
+	seed     *int64
+	now      func() time.Time
+	server   []grpc.ServerOption
+	dial     []grpc.DialOption
+}
+
+// WithMessages replaces the default catalog with the greetings keyed by language code.
+func WithMessages(messages map[string]string) Option {
+	return func(c *config) {
+		c.messages = messages
+	}
+}
+
+// WithCatalog replaces the default catalog with one that has variants of the greetings,
+// e.g. formal and informal greetings. It takes precedence over WithMessages.
+func WithCatalog(messages catalog.Catalog) Option {
+	return func(c *config) {
+		c.catalog = messages
+	}
+}
+
+// WithSeed seeds the random selection of greeting variants so that tests of
+// hello.SelectRandom are reproducible.
+func WithSeed(seed int64) Option {
+	return func(c *config) {
+		c.seed = &seed
+	}
+}
+
+// WithClock sets the clock of the server, e.g. to select the greeting variants for a
+// fixed time of day.
+func WithClock(now func() time.Time) Option {
+	return func(c *config) {
+		c.now = now
+	}
+}
+
+// WithServerOptions passes additional options to hello.NewServerWithMessages, e.g. the
+// options from mock.Certificates to serve with mTLS.
+func WithServerOptions(opts ...grpc.ServerOption) Option {
+	return func(c *config) {
+		c.server = append(c.server, opts...)
+	}
+}
+
+// WithDialOptions replaces the insecure transport credentials used to connect clients.
+func WithDialOptions(opts ...grpc.DialOption) Option {
+	return func(c *config) {
+		c.dial = append(c.dial, opts...)
+	}
+}
+
+// Messages returns a copy of the default catalog used by the test server.
+func Messages() map[string]string {
+	return map[string]string{
+		"en": "Hello",
+		"fr": "Bonjour",
+		"es": "Hola",
+		"de": "Hallo",
+		"it": "Ciao",
+		"ja": "こんにちは",
+	}
+}
+
+// NewServer starts a hello.Server on a bufconn and connects a client to it. The client
+// and server are closed by t.Cleanup when the test and its subtests complete.
+func NewServer(t testing.TB, opts ...Option) *Server {
+	t.Helper()
+
+	conf := &config{}
+	for _, opt := range opts {
+		opt(conf)
+	}
+
+	var messages *hello.Messages
+	switch {
+	case conf.catalog != nil:
+		messages = hello.NewCatalogMessages(conf.catalog)
+	case conf.messages != nil:
+		messages = hello.NewMessages(conf.messages)
+	default:
+		messages = hello.NewMessages(Messages())
+	}
+
+	if conf.seed != nil {
+		messages.Seed(*conf.seed)
+	}
+
+	if len(conf.dial) == 0 {
+		conf.dial = []grpc.DialOption{grpc.WithTransportCredentials(insecure.NewCredentials())}
+	}
+
+	s := &Server{
+		Server:   hello.NewServerWithMessages(messages, conf.server...),
+		Listener: mock.NewBufConn(),
+		dial:     conf.dial,
+	}
+
+	if conf.now != nil {
+		s.SetClock(conf.now)
+	}
+
+	// Run blocks until the server is shut down
+	go s.Run(s.Listener.Sock())
+	t.Cleanup(func() {
+		s.Shutdown()
+	})
+
+	var err error
+	dial := append([]grpc.DialOption{grpc.WithContextDialer(s.Listener.Dialer)}, s.dial...)
+	if s.Client, err = hello.NewClient(mock.DefaultTarget, dial...); err != nil {
+		t.Fatalf("could not connect to the test server: %s", err)
+	}
+
+	t.Cleanup(func() {
+		s.Client.Close()
+	})
+	return s
+}
+
+// NewClient starts a hello.Server on a bufconn and returns a client connected to it.
+func NewClient(t testing.TB, opts ...Option) *hello.Client {
+	t.Helper()
+	return NewServer(t, opts...).Client
+}
+
+// Conn returns a generated gRPC client connected to the server for tests that need to
+// inspect the protocol buffer replies directly. The connection is closed by t.Cleanup.
+func (s *Server) Conn(t testing.TB) pb.HelloClient {
+	t.Helper()
+
+	cc, err := s.Listener.Connect(context.Background(), s.dial...)
+	if err != nil {
+		t.Fatalf("could not connect to the test server: %s", err)
+	}
+
+	t.Cleanup(func() {
+		cc.Close()
+	})
+	return pb.NewHelloClient(cc)
+}
@@ -0,0 +1,54 @@
+package hellotest_test
+
+import (
+	"context"
+	"testing"
+
+	hello "github.com/pdeziel/grpc-example"
+	"github.com/pdeziel/grpc-example/hellotest"
+	"github.com/pdeziel/grpc-example/mock"
+	"github.com/pdeziel/grpc-example/pb"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNewClient(t *testing.T) {
+	client := hellotest.NewClient(t)
+
+	greeting, err := client.SayHello(context.Background(), "fr")
+	require.NoError(t, err, "could not call the test server")
+	require.Equal(t, "Bonjour", greeting)
+
+	// The real server returns detailed errors for unknown languages
+	_, err = client.SayHello(context.Background(), "xx")
+	require.ErrorIs(t, err, hello.ErrLanguageNotFound)
+
+	var herr *hello.Error
+	require.ErrorAs(t, err, &herr)
+	require.Equal(t, "xx", herr.Language)
+}
+
+func TestWithMessages(t *testing.T) {
+	srv := hellotest.NewServer(t, hellotest.WithMessages(map[string]string{"tlh": "nuqneH"}))
+
+	greetings, err := srv.Client.SayServerStream(context.Background(), []string{"tlh"})
+	require.NoError(t, err, "could not call the test server")
+	require.Equal(t, []string{"nuqneH"}, greetings)
+
+	// The default catalog is replaced
+	_, err = srv.Conn(t).SayHello(context.Background(), &pb.HelloRequest{IsoLanguageCode: "en"})
+	require.Error(t, err, "expected the default catalog to be replaced")
+}
+
+func TestMutualTLS(t *testing.T) {
+	certs, err := mock.NewCertificates(mock.DefaultTarget)
+	require.NoError(t, err, "could not generate certificates")
+
+	client := hellotest.NewClient(t,
+		hellotest.WithServerOptions(certs.ServerOptions()...),
+		hellotest.WithDialOptions(certs.DialOptions()...),
+	)
+
+	greeting, err := client.SayHello(context.Background(), "es")
+	require.NoError(t, err, "could not call the test server over mTLS")
+	require.Equal(t, "Hola", greeting)
+}
@@ -0,0 +1,52 @@
+package hello
+
+import (
+	"time"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/keepalive"
+)
+
+// DefaultTimeout bounds unary RPCs and client and server streams made by a Client
+// unless it is changed with SetTimeout. Bidirectional sessions are long lived and are
+// not bounded by the timeout; they rely on keepalive pings to detect a dead server.
+const DefaultTimeout = 30 * time.Second
+
+// DefaultKeepalive returns the keepalive parameters used by NewClient. Pings are only
+// sent while there are active streams so that idle bidirectional sessions are not cut
+// off by proxies and load balancers between the client and server.
+func DefaultKeepalive() keepalive.ClientParameters {
+	return keepalive.ClientParameters{
+		Time:    time.Minute,
+		Timeout: 20 * time.Second,
+	}
+}
+
+// WithoutKeepalive is a dial option for NewClient that disables keepalive pings rather
+// than sending them with the DefaultKeepalive parameters.
+func WithoutKeepalive() grpc.DialOption {
+	return withoutKeepalive{}
+}
+
+type withoutKeepalive struct {
+	grpc.EmptyDialOption
+}
+
+// Returns true unless the dial options include WithoutKeepalive.
+func keepaliveEnabled(opts []grpc.DialOption) bool {
+	for _, opt := range opts {
+		if _, ok := opt.(withoutKeepalive); ok {
+			return false
+		}
+	}
+	return true
+}
+
+// DefaultEnforcementPolicy returns the keepalive enforcement policy used by NewServer.
+// The minimum time between pings must be less than the interval of the clients,
+// otherwise the server closes their connections for pinging too frequently.
+func DefaultEnforcementPolicy() keepalive.EnforcementPolicy {
+	return keepalive.EnforcementPolicy{
+		MinTime: 30 * time.Second,
+	}
+}
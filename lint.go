@@ -0,0 +1,218 @@
+package hello
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	"github.com/pdeziel/grpc-example/catalog"
+	"github.com/pdeziel/grpc-example/msgfmt"
+	"github.com/pdeziel/grpc-example/validate"
+	"golang.org/x/text/unicode/norm"
+)
+
+// ErrInvalidCatalog is returned by Load in strict mode if the catalog has errors.
+var ErrInvalidCatalog = errors.New("invalid catalog")
+
+// Severity of an issue found in a catalog. Errors are problems that prevent a greeting
+// from being served correctly and warnings are problems that should be fixed but do not
+// affect the server, e.g. duplicate entries for aliases.
+type Severity string
+
+const (
+	SeverityError   Severity = "error"
+	SeverityWarning Severity = "warning"
+)
+
+// Codes of the issues that are found in catalogs by Lint.
+const (
+	IssueInvalidCode      = "invalid-code"          // the language code is not a valid language code
+	IssueEmptyGreeting    = "empty-greeting"        // the greeting is empty or only whitespace
+	IssueInvalidUTF8      = "invalid-utf8"          // the greeting is not valid UTF-8 (files are rejected when decoded)
+	IssueInvalidTemplate  = "invalid-template"      // the greeting is not a valid template
+	IssueConflictingAlias = "conflicting-alias"     // an alias and its canonical code have different greetings
+	IssueReplacementChar  = "replacement-character" // the greeting contains U+FFFD, e.g. from a decoding error
+	IssueNotNormalized    = "not-normalized"        // the greeting is not in Unicode normalization form C
+	IssueControlChar      = "control-character"     // the greeting contains control characters
+	IssueWhitespace       = "whitespace"            // the greeting has leading or trailing whitespace
+	IssueDuplicateAlias   = "duplicate-alias"       // an alias duplicates the greetings of its canonical code
+	IssueAlias            = "alias"                 // the language code is an alias or deprecated code
+	IssueMissingSource    = "missing-source"        // the catalog has no greeting in the source language
+)
+
+// Issue is a problem found in a catalog.
+type Issue struct {
+	Code     string
+	Severity Severity
+	Language string
+	Variant  int // index of the variant of the greeting, or -1 if the issue is with the language
+	Message  string
+}
+
+// String formats the issue as severity[code] language: message, with the index of the
+// variant after the language code if the language has several variants.
+func (i Issue) String() string {
+	lang := i.Language
+	if i.Variant >= 0 {
+		lang = fmt.Sprintf("%s[%d]", lang, i.Variant)
+	}
+
+	if lang == "" {
+		return fmt.Sprintf("%s[%s] %s", i.Severity, i.Code, i.Message)
+	}
+	return fmt.Sprintf("%s[%s] %s: %s", i.Severity, i.Code, lang, i.Message)
+}
+
+// Issues are the problems found in a catalog sorted by language code.
+type Issues []Issue
+
+// Errors returns the issues with error severity.
+func (issues Issues) Errors() Issues {
+	return issues.filter(SeverityError)
+}
+
+// Warnings returns the issues with warning severity.
+func (issues Issues) Warnings() Issues {
+	return issues.filter(SeverityWarning)
+}
+
+// Err returns an error that wraps ErrInvalidCatalog and describes the first error if
+// there are any errors, otherwise nil.
+func (issues Issues) Err() error {
+	errs := issues.Errors()
+	switch len(errs) {
+	case 0:
+		return nil
+	case 1:
+		return fmt.Errorf("%w: %s", ErrInvalidCatalog, errs[0])
+	default:
+		return fmt.Errorf("%w: %s (and %d more errors)", ErrInvalidCatalog, errs[0], len(errs)-1)
+	}
+}
+
+func (issues Issues) filter(severity Severity) (filtered Issues) {
+	for _, issue := range issues {
+		if issue.Severity == severity {
+			filtered = append(filtered, issue)
+		}
+	}
+	return filtered
+}
+
+// Validate returns the issues in the catalog that the messages were loaded from, which
+// are found by Lint when the catalog is loaded, before aliases are canonicalized.
+func (m *Messages) Validate() Issues {
+	m.RLock()
+	defer m.RUnlock()
+	return append(Issues(nil), m.issues...)
+}
+
+// Lint checks the language codes and greetings of a catalog for errors and warnings.
+func Lint(messages catalog.Catalog) (issues Issues) {
+	add := func(code string, severity Severity, lang string, variant int, format string, args ...interface{}) {
+		issues = append(issues, Issue{
+			Code:     code,
+			Severity: severity,
+			Language: lang,
+			Variant:  variant,
+			Message:  fmt.Sprintf(format, args...),
+		})
+	}
+
+	if _, ok := messages[catalog.SourceLanguage]; !ok {
+		add(IssueMissingSource, SeverityWarning, "", -1, "the catalog has no greeting in the source language %q", catalog.SourceLanguage)
+	}
+
+	for _, lang := range messages.Languages() {
+		if !validate.IsLanguageCode(lang) {
+			add(IssueInvalidCode, SeverityError, lang, -1, "%q is not a valid language code", lang)
+		}
+
+		if canonical, deprecated := Canonical(lang); canonical != lang {
+			other, ok := messages[canonical]
+			switch {
+			case ok && !sameGreetings(other, messages[lang]):
+				add(IssueConflictingAlias, SeverityError, lang, -1, "the greetings differ from %q and will be ignored", canonical)
+			case ok:
+				add(IssueDuplicateAlias, SeverityWarning, lang, -1, "duplicates the greetings of %q and can be removed", canonical)
+			case deprecated:
+				add(IssueAlias, SeverityWarning, lang, -1, "deprecated language code, use %q", canonical)
+			default:
+				add(IssueAlias, SeverityWarning, lang, -1, "alias of %q, which should be used instead", canonical)
+			}
+		}
+
+		variants := messages[lang]
+		for i, v := range variants {
+			// Only identify the variant if the language has more than one
+			variant := i
+			if len(variants) == 1 {
+				variant = -1
+			}
+
+			for _, issue := range lintGreeting(v.Greeting) {
+				add(issue.Code, issue.Severity, lang, variant, "%s", issue.Message)
+			}
+		}
+	}
+
+	sort.SliceStable(issues, func(i, j int) bool {
+		return issues[i].Language < issues[j].Language
+	})
+	return issues
+}
+
+// Check a single greeting, returning the issues without the language or variant.
+func lintGreeting(greeting string) (issues Issues) {
+	add := func(code string, severity Severity, msg string) {
+		issues = append(issues, Issue{Code: code, Severity: severity, Message: msg})
+	}
+
+	if strings.TrimSpace(greeting) == "" {
+		add(IssueEmptyGreeting, SeverityError, "the greeting is empty")
+		return issues
+	}
+
+	if !utf8.ValidString(greeting) {
+		add(IssueInvalidUTF8, SeverityError, "the greeting is not valid UTF-8")
+		return issues
+	}
+
+	if _, err := msgfmt.Parse(greeting); err != nil {
+		add(IssueInvalidTemplate, SeverityError, fmt.Sprintf("invalid template: %s", err))
+	}
+
+	if strings.ContainsRune(greeting, utf8.RuneError) {
+		add(IssueReplacementChar, SeverityWarning, "the greeting contains the replacement character U+FFFD")
+	}
+
+	if !norm.NFC.IsNormalString(greeting) {
+		add(IssueNotNormalized, SeverityWarning, "the greeting is not in Unicode normalization form C (NFC)")
+	}
+
+	if strings.IndexFunc(greeting, unicode.IsControl) >= 0 {
+		add(IssueControlChar, SeverityWarning, "the greeting contains control characters")
+	}
+
+	if strings.TrimSpace(greeting) != greeting {
+		add(IssueWhitespace, SeverityWarning, "the greeting has leading or trailing whitespace")
+	}
+	return issues
+}
+
+// Returns true if the variants of two languages have the same greetings and tags.
+func sameGreetings(a, b []catalog.Variant) bool {
+	if len(a) != len(b) {
+		return false
+	}
+
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
@@ -1,50 +1,266 @@
 package hello
 
 import (
-	"encoding/json"
-	"errors"
-	"io/ioutil"
+	"context"
+	"fmt"
 	"os"
+	"path/filepath"
 	"sync"
+	"time"
+
+	"github.com/pdeziel/grpc-example/catalog"
+	"github.com/pdeziel/grpc-example/msgfmt"
+	"golang.org/x/text/unicode/norm"
 )
 
 type Messages struct {
 	sync.RWMutex
 
-	messages map[string]string
+	messages  catalog.Catalog
+	templates templates
+	issues    Issues
+	source    *source
+	selector  selector
 }
 
-func (m *Messages) Load(path string) (err error) {
-	m.Lock()
-	defer m.Unlock()
+// The file or directory the messages were loaded from, used to reload them. Reloads
+// are serialized by the mutex so that they do not block readers of the messages.
+type source struct {
+	sync.Mutex
+	path    string
+	format  catalog.Format
+	strict  bool
+	dir     *catalog.Dir
+	modTime time.Time
+	size    int64
+}
 
-	// Load the messages from the JSON file
-	m.messages = make(map[string]string)
+// NewMessages creates an in-memory catalog of greetings keyed by language code. The
+// map is copied so that later changes by the caller do not affect the catalog. As with
+// every catalog, the greetings are normalized to NFC and language code aliases are
+// replaced by their canonical codes.
+func NewMessages(messages map[string]string) *Messages {
+	return NewCatalogMessages(catalog.Flat(messages))
+}
 
-	var f *os.File
-	if f, err = os.Open(path); err != nil {
-		return err
+// NewCatalogMessages creates an in-memory catalog with the variants of the greeting for
+// each language. The catalog is copied so that later changes do not affect it.
+func NewCatalogMessages(messages catalog.Catalog) *Messages {
+	m := &Messages{issues: Lint(messages)}
+	m.messages, m.templates = prepare(messages)
+	return m
+}
+
+// LoadOption configures how a catalog file is loaded.
+type LoadOption func(*loadOptions)
+
+type loadOptions struct {
+	format catalog.Format
+	strict bool
+}
+
+// WithFormat loads the catalog in the specified format rather than determining the
+// format from the file extension.
+func WithFormat(format catalog.Format) LoadOption {
+	return func(o *loadOptions) {
+		o.format = format
 	}
-	defer f.Close()
+}
+
+// Strict refuses to load or reload a catalog that has errors (see Lint), returning an
+// error that wraps ErrInvalidCatalog. Without it the catalog is loaded regardless and
+// its issues are available from Validate.
+func Strict() LoadOption {
+	return func(o *loadOptions) {
+		o.strict = true
+	}
+}
 
-	var data []byte
-	if data, err = ioutil.ReadAll(f); err != nil {
+// Load replaces the messages with the catalog at path, which may be in any of the
+// formats supported by the catalog package. If path is a directory, all of the catalog
+// files in it are merged (see catalog.Dir). The greetings are normalized to Unicode
+// normalization form C and language code aliases in the catalog are replaced by their
+// canonical codes (see Canonical). If the catalog cannot be loaded the current messages
+// are left unchanged.
+func (m *Messages) Load(path string, opts ...LoadOption) (err error) {
+	conf := &loadOptions{}
+	for _, opt := range opts {
+		opt(conf)
+	}
+
+	var info os.FileInfo
+	if info, err = os.Stat(path); err != nil {
 		return err
 	}
 
-	if err = json.Unmarshal(data, &m.messages); err != nil {
+	src := &source{path: path, format: conf.format, strict: conf.strict}
+	var messages catalog.Catalog
+	if info.IsDir() {
+		if src.dir, err = catalog.OpenDir(path, conf.format); err != nil {
+			return err
+		}
+		messages = src.dir.Messages()
+	} else {
+		if messages, err = catalog.ReadFile(path, conf.format); err != nil {
+			return err
+		}
+		src.modTime, src.size = info.ModTime(), info.Size()
+	}
+
+	issues := Lint(messages)
+	if err = src.check(issues); err != nil {
 		return err
 	}
 
+	m.Lock()
+	m.messages, m.templates = prepare(messages)
+	m.issues, m.source = issues, src
+	m.Unlock()
 	return nil
 }
 
-func (m *Messages) Get(key string) (value string, err error) {
+// Reload the messages if the catalog they were loaded from has changed, returning the
+// names of the changed files. Only the changed files of a directory are read again. If
+// the catalog is invalid the current messages are left unchanged so that a hot reload
+// does not take down the server while a translator is editing a file.
+func (m *Messages) Reload() (changed []string, err error) {
 	m.RLock()
-	defer m.RUnlock()
+	src := m.source
+	m.RUnlock()
+
+	if src == nil {
+		return nil, nil
+	}
+
+	src.Lock()
+	defer src.Unlock()
+
+	var messages catalog.Catalog
+	if src.dir != nil {
+		if changed, err = src.dir.Reload(); err != nil || len(changed) == 0 {
+			return nil, err
+		}
+		messages = src.dir.Messages()
+	} else {
+		var info os.FileInfo
+		if info, err = os.Stat(src.path); err != nil {
+			return nil, err
+		}
+
+		if info.ModTime().Equal(src.modTime) && info.Size() == src.size {
+			return nil, nil
+		}
+
+		if messages, err = catalog.ReadFile(src.path, src.format); err != nil {
+			return nil, err
+		}
+		src.modTime, src.size = info.ModTime(), info.Size()
+		changed = []string{filepath.Base(src.path)}
+	}
 
-	if value, ok := m.messages[key]; ok {
-		return value, nil
+	issues := Lint(messages)
+	if err = src.check(issues); err != nil {
+		return nil, err
 	}
-	return "", errors.New("language not found")
+
+	m.Lock()
+	defer m.Unlock()
+	if m.source == src {
+		m.messages, m.templates = prepare(messages)
+		m.issues = issues
+	}
+	return changed, nil
+}
+
+// The parsed templates of the variants of the greeting for each language, in the same
+// order as the variants in the catalog.
+type templates map[string][]*msgfmt.Message
+
+// Copy a catalog to serve its greetings, normalizing them to NFC so that the same text
+// is always sent as the same characters, e.g. é rather than e and a combining accent,
+// and replacing the aliases of language codes with their canonical codes. The templates
+// of the greetings are parsed once here rather than on every request; variants with
+// invalid templates are not served (Lint reports them as errors), and a language whose
+// variants are all invalid is dropped from the catalog. The catalog is linted before
+// it is prepared, so the not-normalized warning still reports the greetings in the
+// catalog file that should be fixed.
+func prepare(messages catalog.Catalog) (_ catalog.Catalog, parsed templates) {
+	normalized := make(catalog.Catalog, len(messages))
+	for lang, variants := range messages {
+		normalized[lang] = make([]catalog.Variant, len(variants))
+		for i, v := range variants {
+			v.Greeting = norm.NFC.String(v.Greeting)
+			normalized[lang][i] = v
+		}
+	}
+
+	prepared := make(catalog.Catalog, len(normalized))
+	parsed = make(templates, len(normalized))
+	for lang, variants := range canonicalize(normalized) {
+		for _, v := range variants {
+			tmpl, err := msgfmt.Parse(v.Greeting)
+			if err != nil {
+				continue
+			}
+			prepared[lang] = append(prepared[lang], v)
+			parsed[lang] = append(parsed[lang], tmpl)
+		}
+	}
+	return prepared, parsed
+}
+
+// In strict mode, return an error if the issues of the catalog include errors.
+func (src *source) check(issues Issues) error {
+	if !src.strict {
+		return nil
+	}
+
+	if err := issues.Err(); err != nil {
+		return fmt.Errorf("%s: %w", src.path, err)
+	}
+	return nil
+}
+
+// Watch polls the catalog for changes at the interval and reloads it until the context
+// is canceled. The callback, if not nil, is called with the files that were reloaded or
+// the error if the catalog could not be reloaded; the same error is only reported once.
+func (m *Messages) Watch(ctx context.Context, interval time.Duration, callback func(changed []string, err error)) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	var last string
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+
+		changed, err := m.Reload()
+		if err != nil {
+			if err.Error() != last && callback != nil {
+				callback(nil, err)
+			}
+			last = err.Error()
+			continue
+		}
+
+		last = ""
+		if len(changed) > 0 && callback != nil {
+			callback(changed, nil)
+		}
+	}
+}
+
+// Get the default greeting for the language, i.e. the variant selected for a request
+// with no preferences (see Select).
+func (m *Messages) Get(key string) (value string, err error) {
+	return m.Select(key, Query{})
+}
+
+// Languages returns the sorted language codes in the catalog.
+func (m *Messages) Languages() []string {
+	m.RLock()
+	defer m.RUnlock()
+	return m.messages.Languages()
 }
@@ -0,0 +1,457 @@
+package hello_test
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	hello "github.com/pdeziel/grpc-example"
+	"github.com/pdeziel/grpc-example/catalog"
+	"github.com/stretchr/testify/require"
+)
+
+func TestLoadMessages(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "fr.po")
+	require.NoError(t, os.WriteFile(path, []byte("msgid \"\"\nmsgstr \"Language: fr\\n\"\n\nmsgid \"Hello\"\nmsgstr \"Bonjour\"\n"), 0644))
+
+	messages := &hello.Messages{}
+	require.NoError(t, messages.Load(path), "could not load the PO catalog")
+	require.Equal(t, []string{"fr"}, messages.Languages())
+
+	greeting, err := messages.Get("fr")
+	require.NoError(t, err)
+	require.Equal(t, "Bonjour", greeting)
+
+	// The format can be specified when the extension does not match
+	path = filepath.Join(dir, "messages.txt")
+	require.NoError(t, os.WriteFile(path, []byte("en: Hello\nde: Hallo\n"), 0644))
+	require.Error(t, messages.Load(path), "expected an unknown format error")
+	require.NoError(t, messages.Load(path, hello.WithFormat(catalog.YAML)))
+	require.Equal(t, []string{"de", "en"}, messages.Languages())
+
+	// A catalog with errors does not replace the loaded messages
+	require.NoError(t, os.WriteFile(path, []byte("en: Hello\nde: [[Hallo]]\n"), 0644))
+	err = messages.Load(path, hello.WithFormat(catalog.YAML))
+	require.EqualError(t, err, path+`:2: variant of "de" must be a string or a mapping`)
+	require.Equal(t, []string{"de", "en"}, messages.Languages())
+}
+
+func TestSelect(t *testing.T) {
+	messages := hello.NewCatalogMessages(catalog.Catalog{
+		"de": {
+			{Greeting: "Hallo"},
+			{Greeting: "Guten Tag", Register: catalog.Formal},
+			{Greeting: "Guten Morgen", Register: catalog.Formal, TimeOfDay: catalog.Morning},
+			{Greeting: "Moin", TimeOfDay: catalog.Morning},
+			{Greeting: "Liebe Grüße", Context: "email"},
+			{Greeting: "Sehr geehrte Damen und Herren", Register: catalog.Formal, Context: "email"},
+		},
+		"fr": {
+			{Greeting: "Bonsoir", TimeOfDay: catalog.Evening},
+			{Greeting: "Bonjour", Register: catalog.Formal},
+		},
+	})
+
+	testCases := []struct {
+		lang     string
+		query    hello.Query
+		expected string
+	}{
+		{"de", hello.Query{}, "Hallo"},
+		{"de", hello.Query{Register: catalog.Informal}, "Hallo"},
+		{"de", hello.Query{Register: catalog.Formal}, "Guten Tag"},
+		{"de", hello.Query{TimeOfDay: catalog.Morning}, "Moin"},
+		{"de", hello.Query{TimeOfDay: catalog.Evening}, "Hallo"},
+		{"de", hello.Query{Register: catalog.Formal, TimeOfDay: catalog.Morning}, "Guten Morgen"},
+		{"de", hello.Query{Register: catalog.Informal, TimeOfDay: catalog.Morning}, "Moin"},
+		{"de", hello.Query{Context: "email"}, "Liebe Grüße"},
+		{"de", hello.Query{Context: "email", Register: catalog.Formal}, "Sehr geehrte Damen und Herren"},
+		{"de", hello.Query{Context: "chat", Register: catalog.Formal}, "Guten Tag"},
+
+		// Without an untagged variant the least specific variant is the default and a
+		// conflicting variant is used if there is nothing better
+		{"fr", hello.Query{}, "Bonsoir"},
+		{"fr", hello.Query{Register: catalog.Formal, TimeOfDay: catalog.Evening}, "Bonjour"},
+		{"fr", hello.Query{Register: catalog.Formal, TimeOfDay: catalog.Morning}, "Bonjour"},
+		{"fr", hello.Query{Register: catalog.Informal, TimeOfDay: catalog.Morning}, "Bonsoir"},
+	}
+
+	for _, tc := range testCases {
+		greeting, err := messages.Select(tc.lang, tc.query)
+		require.NoError(t, err)
+		require.Equal(t, tc.expected, greeting, "wrong variant for %s %+v", tc.lang, tc.query)
+	}
+
+	greeting, err := messages.Get("de")
+	require.NoError(t, err)
+	require.Equal(t, "Hallo", greeting, "expected the untagged variant by default")
+
+	_, err = messages.Select("es", hello.Query{})
+	require.ErrorIs(t, err, hello.ErrLanguageNotFound)
+}
+
+func TestSelectWeighted(t *testing.T) {
+	onboarding := catalog.Catalog{
+		"en": {
+			{Greeting: "Welcome", Weight: 2},
+			{Greeting: "Hi there"},
+			{Greeting: "Hey"},
+			{Greeting: "Good day", Register: catalog.Formal},
+		},
+	}
+
+	sample := func(messages *hello.Messages, q hello.Query, n int) (greetings []string) {
+		for i := 0; i < n; i++ {
+			greeting, err := messages.Select("en", q)
+			require.NoError(t, err)
+			greetings = append(greetings, greeting)
+		}
+		return greetings
+	}
+
+	// By default the first of the best variants is always selected
+	messages := hello.NewCatalogMessages(onboarding)
+	require.Equal(t, []string{"Welcome", "Welcome", "Welcome"}, sample(messages, hello.Query{}, 3))
+
+	// Round-robin rotates through the variants in proportion to their weights, but
+	// only among the variants that best suit the query
+	q := hello.Query{Selection: hello.SelectRoundRobin}
+	require.Equal(t, []string{"Welcome", "Welcome", "Hi there", "Hey", "Welcome"}, sample(messages, q, 5))
+
+	q.Register = catalog.Formal
+	require.Equal(t, []string{"Good day", "Good day"}, sample(messages, q, 2))
+
+	// Queries that rank the same variants highest share a rotation, whatever the
+	// contexts chosen by the clients
+	q = hello.Query{Selection: hello.SelectRoundRobin}
+	for i, expected := range []string{"Welcome", "Hi there", "Hey"} {
+		q.Context = fmt.Sprintf("context-%d", i)
+		require.Equal(t, []string{expected}, sample(messages, q, 1))
+	}
+
+	// Deterministic selection is the same for a user on every call and on every server
+	q = hello.Query{Selection: hello.SelectDeterministic, UserID: "user-42"}
+	greetings := sample(messages, q, 3)
+	require.Equal(t, greetings, sample(hello.NewCatalogMessages(onboarding), q, 3))
+	require.Equal(t, greetings[0], greetings[1])
+	require.Equal(t, greetings[0], greetings[2])
+
+	counts := make(map[string]int)
+	for i := 0; i < 400; i++ {
+		q.UserID = fmt.Sprintf("user-%d", i)
+		counts[sample(messages, q, 1)[0]]++
+	}
+	require.Len(t, counts, 3, "expected every untagged variant to be selected for some users")
+	require.Greater(t, counts["Welcome"], counts["Hey"], "expected the heavier variant to be selected more often")
+
+	// Random selection is reproducible with a fixed seed
+	q = hello.Query{Selection: hello.SelectRandom}
+	messages.Seed(7)
+	greetings = sample(messages, q, 50)
+
+	other := hello.NewCatalogMessages(onboarding)
+	other.Seed(7)
+	require.Equal(t, greetings, sample(other, q, 50))
+	require.Subset(t, greetings, []string{"Welcome", "Hi there", "Hey"})
+	require.NotContains(t, greetings, "Good day")
+}
+
+func TestTimeOfDay(t *testing.T) {
+	berlin, err := time.LoadLocation("Europe/Berlin")
+	require.NoError(t, err)
+
+	for hour, expected := range map[int]string{
+		0: catalog.Night, 4: catalog.Night, 5: catalog.Morning, 11: catalog.Morning,
+		12: catalog.Afternoon, 16: catalog.Afternoon, 17: catalog.Evening, 21: catalog.Evening,
+		22: catalog.Night, 23: catalog.Night,
+	} {
+		require.Equal(t, expected, hello.TimeOfDay(time.Date(2023, 6, 1, hour, 30, 0, 0, time.UTC)), "wrong time of day at %d:30", hour)
+	}
+
+	// The time of day is in the location of the time
+	morning := time.Date(2023, 6, 1, 6, 0, 0, 0, berlin)
+	require.Equal(t, catalog.Morning, hello.TimeOfDay(morning))
+	require.Equal(t, catalog.Night, hello.TimeOfDay(morning.UTC()))
+}
+
+func TestCanonical(t *testing.T) {
+	testCases := []struct {
+		code       string
+		canonical  string
+		deprecated bool
+	}{
+		{"he", "he", false},
+		{"iw", "he", true},
+		{"IW", "he", true},
+		{"iw-IL", "he-IL", true},
+		{"in", "id", true},
+		{"mo", "ro", true},
+		{"no", "nb", false},
+		{"nor", "nb", false},
+		{"deu", "de", false},
+		{"ger", "de", false},
+		{"cmn-Hant", "zh-Hant", false},
+		{"zh-Hant", "zh-Hant", false},
+		{"fil", "fil", false},
+		{"xx", "xx", false},
+	}
+
+	for _, tc := range testCases {
+		canonical, deprecated := hello.Canonical(tc.code)
+		require.Equal(t, tc.canonical, canonical, "wrong canonical code for %q", tc.code)
+		require.Equal(t, tc.deprecated, deprecated, "wrong deprecation for %q", tc.code)
+	}
+}
+
+func TestScript(t *testing.T) {
+	testCases := []struct {
+		lang      string
+		text      string
+		script    string
+		direction hello.Direction
+	}{
+		{"en", "Hello", "Latn", hello.LeftToRight},
+		{"ar", "مرحبا", "Arab", hello.RightToLeft},
+		{"he", "שלום", "Hebr", hello.RightToLeft},
+		{"fa", "", "Arab", hello.RightToLeft},
+		{"ru", "Привет", "Cyrl", hello.LeftToRight},
+		{"sr", "Zdravo", "Latn", hello.LeftToRight},
+		{"sr-Latn", "Здраво", "Latn", hello.LeftToRight},
+		{"ja", "こんにちは", "Jpan", hello.LeftToRight},
+		{"ko", "안녕하세요", "Kore", hello.LeftToRight},
+		{"zh", "你好", "Hans", hello.LeftToRight},
+		{"zh-TW", "你好", "Hant", hello.LeftToRight},
+		{"zh-hant-CN", "你好", "Hant", hello.LeftToRight},
+		{"pa-PK", "", "Arab", hello.RightToLeft},
+		{"xx", "שלום", "Hebr", hello.RightToLeft},
+		{"xx", "123!", "", ""},
+	}
+
+	for _, tc := range testCases {
+		script := hello.Script(tc.lang, tc.text)
+		require.Equal(t, tc.script, script, "wrong script for %q in %q", tc.text, tc.lang)
+		require.Equal(t, tc.direction, hello.ScriptDirection(script), "wrong direction for %q", script)
+	}
+}
+
+func TestRomanize(t *testing.T) {
+	testCases := []struct {
+		lang      string
+		text      string
+		romanized string
+	}{
+		{"ru", "Привет", "Privet"},
+		{"ru", "Здравствуйте, Мария!", "Zdravstvuyte, Mariya!"},
+		{"uk", "Привіт", "Pryvit"},
+		{"bg", "Щастие", "Shtastie"},
+		{"sr", "Здраво, Џон", "Zdravo, Džon"},
+		{"el", "Γεια σου", "Geia sou"},
+		{"el", "Καλημέρα", "Kalimera"},
+		{"hy", "Բարեւ", "Barev"},
+		{"ka", "გამარჯობა", "gamarjoba"},
+		{"ko", "안녕하세요", "annyeonghaseyo"},
+		{"en", "Hello", ""},
+		{"sr", "Zdravo", ""},
+		{"ar", "مرحبا", ""},
+		{"ja", "こんにちは", ""},
+	}
+
+	for _, tc := range testCases {
+		script := hello.Script(tc.lang, tc.text)
+		require.Equal(t, tc.romanized, hello.Romanize(tc.lang, script, tc.text), "wrong romanization of %q", tc.text)
+	}
+}
+
+func TestAliases(t *testing.T) {
+	messages := hello.NewMessages(map[string]string{
+		"he":  "שלום",
+		"iw":  "שלום!",
+		"no":  "Hei",
+		"deu": "Hallo",
+		"ger": "Servus",
+		"en":  "Hello",
+	})
+	require.Equal(t, []string{"de", "en", "he", "nb"}, messages.Languages(), "expected only canonical codes")
+
+	for code, expected := range map[string]string{
+		"he": "שלום", "iw": "שלום", "heb": "שלום", "nb": "Hei", "no": "Hei", "de": "Hallo", "ger": "Hallo",
+	} {
+		greeting, err := messages.Get(code)
+		require.NoError(t, err, "could not get the greeting for %q", code)
+		require.Equal(t, expected, greeting, "wrong greeting for %q", code)
+	}
+
+	// The shipped catalog has no duplicate entries for aliases
+	data, err := catalog.ReadFile("messages.json", "")
+	require.NoError(t, err)
+	require.Empty(t, hello.Aliases(data))
+}
+
+func TestLint(t *testing.T) {
+	issues := hello.Lint(catalog.Catalog{
+		"fr":      {{Greeting: "Bonjour"}},
+		"en_US":   {{Greeting: "Howdy"}},
+		"he":      {{Greeting: "שלום"}},
+		"iw":      {{Greeting: "שלום"}},
+		"in":      {{Greeting: "Halo"}},
+		"id":      {{Greeting: "Hai"}},
+		"no":      {{Greeting: "Hei"}},
+		"de":      {{Greeting: "Hallo"}, {Greeting: "  ", Register: catalog.Formal}, {Greeting: "Guten Tag\n"}},
+		"es":      {{Greeting: "Hola {name"}},
+		"it":      {{Greeting: "Ciao\xff"}},
+		"pt":      {{Greeting: "Ola\u0301"}},
+		"ru":      {{Greeting: "Привет\uFFFD"}},
+		"zh-Hant": {{Greeting: "你好"}},
+	})
+
+	var lines []string
+	for _, issue := range issues {
+		lines = append(lines, issue.String())
+	}
+
+	require.Equal(t, []string{
+		`warning[missing-source] the catalog has no greeting in the source language "en"`,
+		`error[empty-greeting] de[1]: the greeting is empty`,
+		`warning[control-character] de[2]: the greeting contains control characters`,
+		`warning[whitespace] de[2]: the greeting has leading or trailing whitespace`,
+		`error[invalid-code] en_US: "en_US" is not a valid language code`,
+		`error[invalid-template] es: invalid template: column 11: expected , or } after argument "name"`,
+		`error[conflicting-alias] in: the greetings differ from "id" and will be ignored`,
+		`error[invalid-utf8] it: the greeting is not valid UTF-8`,
+		`warning[duplicate-alias] iw: duplicates the greetings of "he" and can be removed`,
+		`warning[alias] no: alias of "nb", which should be used instead`,
+		`warning[not-normalized] pt: the greeting is not in Unicode normalization form C (NFC)`,
+		`warning[replacement-character] ru: the greeting contains the replacement character U+FFFD`,
+	}, lines)
+
+	require.Len(t, issues.Errors(), 5)
+	require.Len(t, issues.Warnings(), 7)
+	require.ErrorIs(t, issues.Err(), hello.ErrInvalidCatalog)
+	require.EqualError(t, issues.Err(), "invalid catalog: error[empty-greeting] de[1]: the greeting is empty (and 4 more errors)")
+
+	// The shipped catalog has no issues
+	messages := &hello.Messages{}
+	require.NoError(t, messages.Load("messages.json", hello.Strict()))
+	require.Empty(t, messages.Validate())
+}
+
+func TestStrictLoad(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "messages.json")
+	require.NoError(t, os.WriteFile(path, []byte(`{"en": "Hello", "iw": "Shalom", "fr": ""}`), 0644))
+
+	// Without strict mode the catalog is loaded and the issues are reported
+	messages := &hello.Messages{}
+	require.NoError(t, messages.Load(path))
+	require.Len(t, messages.Validate().Errors(), 1)
+	require.Len(t, messages.Validate().Warnings(), 1)
+
+	err := messages.Load(path, hello.Strict())
+	require.ErrorIs(t, err, hello.ErrInvalidCatalog)
+	require.EqualError(t, err, path+": invalid catalog: error[empty-greeting] fr: the greeting is empty")
+
+	// A strict reload keeps the current messages if the catalog has errors
+	require.NoError(t, os.WriteFile(path, []byte(`{"en": "Hello", "fr": "Bonjour"}`), 0644))
+	require.NoError(t, messages.Load(path, hello.Strict()))
+	require.Empty(t, messages.Validate())
+
+	require.NoError(t, os.WriteFile(path, []byte(`{"en": "Hello", "fr": "Bonjour {"}`), 0644))
+	require.NoError(t, os.Chtimes(path, time.Now().Add(time.Minute), time.Now().Add(time.Minute)))
+	_, err = messages.Reload()
+	require.ErrorIs(t, err, hello.ErrInvalidCatalog)
+
+	greeting, err := messages.Get("fr")
+	require.NoError(t, err)
+	require.Equal(t, "Bonjour", greeting)
+
+	// Invalid UTF-8 is an error in the file rather than replaced when it is decoded
+	require.NoError(t, os.WriteFile(path, []byte("{\"en\": \"Hello\", \"fr\": \"Bon\xffjour\"}"), 0644))
+	var cerr *catalog.Error
+	require.ErrorAs(t, messages.Load(path, hello.Strict()), &cerr)
+	require.ErrorAs(t, messages.Load(path), &cerr)
+	require.Equal(t, "invalid UTF-8 byte 0xff", cerr.Msg)
+}
+
+func TestInvalidTemplates(t *testing.T) {
+	messages := hello.NewCatalogMessages(catalog.Catalog{
+		"de": {{Greeting: "Hallo {name"}, {Greeting: "Hallo"}},
+		"xx": {{Greeting: "Hello {first-name}"}},
+	})
+
+	// Variants with invalid templates are reported and are not served
+	issues := messages.Validate().Errors()
+	require.Len(t, issues, 2)
+	require.Equal(t, hello.IssueInvalidTemplate, issues[0].Code)
+	require.Equal(t, hello.IssueInvalidTemplate, issues[1].Code)
+
+	greeting, err := messages.Select("de", hello.Query{})
+	require.NoError(t, err)
+	require.Equal(t, "Hallo", greeting)
+
+	_, err = messages.Select("xx", hello.Query{})
+	require.ErrorIs(t, err, hello.ErrLanguageNotFound)
+	require.Equal(t, []string{"de"}, messages.Languages())
+}
+
+func TestReloadMessages(t *testing.T) {
+	dir := t.TempDir()
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "en.json"), []byte(`{"en": "Hello"}`), 0644))
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "fr.yaml"), []byte("fr: Bonjour\n"), 0644))
+
+	messages := &hello.Messages{}
+	require.NoError(t, messages.Load(dir), "could not load the directory")
+	require.Equal(t, []string{"en", "fr"}, messages.Languages())
+
+	changed, err := messages.Reload()
+	require.NoError(t, err)
+	require.Empty(t, changed)
+
+	// Watch the directory and wait for the modified file to be reloaded
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	reloaded := make(chan []string, 1)
+	go messages.Watch(ctx, 10*time.Millisecond, func(changed []string, err error) {
+		if err != nil {
+			t.Errorf("could not reload the catalog: %s", err)
+			return
+		}
+		reloaded <- changed
+	})
+
+	path := filepath.Join(dir, "fr.yaml")
+	require.NoError(t, os.WriteFile(path, []byte("fr: Salut\n"), 0644))
+	mod := time.Now().Add(time.Minute)
+	require.NoError(t, os.Chtimes(path, mod, mod))
+
+	select {
+	case changed = <-reloaded:
+		require.Equal(t, []string{"fr.yaml"}, changed)
+	case <-time.After(5 * time.Second):
+		t.Fatal("the catalog was not reloaded")
+	}
+	cancel()
+
+	greeting, err := messages.Get("fr")
+	require.NoError(t, err)
+	require.Equal(t, "Salut", greeting)
+
+	// A single file is reloaded when it changes
+	path = filepath.Join(dir, "en.json")
+	require.NoError(t, messages.Load(path))
+	require.NoError(t, os.WriteFile(path, []byte(`{"en": "Hi"}`), 0644))
+	require.NoError(t, os.Chtimes(path, mod, mod))
+
+	changed, err = messages.Reload()
+	require.NoError(t, err)
+	require.Equal(t, []string{"en.json"}, changed)
+	require.Equal(t, []string{"en"}, messages.Languages())
+
+	// In-memory messages have nothing to reload
+	changed, err = hello.NewMessages(map[string]string{"en": "Hello"}).Reload()
+	require.NoError(t, err)
+	require.Empty(t, changed)
+}
@@ -21,6 +21,7 @@ const (
 // server.
 type Listener struct {
 	sock   *bufconn.Listener
+	wrap   func(net.Listener) net.Listener
 	target string
 }
 
@@ -47,6 +48,9 @@ func NewBufConn(opts ...DialOption) *Listener {
 
 // Sock returns the server side of the bufconn connection.
 func (l *Listener) Sock() net.Listener {
+	if l.wrap != nil {
+		return l.wrap(l.sock)
+	}
 	return l.sock
 }
 
@@ -95,3 +99,11 @@ func WithBuffer(sock *bufconn.Listener) DialOption {
 		lis.sock = sock
 	}
 }
+
+// WithListenerWrapper wraps the server side of the bufconn connection returned by Sock,
+// e.g. to track or interfere with the connections accepted by the server.
+func WithListenerWrapper(wrap func(net.Listener) net.Listener) DialOption {
+	return func(lis *Listener) {
+		lis.wrap = wrap
+	}
+}
@@ -0,0 +1,79 @@
+package mock
+
+import (
+	"context"
+	"sync"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/protobuf/proto"
+)
+
+// Call records a single invocation of an RPC on the mock service, including the
+// incoming metadata and every request message received from the client. Requests on
+// streaming RPCs are appended as they are received so it is safe to inspect a Call
+// while the stream is still active.
+type Call struct {
+	sync.RWMutex
+	Method   string
+	Metadata metadata.MD
+	requests []proto.Message
+}
+
+// Requests returns a copy of the request messages received so far.
+func (c *Call) Requests() []proto.Message {
+	c.RLock()
+	defer c.RUnlock()
+
+	reqs := make([]proto.Message, len(c.requests))
+	copy(reqs, c.requests)
+	return reqs
+}
+
+func (c *Call) append(req interface{}) {
+	if msg, ok := req.(proto.Message); ok {
+		c.Lock()
+		c.requests = append(c.requests, proto.Clone(msg))
+		c.Unlock()
+	}
+}
+
+// Create and store a new call for the method.
+func (s *HelloService) newCall(ctx context.Context, method string) *Call {
+	call := &Call{Method: method}
+	if md, ok := metadata.FromIncomingContext(ctx); ok {
+		call.Metadata = md.Copy()
+	}
+
+	s.Lock()
+	s.calls[method] = append(s.calls[method], call)
+	s.Unlock()
+	return call
+}
+
+// Server interceptor that records the request of unary RPCs.
+func (s *HelloService) recordUnary(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+	call := s.newCall(ctx, info.FullMethod)
+	call.append(req)
+	return handler(ctx, req)
+}
+
+// Server interceptor that records every request received on a stream.
+func (s *HelloService) recordStream(srv interface{}, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+	call := s.newCall(ss.Context(), info.FullMethod)
+	return handler(srv, &recordingStream{ServerStream: ss, call: call})
+}
+
+type recordingStream struct {
+	grpc.ServerStream
+	call *Call
+}
+
+func (s *recordingStream) RecvMsg(m interface{}) error {
+	if err := s.ServerStream.RecvMsg(m); err != nil {
+		return err
+	}
+
+	s.call.append(m)
+	return nil
+}
@@ -0,0 +1,336 @@
+package mock
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/pdeziel/grpc-example/pb"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// Expectations provide a fluent alternative to writing On* handlers by hand. Each
+// expectation matches calls to a single RPC, optionally restricted to specific language
+// codes, and describes the reply or status error to return:
+//
+//	srv.ExpectSayHello().WithLanguage("fr").Return(&pb.HelloReply{Greeting: "Bonjour"}).Times(2)
+//	srv.ExpectSayHello().WithLanguage("xx").ReturnError(codes.NotFound, "language not found")
+//	srv.ExpectSayServerStream().WithLanguages("en", "fr").Return(hello, bonjour)
+//	defer srv.AssertExpectations(t)
+//
+// Once an expectation is registered for an RPC, calls to that RPC are served only from
+// expectations and the On* handler is ignored; calls that do not match any expectation
+// fail with an Unimplemented status and are reported by AssertExpectations.
+type Expectation struct {
+	method    string
+	languages []string
+	replies   []*pb.HelloReply
+	many      *pb.HelloManyReply
+	codes     []string
+	err       error
+	times     int
+	calls     int
+}
+
+// TestingT is the subset of testing.T used to report failed expectations.
+type TestingT interface {
+	Helper()
+	Errorf(format string, args ...interface{})
+}
+
+// ExpectSayHello registers an expectation for the SayHello unary RPC.
+func (s *HelloService) ExpectSayHello() *Expectation {
+	return s.expect(SayHelloRPC)
+}
+
+// ExpectSayServerStream registers an expectation for the SayServerStream RPC; the
+// replies are streamed to the client in order before the status is returned.
+func (s *HelloService) ExpectSayServerStream() *Expectation {
+	return s.expect(SayServerStreamRPC)
+}
+
+// ExpectSayClientStream registers an expectation for the SayClientStream RPC; the
+// languages are matched against all requests once the client closes the stream.
+func (s *HelloService) ExpectSayClientStream() *Expectation {
+	return s.expect(SayClientStreamRPC)
+}
+
+// ExpectSayBidirectional registers an expectation for the SayBidirectional RPC; one
+// reply is sent in response to each request and the status is returned once all of
+// the replies have been sent.
+func (s *HelloService) ExpectSayBidirectional() *Expectation {
+	return s.expect(SayBidirectionalRPC)
+}
+
+// ExpectListLanguages registers an expectation for the ListLanguages RPC.
+func (s *HelloService) ExpectListLanguages() *Expectation {
+	return s.expect(ListLanguagesRPC)
+}
+
+func (s *HelloService) expect(method string) *Expectation {
+	exp := &Expectation{method: method, times: 1}
+
+	s.Lock()
+	defer s.Unlock()
+	s.expectations = append(s.expectations, exp)
+	return exp
+}
+
+// WithLanguage restricts a unary expectation to requests for the language code.
+func (e *Expectation) WithLanguage(lang string) *Expectation {
+	return e.WithLanguages(lang)
+}
+
+// WithLanguages restricts the expectation to requests for exactly these language codes
+// in this order. For streaming RPCs from the client every request is matched.
+func (e *Expectation) WithLanguages(langs ...string) *Expectation {
+	e.languages = langs
+	return e
+}
+
+// Return the replies from the RPC. Unary RPCs return the first reply, streaming RPCs
+// send all of them and client streams return them wrapped in a HelloManyReply.
+func (e *Expectation) Return(replies ...*pb.HelloReply) *Expectation {
+	e.replies = replies
+	return e
+}
+
+// ReturnMany sets the reply returned from a client streaming RPC.
+func (e *Expectation) ReturnMany(reply *pb.HelloManyReply) *Expectation {
+	e.many = reply
+	return e
+}
+
+// ReturnLanguages sets the language codes returned from the ListLanguages RPC.
+func (e *Expectation) ReturnLanguages(langs ...string) *Expectation {
+	e.codes = langs
+	return e
+}
+
+// ReturnError returns a status error with the code and message from the RPC. On
+// streaming RPCs the error is returned after all replies have been sent.
+func (e *Expectation) ReturnError(code codes.Code, msg string) *Expectation {
+	e.err = status.Error(code, msg)
+	return e
+}
+
+// ReturnStatus returns the status as an error from the RPC, which allows error details
+// to be attached to the status.
+func (e *Expectation) ReturnStatus(st *status.Status) *Expectation {
+	e.err = st.Err()
+	return e
+}
+
+// Times sets the number of times that the expectation must be met, by default an
+// expectation must be met exactly once.
+func (e *Expectation) Times(n int) *Expectation {
+	e.times = n
+	return e
+}
+
+// AnyTimes allows the expectation to be met any number of times, including never.
+func (e *Expectation) AnyTimes() *Expectation {
+	e.times = -1
+	return e
+}
+
+// String describes the expectation for failure messages.
+func (e *Expectation) String() string {
+	if e.languages == nil {
+		return e.method
+	}
+	return fmt.Sprintf("%s(%s)", e.method, strings.Join(e.languages, ", "))
+}
+
+// AssertExpectations reports every expectation that was not met the expected number of
+// times as well as any unexpected calls. It returns true if all expectations were met.
+func (s *HelloService) AssertExpectations(t TestingT) bool {
+	t.Helper()
+	s.RLock()
+	defer s.RUnlock()
+
+	ok := true
+	for _, exp := range s.expectations {
+		if exp.times >= 0 && exp.calls != exp.times {
+			t.Errorf("mock: expected %s to be called %d time(s) but was called %d time(s)", exp, exp.times, exp.calls)
+			ok = false
+		}
+	}
+
+	for _, call := range s.unexpected {
+		t.Errorf("mock: %s", call)
+		ok = false
+	}
+	return ok
+}
+
+// Returns true if any expectations have been registered for the method.
+func (s *HelloService) hasExpectations(method string) bool {
+	s.RLock()
+	defer s.RUnlock()
+	for _, exp := range s.expectations {
+		if exp.method == method {
+			return true
+		}
+	}
+	return false
+}
+
+// Find the first expectation for the method that matches the languages and has not
+// been exhausted, recording an unexpected call if there is none. If match is false the
+// languages are not compared (e.g. for bidirectional streams where they are not known
+// in advance).
+func (s *HelloService) findExpectation(method string, langs []string, match bool) (*Expectation, error) {
+	s.Lock()
+	defer s.Unlock()
+
+	for _, exp := range s.expectations {
+		if exp.method != method || (exp.times >= 0 && exp.calls >= exp.times) {
+			continue
+		}
+
+		if match && exp.languages != nil && !equal(exp.languages, langs) {
+			continue
+		}
+
+		exp.calls++
+		return exp, nil
+	}
+
+	call := method
+	if match {
+		call = fmt.Sprintf("%s(%s)", method, strings.Join(langs, ", "))
+	}
+	desc := fmt.Sprintf("unexpected call to %s", call)
+	s.unexpected = append(s.unexpected, desc)
+	return nil, status.Error(codes.Unimplemented, "mock: "+desc)
+}
+
+// Record an unexpected request on a stream that has already matched an expectation.
+func (s *HelloService) unexpectedRequest(exp *Expectation, desc string) error {
+	s.Lock()
+	defer s.Unlock()
+
+	s.unexpected = append(s.unexpected, fmt.Sprintf("%s on %s", desc, exp))
+	return status.Errorf(codes.Unimplemented, "mock: %s", desc)
+}
+
+func (s *HelloService) expectedSayHello(req *pb.HelloRequest) (*pb.HelloReply, error) {
+	exp, err := s.findExpectation(SayHelloRPC, []string{req.IsoLanguageCode}, true)
+	if err != nil {
+		return nil, err
+	}
+
+	if exp.err != nil {
+		return nil, exp.err
+	}
+
+	if len(exp.replies) > 0 {
+		return exp.replies[0], nil
+	}
+	return &pb.HelloReply{}, nil
+}
+
+func (s *HelloService) expectedSayServerStream(req *pb.HelloManyRequest, stream pb.Hello_SayServerStreamServer) (err error) {
+	var exp *Expectation
+	if exp, err = s.findExpectation(SayServerStreamRPC, req.IsoLanguageCodes, true); err != nil {
+		return err
+	}
+
+	for _, rep := range exp.replies {
+		if err = stream.Send(rep); err != nil {
+			return err
+		}
+	}
+	return exp.err
+}
+
+func (s *HelloService) expectedSayClientStream(stream pb.Hello_SayClientStreamServer) (err error) {
+	langs := make([]string, 0)
+	for {
+		var req *pb.HelloRequest
+		if req, err = stream.Recv(); err != nil {
+			if errors.Is(err, io.EOF) {
+				break
+			}
+			return err
+		}
+		langs = append(langs, req.IsoLanguageCode)
+	}
+
+	var exp *Expectation
+	if exp, err = s.findExpectation(SayClientStreamRPC, langs, true); err != nil {
+		return err
+	}
+
+	if exp.err != nil {
+		return exp.err
+	}
+
+	reply := exp.many
+	if reply == nil {
+		reply = &pb.HelloManyReply{Greetings: exp.replies}
+	}
+	return stream.SendAndClose(reply)
+}
+
+func (s *HelloService) expectedSayBidirectional(stream pb.Hello_SayBidirectionalServer) (err error) {
+	var exp *Expectation
+	if exp, err = s.findExpectation(SayBidirectionalRPC, nil, false); err != nil {
+		return err
+	}
+
+	for i := 0; ; i++ {
+		// Once all the replies are sent the error is returned if there is one
+		if i >= len(exp.replies) && exp.err != nil {
+			return exp.err
+		}
+
+		var req *pb.HelloRequest
+		if req, err = stream.Recv(); err != nil {
+			if errors.Is(err, io.EOF) {
+				if exp.languages != nil && i < len(exp.languages) {
+					return s.unexpectedRequest(exp, fmt.Sprintf("stream closed after %d of %d requests", i, len(exp.languages)))
+				}
+				return nil
+			}
+			return err
+		}
+
+		if exp.languages != nil && (i >= len(exp.languages) || exp.languages[i] != req.IsoLanguageCode) {
+			return s.unexpectedRequest(exp, fmt.Sprintf("unexpected request %d for %q", i, req.IsoLanguageCode))
+		}
+
+		if i < len(exp.replies) {
+			if err = stream.Send(exp.replies[i]); err != nil {
+				return err
+			}
+		}
+	}
+}
+
+func (s *HelloService) expectedListLanguages() (*pb.Languages, error) {
+	exp, err := s.findExpectation(ListLanguagesRPC, nil, false)
+	if err != nil {
+		return nil, err
+	}
+
+	if exp.err != nil {
+		return nil, exp.err
+	}
+	return &pb.Languages{IsoLanguageCodes: exp.codes}, nil
+}
+
+func equal(a, b []string) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
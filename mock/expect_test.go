@@ -0,0 +1,173 @@
+package mock_test
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"testing"
+
+	"github.com/pdeziel/grpc-example/mock"
+	"github.com/pdeziel/grpc-example/pb"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+func TestExpectSayHello(t *testing.T) {
+	srv, client := setup(t)
+
+	srv.ExpectSayHello().WithLanguage("fr").Return(&pb.HelloReply{Greeting: "Bonjour"}).Times(2)
+	srv.ExpectSayHello().WithLanguage("xx").ReturnError(codes.NotFound, "language not found")
+
+	for i := 0; i < 2; i++ {
+		rep, err := client.SayHello(context.Background(), &pb.HelloRequest{IsoLanguageCode: "fr"})
+		require.NoError(t, err, "could not call the mock")
+		require.Equal(t, "Bonjour", rep.Greeting)
+	}
+
+	_, err := client.SayHello(context.Background(), &pb.HelloRequest{IsoLanguageCode: "xx"})
+	require.Equal(t, codes.NotFound, status.Code(err))
+	srv.AssertExpectations(t)
+
+	// A third call exceeds the expected number of calls
+	_, err = client.SayHello(context.Background(), &pb.HelloRequest{IsoLanguageCode: "fr"})
+	require.Equal(t, codes.Unimplemented, status.Code(err))
+
+	mt := &mockT{}
+	require.False(t, srv.AssertExpectations(mt))
+	require.Len(t, mt.errors, 1)
+	require.Contains(t, mt.errors[0], "unexpected call to /hello.Hello/SayHello(fr)")
+}
+
+func TestExpectUnmet(t *testing.T) {
+	srv, _ := setup(t)
+
+	srv.ExpectSayHello().WithLanguage("en")
+	srv.ExpectSayServerStream().AnyTimes()
+	srv.ExpectSayBidirectional().Times(2)
+
+	mt := &mockT{}
+	require.False(t, srv.AssertExpectations(mt))
+	require.Equal(t, []string{
+		"mock: expected /hello.Hello/SayHello(en) to be called 1 time(s) but was called 0 time(s)",
+		"mock: expected /hello.Hello/SayBidirectional to be called 2 time(s) but was called 0 time(s)",
+	}, mt.errors)
+}
+
+func TestExpectSayServerStream(t *testing.T) {
+	srv, client := setup(t)
+
+	srv.ExpectSayServerStream().WithLanguages("en", "fr").
+		Return(&pb.HelloReply{Greeting: "Hello"}, &pb.HelloReply{Greeting: "Bonjour"}).
+		ReturnError(codes.Aborted, "stream interrupted")
+
+	stream, err := client.SayServerStream(context.Background(), &pb.HelloManyRequest{IsoLanguageCodes: []string{"en", "fr"}})
+	require.NoError(t, err, "could not call the mock")
+
+	greetings, err := recvAll(stream.Recv)
+	require.Equal(t, []string{"Hello", "Bonjour"}, greetings)
+	require.Equal(t, codes.Aborted, status.Code(err))
+
+	// Requests for other languages do not match the expectation
+	stream, err = client.SayServerStream(context.Background(), &pb.HelloManyRequest{IsoLanguageCodes: []string{"es"}})
+	require.NoError(t, err, "could not call the mock")
+	_, err = stream.Recv()
+	require.Equal(t, codes.Unimplemented, status.Code(err))
+
+	mt := &mockT{}
+	require.False(t, srv.AssertExpectations(mt))
+	require.Len(t, mt.errors, 1)
+}
+
+func TestExpectSayClientStream(t *testing.T) {
+	srv, client := setup(t)
+
+	srv.ExpectSayClientStream().WithLanguages("en", "es").Return(
+		&pb.HelloReply{Greeting: "Hello"},
+		&pb.HelloReply{Greeting: "Hola"},
+	)
+
+	stream, err := client.SayClientStream(context.Background())
+	require.NoError(t, err, "could not call the mock")
+	for _, lang := range []string{"en", "es"} {
+		require.NoError(t, stream.Send(&pb.HelloRequest{IsoLanguageCode: lang}))
+	}
+
+	rep, err := stream.CloseAndRecv()
+	require.NoError(t, err, "could not close the stream")
+	require.Len(t, rep.Greetings, 2)
+	require.Equal(t, "Hola", rep.Greetings[1].Greeting)
+	srv.AssertExpectations(t)
+}
+
+func TestExpectSayBidirectional(t *testing.T) {
+	srv, client := setup(t)
+
+	srv.ExpectSayBidirectional().WithLanguages("en", "fr").Return(
+		&pb.HelloReply{Greeting: "Hello"},
+		&pb.HelloReply{Greeting: "Bonjour"},
+	)
+
+	stream, err := client.SayBidirectional(context.Background())
+	require.NoError(t, err, "could not call the mock")
+
+	for _, lang := range []string{"en", "fr"} {
+		require.NoError(t, stream.Send(&pb.HelloRequest{IsoLanguageCode: lang}))
+	}
+	require.NoError(t, stream.CloseSend())
+
+	greetings, err := recvAll(stream.Recv)
+	require.NoError(t, err)
+	require.Equal(t, []string{"Hello", "Bonjour"}, greetings)
+	srv.AssertExpectations(t)
+
+	// A request for an unexpected language aborts the stream and is reported
+	srv.Reset()
+	srv.ExpectSayBidirectional().WithLanguages("en").Return(&pb.HelloReply{Greeting: "Hello"})
+
+	stream, err = client.SayBidirectional(context.Background())
+	require.NoError(t, err, "could not call the mock")
+	require.NoError(t, stream.Send(&pb.HelloRequest{IsoLanguageCode: "de"}))
+
+	_, err = recvAll(stream.Recv)
+	require.Equal(t, codes.Unimplemented, status.Code(err))
+
+	mt := &mockT{}
+	require.False(t, srv.AssertExpectations(mt))
+	require.Equal(t, []string{`mock: unexpected request 0 for "de" on /hello.Hello/SayBidirectional(en)`}, mt.errors)
+}
+
+func setup(t *testing.T) (*mock.HelloService, pb.HelloClient) {
+	srv := mock.New(nil)
+	t.Cleanup(srv.Shutdown)
+
+	client, err := srv.Client(context.Background())
+	require.NoError(t, err, "could not connect to the mock")
+	return srv, client
+}
+
+// Receive greetings until the stream is closed.
+func recvAll(recv func() (*pb.HelloReply, error)) (greetings []string, err error) {
+	for {
+		var rep *pb.HelloReply
+		if rep, err = recv(); err != nil {
+			if errors.Is(err, io.EOF) {
+				return greetings, nil
+			}
+			return greetings, err
+		}
+		greetings = append(greetings, rep.Greeting)
+	}
+}
+
+// Captures failures reported by the mock so that they can be asserted on.
+type mockT struct {
+	errors []string
+}
+
+func (m *mockT) Helper() {}
+
+func (m *mockT) Errorf(format string, args ...interface{}) {
+	m.errors = append(m.errors, fmt.Sprintf(format, args...))
+}
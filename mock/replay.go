@@ -0,0 +1,254 @@
+package mock
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/pdeziel/grpc-example/pb"
+	"github.com/pdeziel/grpc-example/record"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/proto"
+)
+
+// Unary and client streaming exchanges that succeeded must have recorded a reply, e.g.
+// a hand-edited recording might not have one.
+var errNoReply = status.Error(codes.Internal, "mock: recording has no reply")
+
+// A recorded exchange with its messages decoded for matching and replay.
+type replayed struct {
+	method   string
+	requests []proto.Message
+	replies  []proto.Message
+	err      error
+	used     bool
+}
+
+// Replay serves RPCs from exchanges captured by a record.Recorder rather than from the
+// On* handlers. Requests are matched against the recording by method and request
+// messages; the first unused matching exchange is replayed, falling back to one that
+// has already been replayed. Requests that do not match the recording fail with an
+// Unimplemented status and are reported by AssertExpectations. Expectations take
+// precedence over the recording.
+func (s *HelloService) Replay(exchanges []*record.Exchange) (err error) {
+	recording := make([]*replayed, 0, len(exchanges))
+	for i, ex := range exchanges {
+		rep := &replayed{method: ex.Method, err: ex.Err()}
+		if rep.requests, err = ex.RequestMessages(); err != nil {
+			return fmt.Errorf("exchange %d: could not decode requests: %w", i, err)
+		}
+
+		if rep.replies, err = ex.ReplyMessages(); err != nil {
+			return fmt.Errorf("exchange %d: could not decode replies: %w", i, err)
+		}
+		recording = append(recording, rep)
+	}
+
+	s.Lock()
+	defer s.Unlock()
+	s.recording = recording
+	return nil
+}
+
+// ReplayFile loads a JSON-lines recording and serves RPCs from it.
+func (s *HelloService) ReplayFile(path string) (err error) {
+	var exchanges []*record.Exchange
+	if exchanges, err = record.Load(path); err != nil {
+		return err
+	}
+	return s.Replay(exchanges)
+}
+
+// Returns true if RPCs should be served from a recording.
+func (s *HelloService) replaying() bool {
+	s.RLock()
+	defer s.RUnlock()
+	return s.recording != nil
+}
+
+// Find the recorded exchange whose requests match, preferring exchanges that have not
+// been replayed yet. If prefix is true the requests only have to match the beginning
+// of the recorded requests, which is used to match bidirectional streams as they
+// progress.
+func (s *HelloService) findRecording(method string, reqs []proto.Message, prefix bool) *replayed {
+	s.Lock()
+	defer s.Unlock()
+
+	var fallback *replayed
+	for _, rec := range s.recording {
+		if rec.method != method || !matches(rec.requests, reqs, prefix) {
+			continue
+		}
+
+		if !rec.used {
+			if !prefix {
+				rec.used = true
+			}
+			return rec
+		}
+
+		if fallback == nil {
+			fallback = rec
+		}
+	}
+	return fallback
+}
+
+// Record a request that does not match the recording and return the error to the client.
+func (s *HelloService) unrecorded(method string, reqs []proto.Message) error {
+	langs := make([]string, 0, len(reqs))
+	for _, req := range reqs {
+		switch msg := req.(type) {
+		case *pb.HelloRequest:
+			langs = append(langs, msg.IsoLanguageCode)
+		case *pb.HelloManyRequest:
+			langs = append(langs, msg.IsoLanguageCodes...)
+		}
+	}
+
+	desc := fmt.Sprintf("request not in recording %s(%s)", method, strings.Join(langs, ", "))
+
+	s.Lock()
+	s.unexpected = append(s.unexpected, desc)
+	s.Unlock()
+	return status.Error(codes.Unimplemented, "mock: "+desc)
+}
+
+func (s *HelloService) replaySayHello(req *pb.HelloRequest) (*pb.HelloReply, error) {
+	reqs := []proto.Message{req}
+	rec := s.findRecording(SayHelloRPC, reqs, false)
+	if rec == nil {
+		return nil, s.unrecorded(SayHelloRPC, reqs)
+	}
+
+	if rec.err != nil {
+		return nil, rec.err
+	}
+
+	if len(rec.replies) == 0 {
+		return nil, errNoReply
+	}
+	return rec.replies[0].(*pb.HelloReply), nil
+}
+
+func (s *HelloService) replaySayServerStream(req *pb.HelloManyRequest, stream pb.Hello_SayServerStreamServer) (err error) {
+	reqs := []proto.Message{req}
+	rec := s.findRecording(SayServerStreamRPC, reqs, false)
+	if rec == nil {
+		return s.unrecorded(SayServerStreamRPC, reqs)
+	}
+
+	for _, rep := range rec.replies {
+		if err = stream.Send(rep.(*pb.HelloReply)); err != nil {
+			return err
+		}
+	}
+	return rec.err
+}
+
+func (s *HelloService) replaySayClientStream(stream pb.Hello_SayClientStreamServer) (err error) {
+	reqs := make([]proto.Message, 0)
+	for {
+		var req *pb.HelloRequest
+		if req, err = stream.Recv(); err != nil {
+			if errors.Is(err, io.EOF) {
+				break
+			}
+			return err
+		}
+		reqs = append(reqs, req)
+	}
+
+	rec := s.findRecording(SayClientStreamRPC, reqs, false)
+	if rec == nil {
+		return s.unrecorded(SayClientStreamRPC, reqs)
+	}
+
+	if rec.err != nil {
+		return rec.err
+	}
+
+	if len(rec.replies) == 0 {
+		return errNoReply
+	}
+	return stream.SendAndClose(rec.replies[0].(*pb.HelloManyReply))
+}
+
+// Bidirectional streams are replayed assuming one reply per request, which is how the
+// Hello service responds; the recording is matched again after every request.
+func (s *HelloService) replaySayBidirectional(stream pb.Hello_SayBidirectionalServer) (err error) {
+	reqs := make([]proto.Message, 0)
+	for {
+		var req *pb.HelloRequest
+		if req, err = stream.Recv(); err != nil {
+			if errors.Is(err, io.EOF) {
+				break
+			}
+			return err
+		}
+		reqs = append(reqs, req)
+
+		rec := s.findRecording(SayBidirectionalRPC, reqs, true)
+		if rec == nil {
+			return s.unrecorded(SayBidirectionalRPC, reqs)
+		}
+
+		// The recorded stream failed before replying to this request
+		i := len(reqs) - 1
+		if i >= len(rec.replies) {
+			if rec.err != nil {
+				s.markUsed(rec)
+				return rec.err
+			}
+			continue
+		}
+
+		if err = stream.Send(rec.replies[i].(*pb.HelloReply)); err != nil {
+			return err
+		}
+	}
+
+	rec := s.findRecording(SayBidirectionalRPC, reqs, false)
+	if rec == nil {
+		return s.unrecorded(SayBidirectionalRPC, reqs)
+	}
+	return rec.err
+}
+
+func (s *HelloService) markUsed(rec *replayed) {
+	s.Lock()
+	defer s.Unlock()
+	rec.used = true
+}
+
+func matches(recorded, actual []proto.Message, prefix bool) bool {
+	if len(actual) > len(recorded) || (!prefix && len(actual) != len(recorded)) {
+		return false
+	}
+
+	for i := range actual {
+		if !proto.Equal(recorded[i], actual[i]) {
+			return false
+		}
+	}
+	return true
+}
+
+func (s *HelloService) replayListLanguages(req *pb.ListLanguagesRequest) (*pb.Languages, error) {
+	reqs := []proto.Message{req}
+	rec := s.findRecording(ListLanguagesRPC, reqs, false)
+	if rec == nil {
+		return nil, s.unrecorded(ListLanguagesRPC, reqs)
+	}
+
+	if rec.err != nil {
+		return nil, rec.err
+	}
+
+	if len(rec.replies) == 0 {
+		return nil, errNoReply
+	}
+	return rec.replies[0].(*pb.Languages), nil
+}
@@ -0,0 +1,249 @@
+package mock
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"sync"
+	"time"
+
+	"github.com/pdeziel/grpc-example/pb"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// Script declaratively describes a streaming scenario: which requests the mock expects
+// to receive, which replies it sends and with what delays, and the status it closes the
+// stream with. Steps run in order so that tests can control exactly how requests and
+// replies are interleaved:
+//
+//	script := mock.NewScript().
+//		Expect("en").Send(&pb.HelloReply{Greeting: "Hello"}).
+//		Expect("fr").Sleep(50 * time.Millisecond).Send(&pb.HelloReply{Greeting: "Bonjour"}).
+//		CloseWith(codes.Unavailable, "server going away")
+//	srv.OnSayBidirectional = script.Bidirectional
+//	defer script.AssertDone(t)
+//
+// The same script can be used for server streams, where the language codes of the
+// single HelloManyRequest are matched as though they were separate requests, and for
+// client streams, where the sent replies are collected into the HelloManyReply.
+// Requests that do not match the script abort the stream with an Unimplemented status
+// and are reported by Err and AssertDone.
+type Script struct {
+	sync.Mutex
+	steps  []step
+	status *status.Status
+	runs   int
+	done   int
+	err    error
+}
+
+type stepKind uint8
+
+const (
+	expectStep stepKind = iota
+	expectCloseStep
+	sendStep
+	sleepStep
+)
+
+type step struct {
+	kind  stepKind
+	lang  string
+	any   bool
+	reply *pb.HelloReply
+	delay time.Duration
+}
+
+// NewScript creates an empty script that closes the stream with an OK status.
+func NewScript() *Script {
+	return &Script{status: status.New(codes.OK, "")}
+}
+
+// Expect a request for each of the language codes in order.
+func (s *Script) Expect(langs ...string) *Script {
+	for _, lang := range langs {
+		s.steps = append(s.steps, step{kind: expectStep, lang: lang})
+	}
+	return s
+}
+
+// ExpectAny expects n requests for any language code.
+func (s *Script) ExpectAny(n int) *Script {
+	for i := 0; i < n; i++ {
+		s.steps = append(s.steps, step{kind: expectStep, any: true})
+	}
+	return s
+}
+
+// ExpectClose expects the client to close its side of the stream.
+func (s *Script) ExpectClose() *Script {
+	s.steps = append(s.steps, step{kind: expectCloseStep})
+	return s
+}
+
+// Send the replies to the client in order.
+func (s *Script) Send(replies ...*pb.HelloReply) *Script {
+	for _, rep := range replies {
+		s.steps = append(s.steps, step{kind: sendStep, reply: rep})
+	}
+	return s
+}
+
+// Sleep pauses the script before the next step; the pause ends early if the stream is
+// cancelled by the client.
+func (s *Script) Sleep(d time.Duration) *Script {
+	s.steps = append(s.steps, step{kind: sleepStep, delay: d})
+	return s
+}
+
+// CloseWith ends the stream with the status code and message once all the steps have
+// been run. Without CloseWith the stream ends with an OK status.
+func (s *Script) CloseWith(code codes.Code, msg string) *Script {
+	s.status = status.New(code, msg)
+	return s
+}
+
+// Bidirectional runs the script as a SayBidirectional handler.
+func (s *Script) Bidirectional(stream pb.Hello_SayBidirectionalServer) error {
+	return s.run(stream.Context(), stream.Recv, stream.Send)
+}
+
+// ServerStream runs the script as a SayServerStream handler.
+func (s *Script) ServerStream(req *pb.HelloManyRequest, stream pb.Hello_SayServerStreamServer) error {
+	langs := req.IsoLanguageCodes
+	recv := func() (*pb.HelloRequest, error) {
+		if len(langs) == 0 {
+			return nil, io.EOF
+		}
+
+		req := &pb.HelloRequest{
+			IsoLanguageCode: langs[0],
+			PartialSuccess:  req.PartialSuccess,
+			Name:            req.Name,
+			Params:          req.Params,
+			Register:        req.Register,
+			Timezone:        req.Timezone,
+			Context:         req.Context,
+			Selection:       req.Selection,
+			UserId:          req.UserId,
+		}
+		langs = langs[1:]
+		return req, nil
+	}
+	return s.run(stream.Context(), recv, stream.Send)
+}
+
+// ClientStream runs the script as a SayClientStream handler; the replies sent by the
+// script are returned together when the script completes successfully.
+func (s *Script) ClientStream(ctx context.Context, stream pb.Hello_SayClientStreamServer) (err error) {
+	reply := &pb.HelloManyReply{Greetings: make([]*pb.HelloReply, 0)}
+	send := func(rep *pb.HelloReply) error {
+		reply.Greetings = append(reply.Greetings, rep)
+		return nil
+	}
+
+	if err = s.run(ctx, stream.Recv, send); err != nil {
+		return err
+	}
+	return stream.SendAndClose(reply)
+}
+
+// Err returns the first mismatch between the script and the requests it received.
+func (s *Script) Err() error {
+	s.Lock()
+	defer s.Unlock()
+	return s.err
+}
+
+// AssertDone reports a failure if the script was never run to completion or if any
+// requests did not match the script. It returns true if the script succeeded.
+func (s *Script) AssertDone(t TestingT) bool {
+	t.Helper()
+	s.Lock()
+	defer s.Unlock()
+
+	if s.err != nil {
+		t.Errorf("mock: %s", s.err)
+		return false
+	}
+
+	if s.done == 0 {
+		t.Errorf("mock: script was run %d time(s) but never completed", s.runs)
+		return false
+	}
+	return true
+}
+
+func (s *Script) run(ctx context.Context, recv func() (*pb.HelloRequest, error), send func(*pb.HelloReply) error) (err error) {
+	s.Lock()
+	s.runs++
+	steps := s.steps
+	s.Unlock()
+
+	for i, step := range steps {
+		switch step.kind {
+		case expectStep:
+			var req *pb.HelloRequest
+			if req, err = recv(); err != nil {
+				if errors.Is(err, io.EOF) {
+					return s.mismatch(i, "expected a request for %s but the stream was closed", step.expected())
+				}
+				return err
+			}
+
+			if !step.any && req.IsoLanguageCode != step.lang {
+				return s.mismatch(i, "expected a request for %s but received %q", step.expected(), req.IsoLanguageCode)
+			}
+
+		case expectCloseStep:
+			var req *pb.HelloRequest
+			if req, err = recv(); err == nil {
+				return s.mismatch(i, "expected the stream to be closed but received %q", req.IsoLanguageCode)
+			}
+
+			if !errors.Is(err, io.EOF) {
+				return err
+			}
+
+		case sendStep:
+			if err = send(step.reply); err != nil {
+				return err
+			}
+
+		case sleepStep:
+			timer := time.NewTimer(step.delay)
+			select {
+			case <-timer.C:
+			case <-ctx.Done():
+				timer.Stop()
+				return status.FromContextError(ctx.Err()).Err()
+			}
+		}
+	}
+
+	s.Lock()
+	s.done++
+	s.Unlock()
+	return s.status.Err()
+}
+
+// Record a mismatch and return the error that aborts the stream.
+func (s *Script) mismatch(idx int, format string, args ...interface{}) error {
+	err := fmt.Errorf("script step %d: %s", idx, fmt.Sprintf(format, args...))
+
+	s.Lock()
+	if s.err == nil {
+		s.err = err
+	}
+	s.Unlock()
+	return status.Error(codes.Unimplemented, "mock: "+err.Error())
+}
+
+func (s step) expected() string {
+	if s.any {
+		return "any language"
+	}
+	return fmt.Sprintf("%q", s.lang)
+}
@@ -0,0 +1,101 @@
+package mock_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/pdeziel/grpc-example/mock"
+	"github.com/pdeziel/grpc-example/pb"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+func TestScriptBidirectional(t *testing.T) {
+	srv, client := setup(t)
+
+	// Reply to the first request only after the second one has been received
+	script := mock.NewScript().
+		Expect("en", "fr").
+		Send(&pb.HelloReply{Greeting: "Hello"}).
+		Sleep(20 * time.Millisecond).
+		Send(&pb.HelloReply{Greeting: "Bonjour"}).
+		ExpectClose()
+	srv.OnSayBidirectional = script.Bidirectional
+
+	stream, err := client.SayBidirectional(context.Background())
+	require.NoError(t, err, "could not call the mock")
+	require.NoError(t, stream.Send(&pb.HelloRequest{IsoLanguageCode: "en"}))
+	require.NoError(t, stream.Send(&pb.HelloRequest{IsoLanguageCode: "fr"}))
+	require.NoError(t, stream.CloseSend())
+
+	start := time.Now()
+	greetings, err := recvAll(stream.Recv)
+	require.NoError(t, err)
+	require.Equal(t, []string{"Hello", "Bonjour"}, greetings)
+	require.GreaterOrEqual(t, time.Since(start), 20*time.Millisecond)
+	require.True(t, script.AssertDone(t))
+}
+
+func TestScriptMismatch(t *testing.T) {
+	srv, client := setup(t)
+
+	script := mock.NewScript().Expect("en").Send(&pb.HelloReply{Greeting: "Hello"})
+	srv.OnSayBidirectional = script.Bidirectional
+
+	stream, err := client.SayBidirectional(context.Background())
+	require.NoError(t, err, "could not call the mock")
+	require.NoError(t, stream.Send(&pb.HelloRequest{IsoLanguageCode: "de"}))
+
+	_, err = stream.Recv()
+	require.Equal(t, codes.Unimplemented, status.Code(err))
+	require.EqualError(t, script.Err(), `script step 0: expected a request for "en" but received "de"`)
+
+	mt := &mockT{}
+	require.False(t, script.AssertDone(mt))
+	require.Len(t, mt.errors, 1)
+}
+
+func TestScriptMidStreamError(t *testing.T) {
+	srv, client := setup(t)
+
+	script := mock.NewScript().
+		ExpectAny(1).
+		Send(&pb.HelloReply{Greeting: "Hello"}).
+		Send(&pb.HelloReply{Greeting: "Bonjour"}).
+		CloseWith(codes.Unavailable, "server going away")
+	srv.OnSayServerStream = script.ServerStream
+
+	stream, err := client.SayServerStream(context.Background(), &pb.HelloManyRequest{IsoLanguageCodes: []string{"en", "fr"}})
+	require.NoError(t, err, "could not call the mock")
+
+	greetings, err := recvAll(stream.Recv)
+	require.Equal(t, []string{"Hello", "Bonjour"}, greetings)
+	require.Equal(t, codes.Unavailable, status.Code(err))
+	script.AssertDone(t)
+}
+
+func TestScriptClientStream(t *testing.T) {
+	srv, client := setup(t)
+
+	script := mock.NewScript().
+		Expect("en").Send(&pb.HelloReply{Greeting: "Hello"}).
+		Expect("es").Send(&pb.HelloReply{Greeting: "Hola"}).
+		ExpectClose()
+	srv.OnSayClientStream = script.ClientStream
+
+	stream, err := client.SayClientStream(context.Background())
+	require.NoError(t, err, "could not call the mock")
+	require.NoError(t, stream.Send(&pb.HelloRequest{IsoLanguageCode: "en"}))
+	require.NoError(t, stream.Send(&pb.HelloRequest{IsoLanguageCode: "es"}))
+
+	rep, err := stream.CloseAndRecv()
+	require.NoError(t, err)
+	require.Len(t, rep.Greetings, 2)
+	script.AssertDone(t)
+
+	// A script that is never run is reported as not done
+	mt := &mockT{}
+	require.False(t, mock.NewScript().AssertDone(mt))
+}
@@ -1,3 +1,9 @@
+// Package mock provides a mock of the Hello service that serves on a bufconn for
+// testing client-side gRPC code.
+//
+// Calls was an exported map of the number of calls per method; it is now a method that
+// returns the recorded calls of a method with their requests and metadata. Replace
+// srv.Calls[method] with srv.CallCount(method).
 package mock
 
 import (
@@ -19,7 +25,11 @@ import (
 // FullMethod in the generated gRPC code. They are intended to be used directly in
 // tests to mock specific responses for endpoints.
 const (
-	SayHelloRPC = "/hello.Hello/SayHello"
+	SayHelloRPC         = "/hello.Hello/SayHello"
+	SayServerStreamRPC  = "/hello.Hello/SayServerStream"
+	SayClientStreamRPC  = "/hello.Hello/SayClientStream"
+	SayBidirectionalRPC = "/hello.Hello/SayBidirectional"
+	ListLanguagesRPC    = "/hello.Hello/ListLanguages"
 )
 
 var ErrUnavailable = status.Error(codes.Unavailable, "mock method has not been configured")
@@ -33,27 +43,41 @@ func New(bufnet *Listener, opts ...grpc.ServerOption) *HelloService {
 
 	hs := &HelloService{
 		bufnet: bufnet,
-		srv:    grpc.NewServer(opts...),
-		Calls:  make(map[string]int),
+		calls:  make(map[string][]*Call),
 	}
 
+	// Record calls before any user supplied interceptors are applied
+	opts = append([]grpc.ServerOption{
+		grpc.ChainUnaryInterceptor(hs.recordUnary),
+		grpc.ChainStreamInterceptor(hs.recordStream),
+	}, opts...)
+	hs.srv = grpc.NewServer(opts...)
+
 	pb.RegisterHelloServer(hs.srv, hs)
 	go hs.srv.Serve(hs.bufnet.Sock())
 
 	return hs
 }
 
+// HelloService serves RPCs from expectations, a replayed recording or the On* handlers,
+// in that order of precedence. The handlers are read under the lock so that Reset can
+// be called while RPCs are in flight, but they are assigned directly by tests: set them
+// before making the RPCs that use them rather than while the RPCs are being served.
 type HelloService struct {
 	sync.RWMutex
 	pb.UnimplementedHelloServer
 	bufnet             *Listener
 	srv                *grpc.Server
 	client             pb.HelloClient
-	Calls              map[string]int
+	calls              map[string][]*Call
+	expectations       []*Expectation
+	unexpected         []string
+	recording          []*replayed
 	OnSayHello         func(ctx context.Context, req *pb.HelloRequest) (*pb.HelloReply, error)
 	OnSayClientStream  func(ctx context.Context, stream pb.Hello_SayClientStreamServer) error
 	OnSayServerStream  func(req *pb.HelloManyRequest, stream pb.Hello_SayServerStreamServer) error
 	OnSayBidirectional func(stream pb.Hello_SayBidirectionalServer) error
+	OnListLanguages    func(ctx context.Context, req *pb.ListLanguagesRequest) (*pb.Languages, error)
 }
 
 // Create and connect a client to the mock server
@@ -85,56 +109,141 @@ func (s *HelloService) Shutdown() {
 	s.bufnet.Close()
 }
 
-// Reset the calls map and all associated handlers in preparation for a new test.
+// Reset the recorded calls, expectations, replayed recording, and all associated
+// handlers in preparation for a new test.
 func (s *HelloService) Reset() {
-	for key := range s.Calls {
-		delete(s.Calls, key)
+	s.Lock()
+	defer s.Unlock()
+
+	for key := range s.calls {
+		delete(s.calls, key)
 	}
 
+	s.expectations = nil
+	s.unexpected = nil
+	s.recording = nil
+
 	s.OnSayHello = nil
 	s.OnSayClientStream = nil
 	s.OnSayServerStream = nil
 	s.OnSayBidirectional = nil
+	s.OnListLanguages = nil
+}
+
+// CallCount returns the number of times the RPC with the full method name was called.
+func (s *HelloService) CallCount(method string) int {
+	s.RLock()
+	defer s.RUnlock()
+	return len(s.calls[method])
+}
+
+// Calls returns the recorded invocations of the RPC with the full method name in the
+// order that they were received.
+func (s *HelloService) Calls(method string) []*Call {
+	s.RLock()
+	defer s.RUnlock()
+
+	calls := make([]*Call, len(s.calls[method]))
+	copy(calls, s.calls[method])
+	return calls
 }
 
 func (s *HelloService) SayHello(ctx context.Context, req *pb.HelloRequest) (*pb.HelloReply, error) {
-	s.incrCall()
-	if s.OnSayHello != nil {
-		return s.OnSayHello(ctx, req)
+	if s.hasExpectations(SayHelloRPC) {
+		return s.expectedSayHello(req)
+	}
+
+	if s.replaying() {
+		return s.replaySayHello(req)
+	}
+
+	s.RLock()
+	handler := s.OnSayHello
+	s.RUnlock()
+
+	if handler != nil {
+		return handler(ctx, req)
 	}
 
 	return nil, ErrUnavailable
 }
 
 func (s *HelloService) SayClientStream(stream pb.Hello_SayClientStreamServer) error {
-	s.incrCall()
-	if s.OnSayClientStream != nil {
-		return s.OnSayClientStream(stream.Context(), stream)
+	if s.hasExpectations(SayClientStreamRPC) {
+		return s.expectedSayClientStream(stream)
+	}
+
+	if s.replaying() {
+		return s.replaySayClientStream(stream)
+	}
+
+	s.RLock()
+	handler := s.OnSayClientStream
+	s.RUnlock()
+
+	if handler != nil {
+		return handler(stream.Context(), stream)
 	}
 
 	return ErrUnavailable
 }
 
 func (s *HelloService) SayServerStream(req *pb.HelloManyRequest, stream pb.Hello_SayServerStreamServer) error {
-	s.incrCall()
-	if s.OnSayServerStream != nil {
-		return s.OnSayServerStream(req, stream)
+	if s.hasExpectations(SayServerStreamRPC) {
+		return s.expectedSayServerStream(req, stream)
+	}
+
+	if s.replaying() {
+		return s.replaySayServerStream(req, stream)
+	}
+
+	s.RLock()
+	handler := s.OnSayServerStream
+	s.RUnlock()
+
+	if handler != nil {
+		return handler(req, stream)
 	}
 
 	return ErrUnavailable
 }
 
 func (s *HelloService) SayBidirectional(stream pb.Hello_SayBidirectionalServer) error {
-	s.incrCall()
-	if s.OnSayBidirectional != nil {
-		return s.OnSayBidirectional(stream)
+	if s.hasExpectations(SayBidirectionalRPC) {
+		return s.expectedSayBidirectional(stream)
+	}
+
+	if s.replaying() {
+		return s.replaySayBidirectional(stream)
+	}
+
+	s.RLock()
+	handler := s.OnSayBidirectional
+	s.RUnlock()
+
+	if handler != nil {
+		return handler(stream)
 	}
 
 	return ErrUnavailable
 }
 
-func (s *HelloService) incrCall() {
-	s.Lock()
-	defer s.Unlock()
-	s.Calls[SayHelloRPC]++
+func (s *HelloService) ListLanguages(ctx context.Context, req *pb.ListLanguagesRequest) (*pb.Languages, error) {
+	if s.hasExpectations(ListLanguagesRPC) {
+		return s.expectedListLanguages()
+	}
+
+	if s.replaying() {
+		return s.replayListLanguages(req)
+	}
+
+	s.RLock()
+	handler := s.OnListLanguages
+	s.RUnlock()
+
+	if handler != nil {
+		return handler(ctx, req)
+	}
+
+	return nil, ErrUnavailable
 }
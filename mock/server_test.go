@@ -0,0 +1,80 @@
+package mock_test
+
+import (
+	"context"
+	"errors"
+	"io"
+	"testing"
+
+	"github.com/pdeziel/grpc-example/mock"
+	"github.com/pdeziel/grpc-example/pb"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+)
+
+func TestCalls(t *testing.T) {
+	srv := mock.New(nil)
+	defer srv.Shutdown()
+
+	client, err := srv.Client(context.Background())
+	require.NoError(t, err, "could not connect to the mock")
+
+	srv.OnSayHello = func(ctx context.Context, req *pb.HelloRequest) (*pb.HelloReply, error) {
+		return &pb.HelloReply{Greeting: "Hello"}, nil
+	}
+
+	srv.OnSayBidirectional = func(stream pb.Hello_SayBidirectionalServer) (err error) {
+		for {
+			if _, err = stream.Recv(); err != nil {
+				if errors.Is(err, io.EOF) {
+					return nil
+				}
+				return err
+			}
+		}
+	}
+
+	// Make a unary request with metadata
+	ctx := metadata.AppendToOutgoingContext(context.Background(), "x-request-id", "42")
+	_, err = client.SayHello(ctx, &pb.HelloRequest{IsoLanguageCode: "en"})
+	require.NoError(t, err, "could not call the mock")
+
+	// Make a bidirectional request
+	stream, err := client.SayBidirectional(context.Background())
+	require.NoError(t, err, "could not call the mock")
+	for _, lang := range []string{"en", "fr"} {
+		require.NoError(t, stream.Send(&pb.HelloRequest{IsoLanguageCode: lang}))
+	}
+	require.NoError(t, stream.CloseSend())
+	_, err = stream.Recv()
+	require.ErrorIs(t, err, io.EOF)
+
+	// Unconfigured RPCs are still recorded
+	sstream, err := client.SayServerStream(context.Background(), &pb.HelloManyRequest{})
+	require.NoError(t, err, "could not call the mock")
+	_, err = sstream.Recv()
+	require.Equal(t, codes.Unavailable, status.Code(err))
+
+	require.Equal(t, 1, srv.CallCount(mock.SayHelloRPC))
+	require.Equal(t, 1, srv.CallCount(mock.SayBidirectionalRPC))
+	require.Equal(t, 1, srv.CallCount(mock.SayServerStreamRPC))
+	require.Equal(t, 0, srv.CallCount(mock.SayClientStreamRPC))
+
+	unary := srv.Calls(mock.SayHelloRPC)[0]
+	require.Equal(t, mock.SayHelloRPC, unary.Method)
+	require.Equal(t, []string{"42"}, unary.Metadata.Get("x-request-id"))
+
+	reqs := srv.Calls(mock.SayBidirectionalRPC)[0].Requests()
+	require.Len(t, reqs, 2)
+	require.Equal(t, "fr", reqs[1].(*pb.HelloRequest).IsoLanguageCode)
+
+	// Reset should clear the calls and the handlers
+	srv.Reset()
+	require.Equal(t, 0, srv.CallCount(mock.SayHelloRPC))
+	require.Empty(t, srv.Calls(mock.SayBidirectionalRPC))
+
+	_, err = client.SayHello(context.Background(), &pb.HelloRequest{IsoLanguageCode: "en"})
+	require.Equal(t, codes.Unavailable, status.Code(err))
+}
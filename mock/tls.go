@@ -0,0 +1,281 @@
+package mock
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"math/big"
+	"net"
+	"time"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials"
+)
+
+// Certificates is an ephemeral PKI generated in memory for testing mTLS over a bufconn.
+// It contains a certificate authority along with a server certificate for the target
+// host and a client certificate that are both signed by the CA. The server and dial
+// options configure mutual TLS so that both sides verify each other:
+//
+//	certs, err := mock.NewCertificates(mock.DefaultTarget)
+//	srv := mock.New(nil, certs.ServerOptions()...)
+//	client, err := srv.Client(ctx, certs.DialOptions()...)
+//
+// The helpers for expired, wrong-host and untrusted certificates return options that
+// are expected to fail the TLS handshake so that failure paths can be exercised.
+type Certificates struct {
+	CA     *x509.Certificate
+	Server tls.Certificate
+	Client tls.Certificate
+	host   string
+	caKey  crypto.Signer
+	pool   *x509.CertPool
+}
+
+// DefaultTarget is the endpoint that bufconn listeners connect to unless changed with
+// WithTarget; server certificates must be issued for this host to be verified.
+const DefaultTarget = buftarget
+
+// Options for issuing a leaf certificate from a CA.
+type leaf struct {
+	host      string
+	client    bool
+	notBefore time.Time
+	notAfter  time.Time
+}
+
+// NewCertificates generates a CA and a server and client certificate signed by it. The
+// server certificate is valid for host, which should match the target of the Listener.
+func NewCertificates(host string) (certs *Certificates, err error) {
+	certs = &Certificates{host: host}
+	if certs.CA, certs.caKey, err = newCA("mock ca"); err != nil {
+		return nil, err
+	}
+
+	certs.pool = x509.NewCertPool()
+	certs.pool.AddCert(certs.CA)
+
+	if certs.Server, err = certs.issue(certs.serverLeaf(host)); err != nil {
+		return nil, err
+	}
+
+	if certs.Client, err = certs.issue(certs.clientLeaf()); err != nil {
+		return nil, err
+	}
+	return certs, nil
+}
+
+// Pool returns a certificate pool containing only the CA.
+func (c *Certificates) Pool() *x509.CertPool {
+	return c.pool
+}
+
+// ServerTLSConfig returns a TLS configuration for the server that requires clients to
+// present a certificate signed by the CA.
+func (c *Certificates) ServerTLSConfig() *tls.Config {
+	return c.serverConfig(c.Server)
+}
+
+// ClientTLSConfig returns a TLS configuration for the client that presents the client
+// certificate and trusts only the CA.
+func (c *Certificates) ClientTLSConfig() *tls.Config {
+	return c.clientConfig(c.Client)
+}
+
+// ServerOptions returns the options to pass to New to serve with mutual TLS.
+func (c *Certificates) ServerOptions() []grpc.ServerOption {
+	return []grpc.ServerOption{grpc.Creds(credentials.NewTLS(c.ServerTLSConfig()))}
+}
+
+// DialOptions returns the options to pass to Listener.Connect or HelloService.Client
+// to connect with mutual TLS.
+func (c *Certificates) DialOptions() []grpc.DialOption {
+	return []grpc.DialOption{grpc.WithTransportCredentials(credentials.NewTLS(c.ClientTLSConfig()))}
+}
+
+// ExpiredServerOptions returns server options with a certificate signed by the CA that
+// has already expired.
+func (c *Certificates) ExpiredServerOptions() (_ []grpc.ServerOption, err error) {
+	opts := c.serverLeaf(c.host)
+	opts.notBefore = time.Now().Add(-48 * time.Hour)
+	opts.notAfter = time.Now().Add(-24 * time.Hour)
+
+	var cert tls.Certificate
+	if cert, err = c.issue(opts); err != nil {
+		return nil, err
+	}
+	return c.serverOptions(cert), nil
+}
+
+// WrongHostServerOptions returns server options with a certificate signed by the CA
+// that is issued for a different host than the target.
+func (c *Certificates) WrongHostServerOptions() (_ []grpc.ServerOption, err error) {
+	var cert tls.Certificate
+	if cert, err = c.issue(c.serverLeaf("wrong.host.invalid")); err != nil {
+		return nil, err
+	}
+	return c.serverOptions(cert), nil
+}
+
+// UntrustedServerOptions returns server options with a certificate for the target host
+// that is signed by a different CA than the one trusted by the client.
+func (c *Certificates) UntrustedServerOptions() (_ []grpc.ServerOption, err error) {
+	var other *Certificates
+	if other, err = NewCertificates(c.host); err != nil {
+		return nil, err
+	}
+	return c.serverOptions(other.Server), nil
+}
+
+// ExpiredDialOptions returns dial options with a client certificate signed by the CA
+// that has already expired.
+func (c *Certificates) ExpiredDialOptions() (_ []grpc.DialOption, err error) {
+	opts := c.clientLeaf()
+	opts.notBefore = time.Now().Add(-48 * time.Hour)
+	opts.notAfter = time.Now().Add(-24 * time.Hour)
+
+	var cert tls.Certificate
+	if cert, err = c.issue(opts); err != nil {
+		return nil, err
+	}
+	return c.dialOptions(cert), nil
+}
+
+// UntrustedDialOptions returns dial options with a client certificate signed by a
+// different CA than the one trusted by the server.
+func (c *Certificates) UntrustedDialOptions() (_ []grpc.DialOption, err error) {
+	var other *Certificates
+	if other, err = NewCertificates(c.host); err != nil {
+		return nil, err
+	}
+	return c.dialOptions(other.Client), nil
+}
+
+func (c *Certificates) serverOptions(cert tls.Certificate) []grpc.ServerOption {
+	return []grpc.ServerOption{grpc.Creds(credentials.NewTLS(c.serverConfig(cert)))}
+}
+
+func (c *Certificates) dialOptions(cert tls.Certificate) []grpc.DialOption {
+	return []grpc.DialOption{grpc.WithTransportCredentials(credentials.NewTLS(c.clientConfig(cert)))}
+}
+
+func (c *Certificates) serverConfig(cert tls.Certificate) *tls.Config {
+	return &tls.Config{
+		Certificates: []tls.Certificate{cert},
+		ClientAuth:   tls.RequireAndVerifyClientCert,
+		ClientCAs:    c.pool,
+		MinVersion:   tls.VersionTLS12,
+	}
+}
+
+func (c *Certificates) clientConfig(cert tls.Certificate) *tls.Config {
+	return &tls.Config{
+		Certificates: []tls.Certificate{cert},
+		RootCAs:      c.pool,
+		ServerName:   c.host,
+		MinVersion:   tls.VersionTLS12,
+	}
+}
+
+func (c *Certificates) serverLeaf(host string) leaf {
+	return leaf{
+		host:      host,
+		notBefore: time.Now().Add(-time.Hour),
+		notAfter:  time.Now().Add(24 * time.Hour),
+	}
+}
+
+func (c *Certificates) clientLeaf() leaf {
+	return leaf{
+		host:      "mock client",
+		client:    true,
+		notBefore: time.Now().Add(-time.Hour),
+		notAfter:  time.Now().Add(24 * time.Hour),
+	}
+}
+
+// Issue a leaf certificate signed by the CA.
+func (c *Certificates) issue(opts leaf) (cert tls.Certificate, err error) {
+	var key *ecdsa.PrivateKey
+	if key, err = ecdsa.GenerateKey(elliptic.P256(), rand.Reader); err != nil {
+		return cert, err
+	}
+
+	var serial *big.Int
+	if serial, err = serialNumber(); err != nil {
+		return cert, err
+	}
+
+	template := &x509.Certificate{
+		SerialNumber: serial,
+		Subject:      pkix.Name{CommonName: opts.host},
+		NotBefore:    opts.notBefore,
+		NotAfter:     opts.notAfter,
+		KeyUsage:     x509.KeyUsageDigitalSignature,
+	}
+
+	if opts.client {
+		template.ExtKeyUsage = []x509.ExtKeyUsage{x509.ExtKeyUsageClientAuth}
+	} else {
+		template.ExtKeyUsage = []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth}
+		if ip := net.ParseIP(opts.host); ip != nil {
+			template.IPAddresses = []net.IP{ip}
+		} else {
+			template.DNSNames = []string{opts.host}
+		}
+	}
+
+	var der []byte
+	if der, err = x509.CreateCertificate(rand.Reader, template, c.CA, key.Public(), c.caKey); err != nil {
+		return cert, err
+	}
+
+	cert = tls.Certificate{
+		Certificate: [][]byte{der},
+		PrivateKey:  key,
+	}
+	if cert.Leaf, err = x509.ParseCertificate(der); err != nil {
+		return cert, err
+	}
+	return cert, nil
+}
+
+// Create a self-signed certificate authority.
+func newCA(name string) (ca *x509.Certificate, key *ecdsa.PrivateKey, err error) {
+	if key, err = ecdsa.GenerateKey(elliptic.P256(), rand.Reader); err != nil {
+		return nil, nil, err
+	}
+
+	var serial *big.Int
+	if serial, err = serialNumber(); err != nil {
+		return nil, nil, err
+	}
+
+	template := &x509.Certificate{
+		SerialNumber:          serial,
+		Subject:               pkix.Name{CommonName: name},
+		NotBefore:             time.Now().Add(-time.Hour),
+		NotAfter:              time.Now().Add(24 * time.Hour),
+		KeyUsage:              x509.KeyUsageCertSign | x509.KeyUsageDigitalSignature,
+		BasicConstraintsValid: true,
+		IsCA:                  true,
+	}
+
+	var der []byte
+	if der, err = x509.CreateCertificate(rand.Reader, template, template, key.Public(), key); err != nil {
+		return nil, nil, err
+	}
+
+	if ca, err = x509.ParseCertificate(der); err != nil {
+		return nil, nil, err
+	}
+	return ca, key, nil
+}
+
+func serialNumber() (*big.Int, error) {
+	return rand.Int(rand.Reader, new(big.Int).Lsh(big.NewInt(1), 128))
+}
@@ -0,0 +1,91 @@
+package mock_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/pdeziel/grpc-example/mock"
+	"github.com/pdeziel/grpc-example/pb"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/peer"
+	"google.golang.org/grpc/status"
+)
+
+func TestMutualTLS(t *testing.T) {
+	certs, err := mock.NewCertificates(mock.DefaultTarget)
+	require.NoError(t, err, "could not generate certificates")
+
+	srv := mock.New(nil, certs.ServerOptions()...)
+	defer srv.Shutdown()
+
+	srv.OnSayHello = func(ctx context.Context, req *pb.HelloRequest) (*pb.HelloReply, error) {
+		// The server should have verified the client certificate
+		p, ok := peer.FromContext(ctx)
+		if !ok {
+			return nil, status.Error(codes.Internal, "no peer")
+		}
+
+		info, ok := p.AuthInfo.(credentials.TLSInfo)
+		if !ok || len(info.State.VerifiedChains) == 0 {
+			return nil, status.Error(codes.Unauthenticated, "client certificate not verified")
+		}
+		return &pb.HelloReply{Greeting: info.State.PeerCertificates[0].Subject.CommonName}, nil
+	}
+
+	client, err := srv.Client(context.Background(), certs.DialOptions()...)
+	require.NoError(t, err, "could not connect to the mock")
+
+	rep, err := client.SayHello(context.Background(), &pb.HelloRequest{IsoLanguageCode: "en"})
+	require.NoError(t, err, "could not make an mTLS request")
+	require.Equal(t, "mock client", rep.Greeting)
+}
+
+func TestTLSFailures(t *testing.T) {
+	certs, err := mock.NewCertificates(mock.DefaultTarget)
+	require.NoError(t, err, "could not generate certificates")
+
+	expiredServer, err := certs.ExpiredServerOptions()
+	require.NoError(t, err)
+	wrongHostServer, err := certs.WrongHostServerOptions()
+	require.NoError(t, err)
+	untrustedServer, err := certs.UntrustedServerOptions()
+	require.NoError(t, err)
+	expiredClient, err := certs.ExpiredDialOptions()
+	require.NoError(t, err)
+	untrustedClient, err := certs.UntrustedDialOptions()
+	require.NoError(t, err)
+
+	testCases := []struct {
+		name   string
+		server []grpc.ServerOption
+		dial   []grpc.DialOption
+	}{
+		{"expired server", expiredServer, certs.DialOptions()},
+		{"wrong host", wrongHostServer, certs.DialOptions()},
+		{"untrusted server", untrustedServer, certs.DialOptions()},
+		{"expired client", certs.ServerOptions(), expiredClient},
+		{"untrusted client", certs.ServerOptions(), untrustedClient},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			srv := mock.New(nil, tc.server...)
+			defer srv.Shutdown()
+			srv.ExpectSayHello().AnyTimes()
+
+			client, err := srv.Client(context.Background(), tc.dial...)
+			require.NoError(t, err, "dialing is non-blocking and should not fail")
+
+			ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+			defer cancel()
+
+			_, err = client.SayHello(ctx, &pb.HelloRequest{IsoLanguageCode: "en"})
+			require.Equal(t, codes.Unavailable, status.Code(err), "expected the handshake to fail: %v", err)
+			require.Zero(t, srv.CallCount(mock.SayHelloRPC))
+		})
+	}
+}
@@ -0,0 +1,415 @@
+/*
+Package msgfmt implements the subset of ICU MessageFormat used by greeting templates.
+A pattern is literal text with arguments in braces that are replaced by the value of
+the named parameter when the message is formatted:
+
+	Bonjour, {name} !
+
+A select argument chooses a sub-message by the value of a parameter, e.g. to agree
+with the gender of the person being greeted. The other case is required and is used
+when the value does not match any case or the parameter is missing:
+
+	{gender, select, female {Chère {name}} male {Cher {name}} other {Bonjour {name}}}
+
+As in ICU, an apostrophe before a brace quotes literal text up to the next apostrophe
+and two apostrophes are a literal apostrophe, e.g. '{name}' is not an argument. Other
+apostrophes are literal, so "Aujourd'hui" needs no quoting.
+
+Argument names are a letter or underscore followed by letters, digits or underscores,
+the same names that requests may specify parameters with (see IsParamName).
+
+Parameter values are always inserted as literal text and are never interpreted as
+part of the pattern, so user supplied values cannot inject arguments.
+*/
+package msgfmt
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// Message is a parsed pattern that can be formatted with parameters.
+type Message struct {
+	parts []part
+}
+
+// A part of a message is literal text, a simple argument or a select argument.
+type part interface {
+	format(buf *strings.Builder, params map[string]string, missing map[string]struct{})
+	params(names map[string]struct{})
+}
+
+type text string
+
+type argument string
+
+type selection struct {
+	name  string
+	cases map[string]*Message
+}
+
+// SyntaxError describes an invalid pattern with the 1-based column (in characters) of
+// the problem.
+type SyntaxError struct {
+	Column int
+	Msg    string
+}
+
+func (e *SyntaxError) Error() string {
+	return fmt.Sprintf("column %d: %s", e.Column, e.Msg)
+}
+
+// MissingError is returned by Format when the parameters required by the message are
+// not specified. Params is sorted.
+type MissingError struct {
+	Params []string
+}
+
+func (e *MissingError) Error() string {
+	if len(e.Params) == 1 {
+		return fmt.Sprintf("missing parameter %q", e.Params[0])
+	}
+	return fmt.Sprintf("missing parameters %s", strings.Join(quote(e.Params), ", "))
+}
+
+// Parse the pattern into a message, returning a *SyntaxError if it is invalid.
+func Parse(pattern string) (_ *Message, err error) {
+	p := &parser{input: []rune(pattern)}
+
+	var msg *Message
+	if msg, err = p.message(false); err != nil {
+		return nil, err
+	}
+
+	if !p.done() {
+		return nil, p.errorf("unmatched }")
+	}
+	return msg, nil
+}
+
+// MustParse is like Parse but panics if the pattern is invalid.
+func MustParse(pattern string) *Message {
+	msg, err := Parse(pattern)
+	if err != nil {
+		panic(fmt.Errorf("could not parse %q: %w", pattern, err))
+	}
+	return msg
+}
+
+// Format the message with the parameters. Every simple argument in the parts of the
+// message that are selected must have a parameter, otherwise a *MissingError listing
+// all of the missing parameters is returned.
+func (m *Message) Format(params map[string]string) (string, error) {
+	buf := &strings.Builder{}
+	missing := make(map[string]struct{})
+	m.format(buf, params, missing)
+
+	if len(missing) > 0 {
+		return "", &MissingError{Params: sorted(missing)}
+	}
+	return buf.String(), nil
+}
+
+// Params returns the sorted names of all of the parameters referenced by the message,
+// including those that are only used by some of the cases of a select.
+func (m *Message) Params() []string {
+	names := make(map[string]struct{})
+	m.params(names)
+	return sorted(names)
+}
+
+func (m *Message) format(buf *strings.Builder, params map[string]string, missing map[string]struct{}) {
+	for _, p := range m.parts {
+		p.format(buf, params, missing)
+	}
+}
+
+func (m *Message) params(names map[string]struct{}) {
+	for _, p := range m.parts {
+		p.params(names)
+	}
+}
+
+func (t text) format(buf *strings.Builder, _ map[string]string, _ map[string]struct{}) {
+	buf.WriteString(string(t))
+}
+
+func (t text) params(map[string]struct{}) {}
+
+func (a argument) format(buf *strings.Builder, params map[string]string, missing map[string]struct{}) {
+	if val, ok := params[string(a)]; ok {
+		buf.WriteString(val)
+		return
+	}
+	missing[string(a)] = struct{}{}
+}
+
+func (a argument) params(names map[string]struct{}) {
+	names[string(a)] = struct{}{}
+}
+
+func (s *selection) format(buf *strings.Builder, params map[string]string, missing map[string]struct{}) {
+	msg, ok := s.cases[params[s.name]]
+	if !ok {
+		msg = s.cases["other"]
+	}
+	msg.format(buf, params, missing)
+}
+
+func (s *selection) params(names map[string]struct{}) {
+	names[s.name] = struct{}{}
+	for _, msg := range s.cases {
+		msg.params(names)
+	}
+}
+
+// A recursive descent parser over the runes of a pattern.
+type parser struct {
+	input []rune
+	pos   int
+}
+
+// Parse a message up to the end of the input or, if nested, up to the closing brace
+// of the enclosing select case, which is not consumed.
+func (p *parser) message(nested bool) (_ *Message, err error) {
+	msg := &Message{}
+	buf := &strings.Builder{}
+	flush := func() {
+		if buf.Len() > 0 {
+			msg.parts = append(msg.parts, text(buf.String()))
+			buf.Reset()
+		}
+	}
+
+	for !p.done() {
+		switch c := p.peek(); c {
+		case '\'':
+			p.pos++
+			switch {
+			case p.peek() == '\'':
+				buf.WriteRune('\'')
+				p.pos++
+			case p.peek() == '{' || p.peek() == '}':
+				if err = p.quoted(buf); err != nil {
+					return nil, err
+				}
+			default:
+				buf.WriteRune('\'')
+			}
+
+		case '{':
+			flush()
+			var arg part
+			if arg, err = p.argument(); err != nil {
+				return nil, err
+			}
+			msg.parts = append(msg.parts, arg)
+
+		case '}':
+			if !nested {
+				return nil, p.errorf("unmatched }")
+			}
+			flush()
+			return msg, nil
+
+		default:
+			buf.WriteRune(c)
+			p.pos++
+		}
+	}
+
+	if nested {
+		return nil, p.errorf("unterminated select case")
+	}
+	flush()
+	return msg, nil
+}
+
+// Read quoted literal text after an opening apostrophe up to the closing apostrophe.
+func (p *parser) quoted(buf *strings.Builder) error {
+	start := p.pos
+	for !p.done() {
+		c := p.next()
+		if c != '\'' {
+			buf.WriteRune(c)
+			continue
+		}
+
+		if p.peek() == '\'' {
+			buf.WriteRune('\'')
+			p.pos++
+			continue
+		}
+		return nil
+	}
+
+	p.pos = start - 1
+	return p.errorf("unterminated quote")
+}
+
+// Parse a simple {name} or a {name, select, ...} argument.
+func (p *parser) argument() (_ part, err error) {
+	p.pos++ // opening brace
+	p.space()
+	start := p.pos
+
+	var name string
+	if name, err = p.identifier("argument name"); err != nil {
+		return nil, err
+	}
+
+	if !IsParamName(name) {
+		p.pos = start
+		return nil, p.errorf("invalid argument name %q (expected a letter or underscore followed by letters, digits or underscores)", name)
+	}
+	p.space()
+
+	switch p.next() {
+	case '}':
+		return argument(name), nil
+	case ',':
+	default:
+		p.pos--
+		return nil, p.errorf("expected , or } after argument %q", name)
+	}
+
+	p.space()
+	start = p.pos
+
+	var kind string
+	if kind, err = p.identifier("argument type"); err != nil {
+		return nil, err
+	}
+
+	if kind != "select" {
+		p.pos = start
+		return nil, p.errorf("unsupported argument type %q", kind)
+	}
+
+	p.space()
+	if p.next() != ',' {
+		p.pos--
+		return nil, p.errorf("expected , after select")
+	}
+	return p.selection(name)
+}
+
+// Parse the cases of a select argument up to and including its closing brace.
+func (p *parser) selection(name string) (_ part, err error) {
+	sel := &selection{name: name, cases: make(map[string]*Message)}
+	for {
+		p.space()
+		if p.done() {
+			return nil, p.errorf("unterminated select")
+		}
+
+		if p.peek() == '}' {
+			p.pos++
+			break
+		}
+
+		start := p.pos
+		var key string
+		if key, err = p.identifier("select case"); err != nil {
+			return nil, err
+		}
+
+		if _, ok := sel.cases[key]; ok {
+			p.pos = start
+			return nil, p.errorf("duplicate select case %q", key)
+		}
+
+		p.space()
+		if p.next() != '{' {
+			p.pos--
+			return nil, p.errorf("expected { after select case %q", key)
+		}
+
+		if sel.cases[key], err = p.message(true); err != nil {
+			return nil, err
+		}
+		p.pos++ // closing brace of the case
+	}
+
+	if _, ok := sel.cases["other"]; !ok {
+		p.pos--
+		return nil, p.errorf("select on %q has no other case", name)
+	}
+	return sel, nil
+}
+
+// IsParamName returns true if name is a valid parameter name, which is a letter or
+// underscore followed by letters, digits or underscores, e.g. first_name.
+func IsParamName(name string) bool {
+	for i, c := range name {
+		switch {
+		case c == '_' || c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z':
+		case c >= '0' && c <= '9' && i > 0:
+		default:
+			return false
+		}
+	}
+	return name != ""
+}
+
+// Read an identifier made of letters, digits, underscores and hyphens.
+func (p *parser) identifier(what string) (string, error) {
+	start := p.pos
+	for !p.done() {
+		c := p.peek()
+		if !(c == '_' || c == '-' || c >= '0' && c <= '9' || c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z') {
+			break
+		}
+		p.pos++
+	}
+
+	if p.pos == start {
+		return "", p.errorf("expected %s", what)
+	}
+	return string(p.input[start:p.pos]), nil
+}
+
+func (p *parser) space() {
+	for !p.done() && (p.peek() == ' ' || p.peek() == '\t' || p.peek() == '\n' || p.peek() == '\r') {
+		p.pos++
+	}
+}
+
+func (p *parser) done() bool {
+	return p.pos >= len(p.input)
+}
+
+func (p *parser) peek() rune {
+	if p.done() {
+		return 0
+	}
+	return p.input[p.pos]
+}
+
+func (p *parser) next() rune {
+	c := p.peek()
+	p.pos++
+	return c
+}
+
+func (p *parser) errorf(msg string, args ...interface{}) error {
+	return &SyntaxError{Column: p.pos + 1, Msg: fmt.Sprintf(msg, args...)}
+}
+
+func sorted(set map[string]struct{}) []string {
+	names := make([]string, 0, len(set))
+	for name := range set {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+func quote(names []string) []string {
+	quoted := make([]string, 0, len(names))
+	for _, name := range names {
+		quoted = append(quoted, fmt.Sprintf("%q", name))
+	}
+	return quoted
+}
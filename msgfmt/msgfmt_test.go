@@ -0,0 +1,105 @@
+package msgfmt_test
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/pdeziel/grpc-example/msgfmt"
+	"github.com/stretchr/testify/require"
+)
+
+func TestFormat(t *testing.T) {
+	gender := "{gender, select, female {Chère {name}} male {Cher {name}} other {Bonjour {name}}}"
+	testCases := []struct {
+		pattern  string
+		params   map[string]string
+		expected string
+	}{
+		{"Hello", nil, "Hello"},
+		{"Bonjour, {name} !", map[string]string{"name": "Zoë"}, "Bonjour, Zoë !"},
+		{"{ greeting }, { name }", map[string]string{"greeting": "Hi", "name": "Bob"}, "Hi, Bob"},
+		{"Aujourd'hui", nil, "Aujourd'hui"},
+		{"It''s '{name}'", nil, "It's {name}"},
+		{"'{'{name}'}'", map[string]string{"name": "x"}, "{x}"},
+		{"'{it''s}'", nil, "{it's}"},
+		{gender, map[string]string{"gender": "female", "name": "Ada"}, "Chère Ada"},
+		{gender, map[string]string{"gender": "male", "name": "Alan"}, "Cher Alan"},
+		{gender, map[string]string{"gender": "unknown", "name": "Sam"}, "Bonjour Sam"},
+		{gender, map[string]string{"name": "Sam"}, "Bonjour Sam"},
+		{"{formal, select, yes {Guten Tag} other {Hallo {name}}}", map[string]string{"formal": "yes"}, "Guten Tag"},
+
+		// Parameter values are never interpreted as part of the pattern
+		{"Hello {name}", map[string]string{"name": "{gender} '{x}'"}, "Hello {gender} '{x}'"},
+	}
+
+	for _, tc := range testCases {
+		msg, err := msgfmt.Parse(tc.pattern)
+		require.NoError(t, err, "could not parse %q", tc.pattern)
+
+		out, err := msg.Format(tc.params)
+		require.NoError(t, err, "could not format %q", tc.pattern)
+		require.Equal(t, tc.expected, out, "unexpected output for %q", tc.pattern)
+	}
+}
+
+func TestMissing(t *testing.T) {
+	msg := msgfmt.MustParse("{greeting}, {gender, select, female {Madame {name}} other {{title} {name}}}")
+	require.Equal(t, []string{"gender", "greeting", "name", "title"}, msg.Params())
+
+	_, err := msg.Format(map[string]string{"gender": "female"})
+	var missing *msgfmt.MissingError
+	require.True(t, errors.As(err, &missing), "expected a missing error")
+	require.Equal(t, []string{"greeting", "name"}, missing.Params)
+	require.EqualError(t, err, `missing parameters "greeting", "name"`)
+
+	_, err = msg.Format(map[string]string{"greeting": "Bonjour", "name": "Dupont"})
+	require.EqualError(t, err, `missing parameter "title"`)
+
+	out, err := msg.Format(map[string]string{"greeting": "Bonjour", "name": "Dupont", "title": "Docteur"})
+	require.NoError(t, err)
+	require.Equal(t, "Bonjour, Docteur Dupont", out)
+}
+
+func TestSyntaxErrors(t *testing.T) {
+	testCases := []struct {
+		pattern string
+		column  int
+		msg     string
+	}{
+		{"Hello {name", 12, `expected , or } after argument "name"`},
+		{"Hello {}", 8, "expected argument name"},
+		{"Hello {name!}", 12, `expected , or } after argument "name"`},
+		{"Hello }", 7, "unmatched }"},
+		{"Hello {n, number}", 11, `unsupported argument type "number"`},
+		{"{g, select female {x}}", 12, "expected , after select"},
+		{"{g, select, female {x}}", 23, `select on "g" has no other case`},
+		{"{g, select, other {x} other {y}}", 23, `duplicate select case "other"`},
+		{"{g, select, other x}", 19, `expected { after select case "other"`},
+		{"{g, select, other {x}", 22, "unterminated select"},
+		{"{g, select, other {x", 21, "unterminated select case"},
+		{"Hello '{name}", 7, "unterminated quote"},
+		{"日本 {名前}", 5, "expected argument name"},
+		{"Hello {first-name}", 8, `invalid argument name "first-name" (expected a letter or underscore followed by letters, digits or underscores)`},
+		{"Hello {1st}", 8, `invalid argument name "1st" (expected a letter or underscore followed by letters, digits or underscores)`},
+	}
+
+	for _, tc := range testCases {
+		_, err := msgfmt.Parse(tc.pattern)
+		var serr *msgfmt.SyntaxError
+		require.True(t, errors.As(err, &serr), "expected a syntax error for %q, got %v", tc.pattern, err)
+		require.Equal(t, tc.column, serr.Column, "wrong column for %q: %s", tc.pattern, err)
+		require.Equal(t, tc.msg, serr.Msg, "wrong message for %q", tc.pattern)
+	}
+
+	require.Panics(t, func() { msgfmt.MustParse("{") })
+}
+
+func TestIsParamName(t *testing.T) {
+	for _, name := range []string{"name", "first_name", "_x", "Name2"} {
+		require.True(t, msgfmt.IsParamName(name), "expected %q to be a parameter name", name)
+	}
+
+	for _, name := range []string{"", "first-name", "1st", "na me", "名前"} {
+		require.False(t, msgfmt.IsParamName(name), "expected %q not to be a parameter name", name)
+	}
+}
@@ -26,6 +26,8 @@ type HelloClient interface {
 	SayClientStream(ctx context.Context, opts ...grpc.CallOption) (Hello_SayClientStreamClient, error)
 	// Bidirectional streaming RPC - a stream of requests and a stream of responses
 	SayBidirectional(ctx context.Context, opts ...grpc.CallOption) (Hello_SayBidirectionalClient, error)
+	// List the language codes that the server has greetings for
+	ListLanguages(ctx context.Context, in *ListLanguagesRequest, opts ...grpc.CallOption) (*Languages, error)
 }
 
 type helloClient struct {
@@ -142,6 +144,15 @@ func (x *helloSayBidirectionalClient) Recv() (*HelloReply, error) {
 	return m, nil
 }
 
+func (c *helloClient) ListLanguages(ctx context.Context, in *ListLanguagesRequest, opts ...grpc.CallOption) (*Languages, error) {
+	out := new(Languages)
+	err := c.cc.Invoke(ctx, "/hello.Hello/ListLanguages", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
 // HelloServer is the server API for Hello service.
 // All implementations must embed UnimplementedHelloServer
 // for forward compatibility
@@ -154,6 +165,8 @@ type HelloServer interface {
 	SayClientStream(Hello_SayClientStreamServer) error
 	// Bidirectional streaming RPC - a stream of requests and a stream of responses
 	SayBidirectional(Hello_SayBidirectionalServer) error
+	// List the language codes that the server has greetings for
+	ListLanguages(context.Context, *ListLanguagesRequest) (*Languages, error)
 	mustEmbedUnimplementedHelloServer()
 }
 
@@ -173,6 +186,9 @@ func (UnimplementedHelloServer) SayClientStream(Hello_SayClientStreamServer) err
 func (UnimplementedHelloServer) SayBidirectional(Hello_SayBidirectionalServer) error {
 	return status.Errorf(codes.Unimplemented, "method SayBidirectional not implemented")
 }
+func (UnimplementedHelloServer) ListLanguages(context.Context, *ListLanguagesRequest) (*Languages, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method ListLanguages not implemented")
+}
 func (UnimplementedHelloServer) mustEmbedUnimplementedHelloServer() {}
 
 // UnsafeHelloServer may be embedded to opt out of forward compatibility for this service.
@@ -277,6 +293,24 @@ func (x *helloSayBidirectionalServer) Recv() (*HelloRequest, error) {
 	return m, nil
 }
 
+func _Hello_ListLanguages_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ListLanguagesRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(HelloServer).ListLanguages(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/hello.Hello/ListLanguages",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(HelloServer).ListLanguages(ctx, req.(*ListLanguagesRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
 // Hello_ServiceDesc is the grpc.ServiceDesc for Hello service.
 // It's only intended for direct use with grpc.RegisterService,
 // and not to be introspected or modified (even as a copy)
@@ -288,6 +322,10 @@ var Hello_ServiceDesc = grpc.ServiceDesc{
 			MethodName: "SayHello",
 			Handler:    _Hello_SayHello_Handler,
 		},
+		{
+			MethodName: "ListLanguages",
+			Handler:    _Hello_ListLanguages_Handler,
+		},
 	},
 	Streams: []grpc.StreamDesc{
 		{
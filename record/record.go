@@ -0,0 +1,158 @@
+/*
+Package record captures Hello gRPC traffic to a JSON-lines file so that it can be
+replayed later without a server, e.g. by the mock.HelloService in golden tests. Each
+line in the file is an Exchange that describes a single RPC: its requests, replies,
+metadata, final status and timings. Messages are encoded with protojson so recordings
+are readable and can be edited by hand.
+*/
+package record
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"time"
+
+	"github.com/pdeziel/grpc-example/pb"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/encoding/protojson"
+	"google.golang.org/protobuf/proto"
+)
+
+// Exchange is a single recorded RPC.
+type Exchange struct {
+	Method   string            `json:"method"`
+	Metadata metadata.MD       `json:"metadata,omitempty"`
+	Requests []json.RawMessage `json:"requests"`
+	Replies  []json.RawMessage `json:"replies"`
+	Code     codes.Code        `json:"code"`
+	Message  string            `json:"message,omitempty"`
+	Start    time.Time         `json:"start"`
+	Duration time.Duration     `json:"duration"`
+
+	// The time each reply was received relative to the start of the RPC.
+	ReplyOffsets []time.Duration `json:"reply_offsets,omitempty"`
+}
+
+// Status returns the final status of the RPC.
+func (e *Exchange) Status() *status.Status {
+	return status.New(e.Code, e.Message)
+}
+
+// Err returns the final status of the RPC as an error or nil if the RPC succeeded.
+func (e *Exchange) Err() error {
+	return e.Status().Err()
+}
+
+// RequestMessages decodes the recorded requests.
+func (e *Exchange) RequestMessages() (msgs []proto.Message, err error) {
+	return decode(e.Requests, func() proto.Message { return newRequest(e.Method) })
+}
+
+// ReplyMessages decodes the recorded replies.
+func (e *Exchange) ReplyMessages() (msgs []proto.Message, err error) {
+	return decode(e.Replies, func() proto.Message { return newReply(e.Method) })
+}
+
+func (e *Exchange) addRequest(m interface{}) {
+	if data, err := encode(m); err == nil {
+		e.Requests = append(e.Requests, data)
+	}
+}
+
+func (e *Exchange) addReply(m interface{}) {
+	if data, err := encode(m); err == nil {
+		e.Replies = append(e.Replies, data)
+		e.ReplyOffsets = append(e.ReplyOffsets, time.Since(e.Start))
+	}
+}
+
+func (e *Exchange) finish(err error) {
+	st := status.Convert(err)
+	e.Code = st.Code()
+	e.Message = st.Message()
+	e.Duration = time.Since(e.Start)
+}
+
+// Read all of the exchanges from a JSON-lines recording.
+func Read(r io.Reader) (exchanges []*Exchange, err error) {
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 0, 64*1024), 16*1024*1024)
+
+	var line int
+	for scanner.Scan() {
+		line++
+		if len(scanner.Bytes()) == 0 {
+			continue
+		}
+
+		ex := &Exchange{}
+		if err = json.Unmarshal(scanner.Bytes(), ex); err != nil {
+			return nil, fmt.Errorf("line %d: %w", line, err)
+		}
+		exchanges = append(exchanges, ex)
+	}
+
+	if err = scanner.Err(); err != nil {
+		return nil, err
+	}
+	return exchanges, nil
+}
+
+// Load all of the exchanges from a recording file.
+func Load(path string) (_ []*Exchange, err error) {
+	var f *os.File
+	if f, err = os.Open(path); err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	return Read(f)
+}
+
+// Create the request message for the full method name of a Hello RPC.
+func newRequest(method string) proto.Message {
+	switch method {
+	case "/hello.Hello/SayServerStream":
+		return &pb.HelloManyRequest{}
+	case "/hello.Hello/ListLanguages":
+		return &pb.ListLanguagesRequest{}
+	default:
+		return &pb.HelloRequest{}
+	}
+}
+
+// Create the reply message for the full method name of a Hello RPC.
+func newReply(method string) proto.Message {
+	switch method {
+	case "/hello.Hello/SayClientStream":
+		return &pb.HelloManyReply{}
+	case "/hello.Hello/ListLanguages":
+		return &pb.Languages{}
+	default:
+		return &pb.HelloReply{}
+	}
+}
+
+func encode(m interface{}) (json.RawMessage, error) {
+	msg, ok := m.(proto.Message)
+	if !ok {
+		return nil, fmt.Errorf("cannot record non-protobuf message %T", m)
+	}
+	return protojson.Marshal(msg)
+}
+
+func decode(raw []json.RawMessage, factory func() proto.Message) (msgs []proto.Message, err error) {
+	msgs = make([]proto.Message, 0, len(raw))
+	for _, data := range raw {
+		msg := factory()
+		if err = protojson.Unmarshal(data, msg); err != nil {
+			return nil, err
+		}
+		msgs = append(msgs, msg)
+	}
+	return msgs, nil
+}
@@ -0,0 +1,201 @@
+package record_test
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+	"testing"
+
+	"github.com/pdeziel/grpc-example/mock"
+	"github.com/pdeziel/grpc-example/pb"
+	"github.com/pdeziel/grpc-example/record"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+)
+
+func TestRecordAndReplay(t *testing.T) {
+	// Record traffic from a mock configured with expectations
+	buf := &bytes.Buffer{}
+	rec := record.New(buf)
+
+	srv := mock.New(nil, rec.ServerOptions()...)
+	defer srv.Shutdown()
+
+	srv.ExpectSayHello().WithLanguage("fr").Return(&pb.HelloReply{Greeting: "Bonjour", IsoLanguageCode: "fr"})
+	srv.ExpectSayHello().WithLanguage("xx").ReturnError(codes.NotFound, "language not found")
+	srv.ExpectSayServerStream().WithLanguages("en", "es").Return(
+		&pb.HelloReply{Greeting: "Hello"},
+		&pb.HelloReply{Greeting: "Hola"},
+	)
+	srv.ExpectSayBidirectional().WithLanguages("de", "it").Return(
+		&pb.HelloReply{Greeting: "Hallo"},
+		&pb.HelloReply{Greeting: "Ciao"},
+	)
+
+	client, err := srv.Client(context.Background())
+	require.NoError(t, err, "could not connect to the mock")
+	recorded := exercise(t, client)
+	srv.AssertExpectations(t)
+	require.NoError(t, rec.Close())
+
+	// Check the recording
+	exchanges, err := record.Read(bytes.NewReader(buf.Bytes()))
+	require.NoError(t, err, "could not read the recording")
+	require.Len(t, exchanges, 4)
+	require.Equal(t, mock.SayHelloRPC, exchanges[0].Method)
+	require.Equal(t, []string{"golden"}, exchanges[0].Metadata.Get("x-test"))
+	require.Equal(t, codes.NotFound, exchanges[1].Code)
+	require.Len(t, exchanges[2].Replies, 2)
+	require.Len(t, exchanges[2].ReplyOffsets, 2)
+	require.Len(t, exchanges[3].Requests, 2)
+
+	// Replay the recording from a new mock without any expectations
+	replay := mock.New(nil)
+	defer replay.Shutdown()
+	require.NoError(t, replay.Replay(exchanges), "could not load the recording")
+
+	client, err = replay.Client(context.Background())
+	require.NoError(t, err, "could not connect to the replay mock")
+	require.Equal(t, recorded, exercise(t, client), "expected replay to match the recording")
+	replay.AssertExpectations(t)
+
+	// Requests that are not in the recording are flagged
+	_, err = client.SayHello(context.Background(), &pb.HelloRequest{IsoLanguageCode: "ja"})
+	require.Equal(t, codes.Unimplemented, status.Code(err))
+
+	stream, err := client.SayBidirectional(context.Background())
+	require.NoError(t, err)
+	require.NoError(t, stream.Send(&pb.HelloRequest{IsoLanguageCode: "de"}))
+	rep, err := stream.Recv()
+	require.NoError(t, err)
+	require.Equal(t, "Hallo", rep.Greeting)
+	require.NoError(t, stream.Send(&pb.HelloRequest{IsoLanguageCode: "fr"}))
+	_, err = stream.Recv()
+	require.Equal(t, codes.Unimplemented, status.Code(err))
+
+	mt := &mockT{}
+	require.False(t, replay.AssertExpectations(mt))
+	require.Equal(t, []string{
+		"mock: request not in recording /hello.Hello/SayHello(ja)",
+		"mock: request not in recording /hello.Hello/SayBidirectional(de, fr)",
+	}, mt.errors)
+}
+
+func TestReplayNoReply(t *testing.T) {
+	replay := mock.New(nil)
+	defer replay.Shutdown()
+
+	// Recordings that succeeded without a reply, e.g. that were edited by hand
+	exchanges, err := record.Read(strings.NewReader(`{"method":"/hello.Hello/SayHello","requests":[{"iso_language_code":"en"}],"replies":[]}
+{"method":"/hello.Hello/SayClientStream","requests":[{"iso_language_code":"en"}],"replies":[]}
+{"method":"/hello.Hello/ListLanguages","requests":[{}],"replies":[]}
+`))
+	require.NoError(t, err, "could not read the recording")
+	require.NoError(t, replay.Replay(exchanges), "could not load the recording")
+
+	client, err := replay.Client(context.Background())
+	require.NoError(t, err, "could not connect to the replay mock")
+
+	_, err = client.SayHello(context.Background(), &pb.HelloRequest{IsoLanguageCode: "en"})
+	require.Equal(t, codes.Internal, status.Code(err))
+	require.Equal(t, "mock: recording has no reply", status.Convert(err).Message())
+
+	stream, err := client.SayClientStream(context.Background())
+	require.NoError(t, err)
+	require.NoError(t, stream.Send(&pb.HelloRequest{IsoLanguageCode: "en"}))
+	_, err = stream.CloseAndRecv()
+	require.Equal(t, codes.Internal, status.Code(err))
+	require.Equal(t, "mock: recording has no reply", status.Convert(err).Message())
+	_, err = client.ListLanguages(context.Background(), &pb.ListLanguagesRequest{})
+	require.Equal(t, codes.Internal, status.Code(err))
+	require.Equal(t, "mock: recording has no reply", status.Convert(err).Message())
+}
+
+func TestClientRecorder(t *testing.T) {
+	srv := mock.New(nil)
+	defer srv.Shutdown()
+	srv.ExpectSayHello().WithLanguage("fr").Return(&pb.HelloReply{Greeting: "Bonjour"})
+	srv.ExpectSayClientStream().WithLanguages("en", "fr").Return(&pb.HelloReply{Greeting: "Hello"}, &pb.HelloReply{Greeting: "Bonjour"})
+
+	buf := &bytes.Buffer{}
+	rec := record.New(buf)
+	opts := append([]grpc.DialOption{grpc.WithTransportCredentials(insecure.NewCredentials())}, rec.DialOptions()...)
+	client, err := srv.Client(context.Background(), opts...)
+	require.NoError(t, err, "could not connect to the mock")
+
+	_, err = client.SayHello(context.Background(), &pb.HelloRequest{IsoLanguageCode: "fr"})
+	require.NoError(t, err)
+
+	stream, err := client.SayClientStream(context.Background())
+	require.NoError(t, err)
+	require.NoError(t, stream.Send(&pb.HelloRequest{IsoLanguageCode: "en"}))
+	require.NoError(t, stream.Send(&pb.HelloRequest{IsoLanguageCode: "fr"}))
+	_, err = stream.CloseAndRecv()
+	require.NoError(t, err)
+
+	exchanges, err := record.Read(buf)
+	require.NoError(t, err, "could not read the recording")
+	require.Len(t, exchanges, 2)
+	require.Equal(t, mock.SayClientStreamRPC, exchanges[1].Method)
+	require.Equal(t, codes.OK, exchanges[1].Code)
+
+	replies, err := exchanges[1].ReplyMessages()
+	require.NoError(t, err)
+	require.Len(t, replies, 1)
+	require.Len(t, replies[0].(*pb.HelloManyReply).Greetings, 2)
+}
+
+// Make the same set of requests against a client and return the results.
+func exercise(t *testing.T, client pb.HelloClient) []string {
+	results := make([]string, 0)
+	ctx := metadata.AppendToOutgoingContext(context.Background(), "x-test", "golden")
+
+	for _, lang := range []string{"fr", "xx"} {
+		rep, err := client.SayHello(ctx, &pb.HelloRequest{IsoLanguageCode: lang})
+		if err != nil {
+			results = append(results, status.Code(err).String())
+			continue
+		}
+		results = append(results, rep.Greeting)
+	}
+
+	sstream, err := client.SayServerStream(ctx, &pb.HelloManyRequest{IsoLanguageCodes: []string{"en", "es"}})
+	require.NoError(t, err)
+	results = append(results, recvAll(t, sstream.Recv)...)
+
+	bstream, err := client.SayBidirectional(ctx)
+	require.NoError(t, err)
+	for _, lang := range []string{"de", "it"} {
+		require.NoError(t, bstream.Send(&pb.HelloRequest{IsoLanguageCode: lang}))
+	}
+	require.NoError(t, bstream.CloseSend())
+	return append(results, recvAll(t, bstream.Recv)...)
+}
+
+func recvAll(t *testing.T, recv func() (*pb.HelloReply, error)) (greetings []string) {
+	for {
+		rep, err := recv()
+		if err != nil {
+			require.True(t, errors.Is(err, io.EOF), "unexpected stream error: %v", err)
+			return greetings
+		}
+		greetings = append(greetings, rep.Greeting)
+	}
+}
+
+type mockT struct {
+	errors []string
+}
+
+func (m *mockT) Helper() {}
+
+func (m *mockT) Errorf(format string, args ...interface{}) {
+	m.errors = append(m.errors, fmt.Sprintf(format, args...))
+}
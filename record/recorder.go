@@ -0,0 +1,249 @@
+package record
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"os"
+	"sync"
+	"time"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/metadata"
+)
+
+// Recorder writes exchanges to a JSON-lines file. It provides interceptors for both
+// servers and clients so traffic can be captured on either side of the connection.
+// It is safe to use from multiple go routines.
+type Recorder struct {
+	sync.Mutex
+	enc    *json.Encoder
+	closer io.Closer
+	err    error
+}
+
+// New creates a recorder that writes exchanges to w.
+func New(w io.Writer) *Recorder {
+	return &Recorder{enc: json.NewEncoder(w)}
+}
+
+// Create a recorder that writes to the file at path, truncating it if it exists.
+func Create(path string) (_ *Recorder, err error) {
+	var f *os.File
+	if f, err = os.Create(path); err != nil {
+		return nil, err
+	}
+
+	rec := New(f)
+	rec.closer = f
+	return rec, nil
+}
+
+// Close the underlying file if the recorder was created with Create, returning the
+// first error that occurred while writing exchanges.
+func (r *Recorder) Close() error {
+	r.Lock()
+	defer r.Unlock()
+
+	if r.closer != nil {
+		if err := r.closer.Close(); err != nil && r.err == nil {
+			r.err = err
+		}
+		r.closer = nil
+	}
+	return r.err
+}
+
+// ServerOptions returns the options that install the recording interceptors on a server.
+func (r *Recorder) ServerOptions() []grpc.ServerOption {
+	return []grpc.ServerOption{
+		grpc.ChainUnaryInterceptor(r.UnaryServerInterceptor()),
+		grpc.ChainStreamInterceptor(r.StreamServerInterceptor()),
+	}
+}
+
+// DialOptions returns the options that install the recording interceptors on a client.
+func (r *Recorder) DialOptions() []grpc.DialOption {
+	return []grpc.DialOption{
+		grpc.WithChainUnaryInterceptor(r.UnaryClientInterceptor()),
+		grpc.WithChainStreamInterceptor(r.StreamClientInterceptor()),
+	}
+}
+
+// UnaryServerInterceptor records unary RPCs handled by a server.
+func (r *Recorder) UnaryServerInterceptor() grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (rep interface{}, err error) {
+		md, _ := metadata.FromIncomingContext(ctx)
+		ex := newExchange(info.FullMethod, md)
+		ex.addRequest(req)
+
+		if rep, err = handler(ctx, req); err == nil {
+			ex.addReply(rep)
+		}
+
+		ex.finish(err)
+		r.write(ex)
+		return rep, err
+	}
+}
+
+// StreamServerInterceptor records streaming RPCs handled by a server.
+func (r *Recorder) StreamServerInterceptor() grpc.StreamServerInterceptor {
+	return func(srv interface{}, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) (err error) {
+		md, _ := metadata.FromIncomingContext(ss.Context())
+		stream := &serverStream{ServerStream: ss, ex: newExchange(info.FullMethod, md)}
+
+		err = handler(srv, stream)
+
+		stream.Lock()
+		stream.ex.finish(err)
+		stream.Unlock()
+
+		r.write(stream.ex)
+		return err
+	}
+}
+
+// UnaryClientInterceptor records unary RPCs made by a client.
+func (r *Recorder) UnaryClientInterceptor() grpc.UnaryClientInterceptor {
+	return func(ctx context.Context, method string, req, rep interface{}, cc *grpc.ClientConn, invoker grpc.UnaryInvoker, opts ...grpc.CallOption) (err error) {
+		md, _ := metadata.FromOutgoingContext(ctx)
+		ex := newExchange(method, md)
+		ex.addRequest(req)
+
+		if err = invoker(ctx, method, req, rep, cc, opts...); err == nil {
+			ex.addReply(rep)
+		}
+
+		ex.finish(err)
+		r.write(ex)
+		return err
+	}
+}
+
+// StreamClientInterceptor records streaming RPCs made by a client. The exchange is
+// written when the stream ends, which requires the client to receive until the
+// stream returns an error or io.EOF.
+func (r *Recorder) StreamClientInterceptor() grpc.StreamClientInterceptor {
+	return func(ctx context.Context, desc *grpc.StreamDesc, cc *grpc.ClientConn, method string, streamer grpc.Streamer, opts ...grpc.CallOption) (_ grpc.ClientStream, err error) {
+		md, _ := metadata.FromOutgoingContext(ctx)
+		ex := newExchange(method, md)
+
+		var cs grpc.ClientStream
+		if cs, err = streamer(ctx, desc, cc, method, opts...); err != nil {
+			ex.finish(err)
+			r.write(ex)
+			return nil, err
+		}
+
+		return &clientStream{ClientStream: cs, ex: ex, rec: r, desc: desc}, nil
+	}
+}
+
+// Write the exchange as a single JSON line, only the first write error is kept.
+func (r *Recorder) write(ex *Exchange) {
+	r.Lock()
+	defer r.Unlock()
+
+	if err := r.enc.Encode(ex); err != nil && r.err == nil {
+		r.err = err
+	}
+}
+
+func newExchange(method string, md metadata.MD) *Exchange {
+	return &Exchange{
+		Method:   method,
+		Metadata: md.Copy(),
+		Requests: make([]json.RawMessage, 0),
+		Replies:  make([]json.RawMessage, 0),
+		Start:    time.Now(),
+	}
+}
+
+type serverStream struct {
+	grpc.ServerStream
+	sync.Mutex
+	ex *Exchange
+}
+
+func (s *serverStream) RecvMsg(m interface{}) error {
+	if err := s.ServerStream.RecvMsg(m); err != nil {
+		return err
+	}
+
+	s.Lock()
+	s.ex.addRequest(m)
+	s.Unlock()
+	return nil
+}
+
+func (s *serverStream) SendMsg(m interface{}) error {
+	if err := s.ServerStream.SendMsg(m); err != nil {
+		return err
+	}
+
+	s.Lock()
+	s.ex.addReply(m)
+	s.Unlock()
+	return nil
+}
+
+type clientStream struct {
+	grpc.ClientStream
+	sync.Mutex
+	ex   *Exchange
+	rec  *Recorder
+	desc *grpc.StreamDesc
+	done bool
+}
+
+func (s *clientStream) SendMsg(m interface{}) error {
+	if err := s.ClientStream.SendMsg(m); err != nil {
+		return err
+	}
+
+	s.Lock()
+	s.ex.addRequest(m)
+	s.Unlock()
+	return nil
+}
+
+func (s *clientStream) RecvMsg(m interface{}) (err error) {
+	if err = s.ClientStream.RecvMsg(m); err != nil {
+		if errors.Is(err, io.EOF) {
+			err = nil
+		}
+		s.finish(err)
+		return s.recvErr(err)
+	}
+
+	s.Lock()
+	s.ex.addReply(m)
+	s.Unlock()
+
+	// Streams without server streaming end after the single reply is received
+	if !s.desc.ServerStreams {
+		s.finish(nil)
+	}
+	return nil
+}
+
+// Restore io.EOF for the caller if the stream ended normally.
+func (s *clientStream) recvErr(err error) error {
+	if err == nil {
+		return io.EOF
+	}
+	return err
+}
+
+func (s *clientStream) finish(err error) {
+	s.Lock()
+	defer s.Unlock()
+
+	if !s.done {
+		s.done = true
+		s.ex.finish(err)
+		s.rec.write(s.ex)
+	}
+}
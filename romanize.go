@@ -0,0 +1,179 @@
+package hello
+
+import (
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	"golang.org/x/text/unicode/norm"
+)
+
+// A romanizer transliterates the letters of a script into the Latin alphabet. Digraphs
+// are matched before single letters and, if accents is false, combining marks such as
+// the Greek tonos are removed first. The tables are in lower case; an upper case letter
+// is romanized as its lower case letter with the first Latin letter capitalized.
+type romanizer struct {
+	letters  map[rune]string
+	digraphs map[string]string
+	accents  bool
+}
+
+// Romanizations of the scripts that can be transliterated letter by letter, loosely
+// following the BGN/PCGN systems, and of Hangul, which is romanized algorithmically.
+var romanizers = map[string]*romanizer{
+	"Armn": {
+		letters: map[rune]string{
+			'ա': "a", 'բ': "b", 'գ': "g", 'դ': "d", 'ե': "e", 'զ': "z", 'է': "e", 'ը': "y", 'թ': "t'",
+			'ժ': "zh", 'ի': "i", 'լ': "l", 'խ': "kh", 'ծ': "ts", 'կ': "k", 'հ': "h", 'ձ': "dz", 'ղ': "gh",
+			'ճ': "ch", 'մ': "m", 'յ': "y", 'ն': "n", 'շ': "sh", 'ո': "o", 'չ': "ch'", 'պ': "p", 'ջ': "j",
+			'ռ': "r", 'ս': "s", 'վ': "v", 'տ': "t", 'ր': "r", 'ց': "ts'", 'ւ': "v", 'փ': "p'", 'ք': "k'",
+			'օ': "o", 'ֆ': "f", 'և': "ev",
+		},
+		digraphs: map[string]string{"ու": "u"},
+		accents:  true,
+	},
+	"Cyrl": {
+		letters: map[rune]string{
+			'а': "a", 'б': "b", 'в': "v", 'г': "g", 'д': "d", 'е': "e", 'ё': "yo", 'ж': "zh", 'з': "z",
+			'и': "i", 'й': "y", 'к': "k", 'л': "l", 'м': "m", 'н': "n", 'о': "o", 'п': "p", 'р': "r",
+			'с': "s", 'т': "t", 'у': "u", 'ф': "f", 'х': "kh", 'ц': "ts", 'ч': "ch", 'ш': "sh", 'щ': "shch",
+			'ъ': "", 'ы': "y", 'ь': "", 'э': "e", 'ю': "yu", 'я': "ya", 'і': "i", 'ї': "yi", 'є': "ye",
+			'ґ': "g", 'ў': "w", 'ј': "j", 'љ': "lj", 'њ': "nj", 'ћ': "ć", 'ђ': "đ", 'џ': "dž", 'ѓ': "gj",
+			'ќ': "kj", 'ѕ': "dz",
+		},
+		accents: true,
+	},
+	"Geor": {
+		letters: map[rune]string{
+			'ა': "a", 'ბ': "b", 'გ': "g", 'დ': "d", 'ე': "e", 'ვ': "v", 'ზ': "z", 'თ': "t", 'ი': "i",
+			'კ': "k'", 'ლ': "l", 'მ': "m", 'ნ': "n", 'ო': "o", 'პ': "p'", 'ჟ': "zh", 'რ': "r", 'ს': "s",
+			'ტ': "t'", 'უ': "u", 'ფ': "p", 'ქ': "k", 'ღ': "gh", 'ყ': "q'", 'შ': "sh", 'ჩ': "ch", 'ც': "ts",
+			'ძ': "dz", 'წ': "ts'", 'ჭ': "ch'", 'ხ': "kh", 'ჯ': "j", 'ჰ': "h",
+		},
+		accents: true,
+	},
+	"Grek": {
+		letters: map[rune]string{
+			'α': "a", 'β': "v", 'γ': "g", 'δ': "d", 'ε': "e", 'ζ': "z", 'η': "i", 'θ': "th", 'ι': "i",
+			'κ': "k", 'λ': "l", 'μ': "m", 'ν': "n", 'ξ': "x", 'ο': "o", 'π': "p", 'ρ': "r", 'σ': "s",
+			'ς': "s", 'τ': "t", 'υ': "y", 'φ': "f", 'χ': "ch", 'ψ': "ps", 'ω': "o",
+		},
+		digraphs: map[string]string{"ου": "ou"},
+	},
+}
+
+// Languages that romanize some letters of their script differently from the others,
+// e.g. the Ukrainian г is romanized as h rather than g.
+var romanizedLetters = map[string]map[rune]string{
+	"be": {'г': "h", 'х': "kh"},
+	"bg": {'х': "h", 'ц': "ts", 'щ': "sht", 'ъ': "a", 'й': "y"},
+	"mk": {'ж': "ž", 'ѓ': "ǵ", 'ќ': "ḱ", 'х': "h", 'ц': "c", 'ч': "č", 'ш': "š"},
+	"sr": {'ж': "ž", 'х': "h", 'ц': "c", 'ч': "č", 'ш': "š"},
+	"uk": {'г': "h", 'и': "y", 'х': "kh"},
+}
+
+// Romanize returns the greeting in the language and script (see Script) transliterated
+// into the Latin alphabet if the script can be romanized, otherwise an empty string.
+// Characters that are not in the script, such as the name of the person being greeted,
+// are kept as they are. Romanization is only an aid to pronunciation and is not
+// reversible.
+func Romanize(lang, script, text string) string {
+	var romanized string
+	switch script {
+	case "Hang", "Kore":
+		romanized = romanizeHangul(text)
+	default:
+		r, ok := romanizers[script]
+		if !ok {
+			return ""
+		}
+
+		primary, _, _ := splitLanguage(lang)
+		romanized = r.romanize(text, romanizedLetters[primary])
+	}
+
+	if romanized == text {
+		return ""
+	}
+	return romanized
+}
+
+func (r *romanizer) romanize(text string, overrides map[rune]string) string {
+	if !r.accents {
+		text = stripMarks(text)
+	}
+
+	buf := &strings.Builder{}
+	for len(text) > 0 {
+		c, size := utf8.DecodeRuneInString(text)
+		lower := unicode.ToLower(c)
+
+		// Match a digraph of the current and next letters
+		latin, ok := "", false
+		if next, nsize := utf8.DecodeRuneInString(text[size:]); nsize > 0 {
+			if latin, ok = r.digraphs[string(lower)+string(unicode.ToLower(next))]; ok {
+				size += nsize
+			}
+		}
+
+		if !ok {
+			if latin, ok = overrides[lower]; !ok {
+				latin, ok = r.letters[lower]
+			}
+		}
+
+		switch {
+		case !ok:
+			buf.WriteRune(c)
+		case unicode.IsUpper(c) && latin != "":
+			first, n := utf8.DecodeRuneInString(latin)
+			buf.WriteRune(unicode.ToUpper(first))
+			buf.WriteString(latin[n:])
+		default:
+			buf.WriteString(latin)
+		}
+		text = text[size:]
+	}
+	return buf.String()
+}
+
+// Remove the combining marks from the text, e.g. the accents of Greek vowels.
+func stripMarks(text string) string {
+	buf := &strings.Builder{}
+	for _, c := range norm.NFD.String(text) {
+		if !unicode.Is(unicode.Mn, c) {
+			buf.WriteRune(c)
+		}
+	}
+	return norm.NFC.String(buf.String())
+}
+
+// The initial consonants, vowels and final consonants of precomposed Hangul syllables
+// in the Revised Romanization of Korean.
+var (
+	hangulInitials = []string{"g", "kk", "n", "d", "tt", "r", "m", "b", "pp", "s", "ss", "", "j", "jj", "ch", "k", "t", "p", "h"}
+	hangulVowels   = []string{"a", "ae", "ya", "yae", "eo", "e", "yeo", "ye", "o", "wa", "wae", "oe", "yo", "u", "wo", "we", "wi", "yu", "eu", "ui", "i"}
+	hangulFinals   = []string{"", "k", "k", "k", "n", "n", "n", "t", "l", "k", "m", "l", "l", "l", "p", "l", "m", "p", "p", "t", "t", "ng", "t", "t", "k", "t", "p", "t"}
+)
+
+const (
+	hangulBase      = 0xAC00
+	hangulSyllables = 11172
+)
+
+// Romanize Hangul by decomposing each precomposed syllable into its letters.
+func romanizeHangul(text string) string {
+	buf := &strings.Builder{}
+	for _, c := range text {
+		if c < hangulBase || c >= hangulBase+hangulSyllables {
+			buf.WriteRune(c)
+			continue
+		}
+
+		n := int(c - hangulBase)
+		buf.WriteString(hangulInitials[n/(21*28)])
+		buf.WriteString(hangulVowels[n/28%21])
+		buf.WriteString(hangulFinals[n%28])
+	}
+	return buf.String()
+}
@@ -0,0 +1,175 @@
+package hello
+
+import (
+	"strings"
+	"unicode"
+)
+
+// Direction is the direction in which the text of a script is written.
+type Direction string
+
+const (
+	LeftToRight Direction = "ltr"
+	RightToLeft Direction = "rtl"
+)
+
+// The likely ISO 15924 script of each language, following the CLDR likely subtags. The
+// Chinese script is the simplified Han of mainland China; regions that use a different
+// script than the language usually does are in regionScripts.
+var likelyScripts = map[string]string{
+	"aa": "Latn", "ab": "Cyrl", "af": "Latn", "ak": "Latn", "am": "Ethi", "an": "Latn", "ar": "Arab",
+	"as": "Beng", "av": "Cyrl", "ay": "Latn", "az": "Latn", "ba": "Cyrl", "be": "Cyrl", "bg": "Cyrl",
+	"bi": "Latn", "bm": "Latn", "bn": "Beng", "bo": "Tibt", "br": "Latn", "bs": "Latn", "ca": "Latn",
+	"ce": "Cyrl", "ch": "Latn", "ckb": "Arab", "co": "Latn", "cr": "Cans", "cs": "Latn", "cu": "Cyrl",
+	"cv": "Cyrl", "cy": "Latn", "da": "Latn", "de": "Latn", "dv": "Thaa", "dz": "Tibt", "ee": "Latn",
+	"el": "Grek", "en": "Latn", "eo": "Latn", "es": "Latn", "et": "Latn", "eu": "Latn", "fa": "Arab",
+	"ff": "Latn", "fi": "Latn", "fj": "Latn", "fo": "Latn", "fr": "Latn", "fy": "Latn", "ga": "Latn",
+	"gd": "Latn", "gl": "Latn", "gn": "Latn", "gu": "Gujr", "gv": "Latn", "ha": "Latn", "he": "Hebr",
+	"hi": "Deva", "ho": "Latn", "ht": "Latn", "hu": "Latn", "hy": "Armn", "hz": "Latn", "ia": "Latn",
+	"id": "Latn", "ie": "Latn", "ig": "Latn", "ii": "Yiii", "ik": "Latn", "io": "Latn", "is": "Latn",
+	"it": "Latn", "iu": "Cans", "ja": "Jpan", "jv": "Latn", "ka": "Geor", "kg": "Latn", "ki": "Latn",
+	"kj": "Latn", "kk": "Cyrl", "kl": "Latn", "km": "Khmr", "kn": "Knda", "ko": "Kore", "kr": "Latn",
+	"ks": "Arab", "ku": "Latn", "kv": "Cyrl", "kw": "Latn", "ky": "Cyrl", "la": "Latn", "lb": "Latn",
+	"lg": "Latn", "li": "Latn", "ln": "Latn", "lo": "Laoo", "lt": "Latn", "lu": "Latn", "lv": "Latn",
+	"mg": "Latn", "mh": "Latn", "mi": "Latn", "mk": "Cyrl", "ml": "Mlym", "mn": "Cyrl", "mr": "Deva",
+	"ms": "Latn", "mt": "Latn", "my": "Mymr", "na": "Latn", "nb": "Latn", "nd": "Latn", "ne": "Deva",
+	"ng": "Latn", "nl": "Latn", "nn": "Latn", "nqo": "Nkoo", "nr": "Latn", "nv": "Latn", "ny": "Latn",
+	"oc": "Latn", "oj": "Cans", "om": "Latn", "or": "Orya", "os": "Cyrl", "pa": "Guru", "pl": "Latn",
+	"ps": "Arab", "pt": "Latn", "qu": "Latn", "rm": "Latn", "rn": "Latn", "ro": "Latn", "ru": "Cyrl",
+	"rw": "Latn", "sa": "Deva", "sc": "Latn", "sd": "Arab", "se": "Latn", "sg": "Latn", "si": "Sinh",
+	"sk": "Latn", "sl": "Latn", "sm": "Latn", "sn": "Latn", "so": "Latn", "sq": "Latn", "sr": "Cyrl",
+	"ss": "Latn", "st": "Latn", "su": "Latn", "sv": "Latn", "sw": "Latn", "syr": "Syrc", "ta": "Taml",
+	"te": "Telu", "tg": "Cyrl", "th": "Thai", "ti": "Ethi", "tk": "Latn", "tl": "Latn", "tn": "Latn",
+	"to": "Latn", "tr": "Latn", "ts": "Latn", "tt": "Cyrl", "tw": "Latn", "ty": "Latn", "ug": "Arab",
+	"uk": "Cyrl", "ur": "Arab", "uz": "Latn", "ve": "Latn", "vi": "Latn", "vo": "Latn", "wa": "Latn",
+	"wo": "Latn", "xh": "Latn", "yi": "Hebr", "yo": "Latn", "za": "Latn", "zh": "Hans", "zu": "Latn",
+}
+
+// Scripts that are used by a language in some regions but not in most others.
+var regionScripts = map[string]string{
+	"az-IR": "Arab", "mn-CN": "Mong", "pa-PK": "Arab", "sd-IN": "Deva", "uz-AF": "Arab",
+	"zh-HK": "Hant", "zh-MO": "Hant", "zh-TW": "Hant",
+}
+
+// The Unicode characters of the scripts that can be detected from the text of a greeting.
+var scriptRanges = map[string]*unicode.RangeTable{
+	"Arab": unicode.Arabic, "Armn": unicode.Armenian, "Beng": unicode.Bengali, "Cans": unicode.Canadian_Aboriginal,
+	"Cyrl": unicode.Cyrillic, "Deva": unicode.Devanagari, "Ethi": unicode.Ethiopic, "Geor": unicode.Georgian,
+	"Grek": unicode.Greek, "Gujr": unicode.Gujarati, "Guru": unicode.Gurmukhi, "Hang": unicode.Hangul,
+	"Hani": unicode.Han, "Hebr": unicode.Hebrew, "Hira": unicode.Hiragana, "Kana": unicode.Katakana,
+	"Khmr": unicode.Khmer, "Knda": unicode.Kannada, "Laoo": unicode.Lao, "Latn": unicode.Latin,
+	"Mlym": unicode.Malayalam, "Mong": unicode.Mongolian, "Mymr": unicode.Myanmar, "Nkoo": unicode.Nko,
+	"Orya": unicode.Oriya, "Sinh": unicode.Sinhala, "Syrc": unicode.Syriac, "Taml": unicode.Tamil,
+	"Telu": unicode.Telugu, "Thaa": unicode.Thaana, "Thai": unicode.Thai, "Tibt": unicode.Tibetan,
+	"Yiii": unicode.Yi,
+}
+
+// Scripts that are written with the characters of several other scripts, e.g. Japanese
+// is written with Han, Hiragana and Katakana.
+var compositeScripts = map[string][]string{
+	"Hans": {"Hani"},
+	"Hant": {"Hani"},
+	"Jpan": {"Hani", "Hira", "Kana"},
+	"Kore": {"Hang", "Hani"},
+}
+
+// Scripts that are written from right to left.
+var rtlScripts = map[string]bool{
+	"Adlm": true, "Arab": true, "Hebr": true, "Mand": true, "Nkoo": true,
+	"Rohg": true, "Samr": true, "Syrc": true, "Thaa": true, "Yezi": true,
+}
+
+// Script returns the ISO 15924 code of the script of a greeting in the language, e.g.
+// "Cyrl" for Russian. A script subtag in the language code, as in "sr-Latn", is always
+// used; otherwise the likely script of the language (and region) is used unless the
+// greeting is written in another script, e.g. a Serbian greeting in the Latin alphabet.
+// Returns an empty string if the script can be determined from neither.
+func Script(lang, text string) string {
+	primary, region, script := splitLanguage(lang)
+	if script != "" {
+		return script
+	}
+
+	likely, ok := regionScripts[primary+"-"+region]
+	if !ok {
+		likely = likelyScripts[primary]
+	}
+
+	detected := detectScript(text)
+	switch {
+	case detected == "":
+		return likely
+	case likely == "" || likely == detected:
+		return detected
+	}
+
+	for _, part := range compositeScripts[likely] {
+		if part == detected {
+			return likely
+		}
+	}
+	return detected
+}
+
+// ScriptDirection returns the direction in which the script is written, or an empty
+// direction if the script is empty.
+func ScriptDirection(script string) Direction {
+	switch {
+	case script == "":
+		return ""
+	case rtlScripts[script]:
+		return RightToLeft
+	default:
+		return LeftToRight
+	}
+}
+
+// Split a language code into its lower case primary subtag, upper case region subtag
+// and title case script subtag, which are empty if they are not in the code.
+func splitLanguage(lang string) (primary, region, script string) {
+	subtags := strings.Split(lang, "-")
+	primary = strings.ToLower(subtags[0])
+	for _, subtag := range subtags[1:] {
+		switch {
+		case len(subtag) == 4 && script == "" && region == "" && isAlpha(subtag):
+			script = strings.ToUpper(subtag[:1]) + strings.ToLower(subtag[1:])
+		case (len(subtag) == 2 && isAlpha(subtag) || len(subtag) == 3 && isDigits(subtag)) && region == "":
+			region = strings.ToUpper(subtag)
+		}
+	}
+	return primary, region, script
+}
+
+// Detect the script of the first letter of the text that is in a known script.
+func detectScript(text string) string {
+	for _, r := range text {
+		if !unicode.IsLetter(r) {
+			continue
+		}
+
+		for script, table := range scriptRanges {
+			if unicode.Is(table, r) {
+				return script
+			}
+		}
+	}
+	return ""
+}
+
+func isAlpha(s string) bool {
+	for _, c := range s {
+		if (c < 'a' || c > 'z') && (c < 'A' || c > 'Z') {
+			return false
+		}
+	}
+	return true
+}
+
+func isDigits(s string) bool {
+	for _, c := range s {
+		if c < '0' || c > '9' {
+			return false
+		}
+	}
+	return true
+}
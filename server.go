@@ -9,7 +9,9 @@ import (
 	"os/signal"
 	"time"
 
+	"github.com/pdeziel/grpc-example/msgfmt"
 	"github.com/pdeziel/grpc-example/pb"
+	"github.com/pdeziel/grpc-example/validate"
 	"google.golang.org/grpc"
 	"google.golang.org/grpc/codes"
 	"google.golang.org/grpc/status"
@@ -21,23 +23,47 @@ type Server struct {
 	srv      *grpc.Server
 	messages *Messages
 	echan    chan error
+	now      func() time.Time
 }
 
-// Create a new server
+// Create a new server with the messages loaded from messages.json in the working directory
 func NewServer(opts ...grpc.ServerOption) (s *Server, err error) {
-	s = &Server{
-		echan: make(chan error),
-	}
-
 	// Load the messages from the JSON file
-	s.messages = &Messages{}
-	if err = s.messages.Load("messages.json"); err != nil {
+	messages := &Messages{}
+	if err = messages.Load("messages.json"); err != nil {
 		return nil, err
 	}
+	return NewServerWithMessages(messages, opts...), nil
+}
+
+// NewServerWithMessages creates a new server that greets from the catalog, e.g. one
+// created in memory by NewMessages for tests.
+func NewServerWithMessages(messages *Messages, opts ...grpc.ServerOption) (s *Server) {
+	s = &Server{
+		messages: messages,
+		echan:    make(chan error),
+		now:      time.Now,
+	}
+
+	// Validate all incoming requests before they reach the handlers and allow clients
+	// to send keepalive pings at the default interval.
+	limits := validate.DefaultLimits()
+	opts = append([]grpc.ServerOption{
+		grpc.KeepaliveEnforcementPolicy(DefaultEnforcementPolicy()),
+		grpc.ChainUnaryInterceptor(validate.UnaryInterceptor(limits)),
+		grpc.ChainStreamInterceptor(validate.StreamInterceptor(limits)),
+	}, opts...)
 
 	s.srv = grpc.NewServer(opts...)
 	pb.RegisterHelloServer(s.srv, s)
-	return
+	return s
+}
+
+// SetClock replaces the clock that determines the time of day of greetings and the
+// creation time of replies, e.g. to test greetings at a fixed time. It must be called
+// before the server is run.
+func (s *Server) SetClock(now func() time.Time) {
+	s.now = now
 }
 
 // Start the server
@@ -82,20 +108,15 @@ func (s *Server) Shutdown() error {
 
 // Unary RPC
 func (s *Server) SayHello(ctx context.Context, req *pb.HelloRequest) (rep *pb.HelloReply, err error) {
-	// Lookup the message from the request
-	var msg string
-	if msg, err = s.messages.Get(req.IsoLanguageCode); err != nil {
-		return nil, status.Error(codes.NotFound, err.Error())
+	var opts *greeting
+	if opts, err = s.newGreeting(req); err != nil {
+		return nil, err
 	}
 
-	// Return the message
-	rep = &pb.HelloReply{
-		Greeting:        msg,
-		IsoLanguageCode: req.IsoLanguageCode,
-		CreatedAt:       time.Now().Format(time.RFC3339),
+	if md := deprecationTrailer(req.IsoLanguageCode); md != nil {
+		grpc.SetTrailer(ctx, md)
 	}
-
-	return rep, nil
+	return s.greet(req.IsoLanguageCode, opts)
 }
 
 // Client streaming RPC
@@ -113,32 +134,45 @@ func (s *Server) SayClientStream(stream pb.Hello_SayClientStreamServer) (err err
 			return err
 		}
 
-		var msg string
-		if msg, err = s.messages.Get(req.IsoLanguageCode); err != nil {
-			return status.Error(codes.NotFound, err.Error())
+		var opts *greeting
+		if opts, err = s.newGreeting(req); err != nil {
+			return err
+		}
+
+		if md := deprecationTrailer(req.IsoLanguageCode); md != nil {
+			stream.SetTrailer(md)
+		}
+
+		var rep *pb.HelloReply
+		if rep, err = s.greetMany(req.IsoLanguageCode, opts, req.PartialSuccess); err != nil {
+			return err
 		}
 
-		reply.Greetings = append(reply.Greetings, &pb.HelloReply{
-			Greeting:        msg,
-			IsoLanguageCode: req.IsoLanguageCode,
-			CreatedAt:       time.Now().Format(time.RFC3339),
-		})
+		if rep.Error != nil {
+			reply.Failures++
+		}
+		reply.Greetings = append(reply.Greetings, rep)
 	}
 }
 
 // Server streaming RPC
 func (s *Server) SayServerStream(req *pb.HelloManyRequest, stream pb.Hello_SayServerStreamServer) (err error) {
+	var opts *greeting
+	if opts, err = s.newGreeting(req); err != nil {
+		return err
+	}
+
 	for _, iso := range req.IsoLanguageCodes {
-		var msg string
-		if msg, err = s.messages.Get(iso); err != nil {
-			return status.Error(codes.NotFound, err.Error())
+		if md := deprecationTrailer(iso); md != nil {
+			stream.SetTrailer(md)
 		}
 
-		if err = stream.Send(&pb.HelloReply{
-			Greeting:        msg,
-			IsoLanguageCode: iso,
-			CreatedAt:       time.Now().Format(time.RFC3339),
-		}); err != nil {
+		var rep *pb.HelloReply
+		if rep, err = s.greetMany(iso, opts, req.PartialSuccess); err != nil {
+			return err
+		}
+
+		if err = stream.Send(rep); err != nil {
 			return err
 		}
 	}
@@ -158,17 +192,163 @@ func (s *Server) SayBidirectional(stream pb.Hello_SayBidirectionalServer) (err e
 			return err
 		}
 
-		var msg string
-		if msg, err = s.messages.Get(req.IsoLanguageCode); err != nil {
-			return status.Error(codes.NotFound, err.Error())
+		var opts *greeting
+		if opts, err = s.newGreeting(req); err != nil {
+			return err
 		}
 
-		if err = stream.Send(&pb.HelloReply{
-			Greeting:        msg,
-			IsoLanguageCode: req.IsoLanguageCode,
-			CreatedAt:       time.Now().Format(time.RFC3339),
-		}); err != nil {
+		if md := deprecationTrailer(req.IsoLanguageCode); md != nil {
+			stream.SetTrailer(md)
+		}
+
+		var rep *pb.HelloReply
+		if rep, err = s.greetMany(req.IsoLanguageCode, opts, req.PartialSuccess); err != nil {
+			return err
+		}
+
+		if err = stream.Send(rep); err != nil {
 			return err
 		}
 	}
 }
+
+// List the languages in the catalog
+func (s *Server) ListLanguages(ctx context.Context, req *pb.ListLanguagesRequest) (*pb.Languages, error) {
+	return &pb.Languages{IsoLanguageCodes: s.messages.Languages()}, nil
+}
+
+// Select the variant of the greeting for the language code, render its template with
+// the parameters and create a reply with the canonical language code and the script,
+// direction and romanization of the greeting. Returns a NotFound status error with
+// details if the language is not in the catalog or an InvalidArgument status error if
+// the template requires parameters that were not specified.
+func (s *Server) greet(iso string, opts *greeting) (_ *pb.HelloReply, err error) {
+	code, _ := Canonical(iso)
+
+	var tmpl *msgfmt.Message
+	if tmpl, err = s.messages.template(iso, opts.query); err != nil {
+		if errors.Is(err, ErrLanguageNotFound) {
+			return nil, languageNotFound(iso)
+		}
+		return nil, status.Error(codes.Internal, err.Error())
+	}
+
+	// The script is determined from the text of the greeting without the parameters
+	// so that e.g. a Latin name does not change the script of a Russian greeting.
+	var script string
+	if script, err = greetingScript(code, tmpl); err != nil {
+		return nil, status.Error(codes.Internal, err.Error())
+	}
+
+	var msg string
+	if msg, err = tmpl.Format(opts.params); err != nil {
+		var missing *msgfmt.MissingError
+		if errors.As(err, &missing) {
+			return nil, missingParams(iso, missing.Params)
+		}
+		return nil, status.Error(codes.Internal, err.Error())
+	}
+
+	return &pb.HelloReply{
+		Greeting:        msg,
+		IsoLanguageCode: code,
+		Script:          script,
+		Direction:       string(ScriptDirection(script)),
+		Romanization:    Romanize(code, script, msg),
+		CreatedAt:       s.now().Format(time.RFC3339),
+	}, nil
+}
+
+// Determine the script of a greeting from its template formatted with every parameter
+// empty.
+func greetingScript(lang string, tmpl *msgfmt.Message) (_ string, err error) {
+	blank := make(map[string]string)
+	for _, param := range tmpl.Params() {
+		blank[param] = ""
+	}
+
+	var text string
+	if text, err = tmpl.Format(blank); err != nil {
+		return "", err
+	}
+	return Script(lang, text), nil
+}
+
+// Greet from a streaming RPC; in partial success mode an unknown language or missing
+// template parameters produce a reply with the error set rather than an error that
+// would abort the stream.
+func (s *Server) greetMany(iso string, opts *greeting, partial bool) (rep *pb.HelloReply, err error) {
+	if rep, err = s.greet(iso, opts); err != nil {
+		if code := status.Code(err); partial && (code == codes.NotFound || code == codes.InvalidArgument) {
+			st := status.Convert(err)
+			code, _ := Canonical(iso)
+			return &pb.HelloReply{
+				IsoLanguageCode: code,
+				Error: &pb.HelloError{
+					Code:    uint32(st.Code()),
+					Message: st.Message(),
+				},
+				CreatedAt: s.now().Format(time.RFC3339),
+			}, nil
+		}
+		return nil, err
+	}
+	return rep, nil
+}
+
+// The fields shared by HelloRequest and HelloManyRequest that determine the greeting.
+type greetingRequest interface {
+	GetName() string
+	GetParams() map[string]string
+	GetRegister() string
+	GetTimezone() string
+	GetContext() string
+	GetSelection() string
+	GetUserId() string
+}
+
+// The variant query and template parameters for the greetings of a request.
+type greeting struct {
+	query  Query
+	params map[string]string
+}
+
+// Create the greeting options from a request. The time of day is determined from the
+// current time of the server clock in the timezone of the request; without a timezone
+// the request has no preference for the time of day. Returns an InvalidArgument status
+// error if the timezone is unknown.
+func (s *Server) newGreeting(req greetingRequest) (_ *greeting, err error) {
+	opts := &greeting{
+		query: Query{
+			Register:  req.GetRegister(),
+			Context:   req.GetContext(),
+			Selection: Selection(req.GetSelection()),
+			UserID:    req.GetUserId(),
+		},
+		params: templateParams(req.GetName(), req.GetParams()),
+	}
+
+	if tz := req.GetTimezone(); tz != "" {
+		var loc *time.Location
+		if loc, err = time.LoadLocation(tz); err != nil {
+			return nil, status.Errorf(codes.InvalidArgument, "unknown timezone %q", tz)
+		}
+		opts.query.TimeOfDay = TimeOfDay(s.now().In(loc))
+	}
+	return opts, nil
+}
+
+// The parameters for greeting templates are the request params with the name of the
+// person being greeted, if specified, as the name parameter.
+func templateParams(name string, params map[string]string) map[string]string {
+	if name == "" {
+		return params
+	}
+
+	merged := make(map[string]string, len(params)+1)
+	for key, val := range params {
+		merged[key] = val
+	}
+	merged["name"] = name
+	return merged
+}
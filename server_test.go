@@ -4,14 +4,23 @@ import (
 	"context"
 	"errors"
 	"io"
+	"strings"
 	"testing"
+	"time"
 
 	hello "github.com/pdeziel/grpc-example"
+	"github.com/pdeziel/grpc-example/catalog"
+	"github.com/pdeziel/grpc-example/hellotest"
 	"github.com/pdeziel/grpc-example/mock"
 	"github.com/pdeziel/grpc-example/pb"
+	"github.com/stretchr/testify/require"
 	"github.com/stretchr/testify/suite"
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
 	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
 	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
 )
 
 type serverTestSuite struct {
@@ -63,6 +72,70 @@ func (s *serverTestSuite) TestSayHello() {
 	require.Equal("Bonjour", rep.Greeting, "unexpected greeting")
 }
 
+func (s *serverTestSuite) TestSayHelloNotFound() {
+	require := s.Require()
+
+	// Create a client connection to the server
+	client := s.initClient(context.Background())
+
+	// Request a language that is not in the catalog
+	_, err := client.SayHello(context.Background(), &pb.HelloRequest{IsoLanguageCode: "xx"})
+	require.Error(err, "expected an error for an unknown language")
+
+	st, ok := status.FromError(err)
+	require.True(ok, "expected a status error")
+	require.Equal(codes.NotFound, st.Code())
+
+	var resource *errdetails.ResourceInfo
+	var badRequest *errdetails.BadRequest
+	for _, detail := range st.Details() {
+		switch d := detail.(type) {
+		case *errdetails.ResourceInfo:
+			resource = d
+		case *errdetails.BadRequest:
+			badRequest = d
+		}
+	}
+
+	require.NotNil(resource, "expected resource info in the error details")
+	require.Equal("language", resource.ResourceType)
+	require.Equal("xx", resource.ResourceName)
+	require.NotNil(badRequest, "expected a bad request in the error details")
+	require.Len(badRequest.FieldViolations, 1)
+	require.Equal("iso_language_code", badRequest.FieldViolations[0].Field)
+}
+
+func (s *serverTestSuite) TestInvalidRequests() {
+	require := s.Require()
+
+	// Create a client connection to the server
+	client := s.initClient(context.Background())
+
+	// Unary requests are validated
+	_, err := client.SayHello(context.Background(), &pb.HelloRequest{IsoLanguageCode: "not a language"})
+	require.Equal(codes.InvalidArgument, status.Code(err), "expected invalid argument")
+
+	// Server stream requests are validated
+	sstream, err := client.SayServerStream(context.Background(), &pb.HelloManyRequest{
+		IsoLanguageCodes: []string{"en", ""},
+	})
+	require.NoError(err, "could not call the service")
+	_, err = sstream.Recv()
+	require.Equal(codes.InvalidArgument, status.Code(err), "expected invalid argument")
+
+	// Every message on a client stream is validated
+	stream, err := client.SayBidirectional(context.Background())
+	require.NoError(err, "could not call the service")
+	require.NoError(stream.Send(&pb.HelloRequest{IsoLanguageCode: "en"}))
+	rep, err := stream.Recv()
+	require.NoError(err, "could not receive a message")
+	require.Equal("Hello", rep.Greeting)
+
+	require.NoError(stream.Send(&pb.HelloRequest{IsoLanguageCode: strings.Repeat("x", 4096)}))
+	_, err = stream.Recv()
+	require.Equal(codes.InvalidArgument, status.Code(err), "expected invalid argument")
+}
+
 func (s *serverTestSuite) TestSayClientStream() {
 	require := s.Require()
 
@@ -123,6 +196,67 @@ func (s *serverTestSuite) TestSayServerStream() {
 	require.Equal(expected, actual, "unexpected greetings")
 }
 
+func (s *serverTestSuite) TestPartialSuccess() {
+	require := s.Require()
+
+	// Create a client connection to the server
+	client := s.initClient(context.Background())
+
+	// Without partial success the stream is aborted on the unknown language
+	stream, err := client.SayServerStream(context.Background(), &pb.HelloManyRequest{
+		IsoLanguageCodes: []string{"en", "xx", "fr"},
+	})
+	require.NoError(err, "could not call the service")
+
+	rep, err := stream.Recv()
+	require.NoError(err, "could not receive a message")
+	require.Equal("Hello", rep.Greeting)
+
+	_, err = stream.Recv()
+	require.Equal(codes.NotFound, status.Code(err), "expected the stream to be aborted")
+
+	// With partial success the unknown language produces an error reply
+	stream, err = client.SayServerStream(context.Background(), &pb.HelloManyRequest{
+		IsoLanguageCodes: []string{"en", "xx", "fr"},
+		PartialSuccess:   true,
+	})
+	require.NoError(err, "could not call the service")
+
+	actual := make([]*pb.HelloReply, 0, 3)
+	for {
+		if rep, err = stream.Recv(); err != nil {
+			require.ErrorIs(err, io.EOF, "expected the stream to end normally")
+			break
+		}
+		actual = append(actual, rep)
+	}
+
+	require.Len(actual, 3)
+	require.Equal("Hello", actual[0].Greeting)
+	require.Nil(actual[0].Error)
+	require.Empty(actual[1].Greeting)
+	require.Equal("xx", actual[1].IsoLanguageCode)
+	require.NotNil(actual[1].Error)
+	require.Equal(uint32(codes.NotFound), actual[1].Error.Code)
+	require.Equal("Bonjour", actual[2].Greeting)
+
+	// Client streams count the failures in the reply
+	cstream, err := client.SayClientStream(context.Background())
+	require.NoError(err, "could not call the service")
+	for _, lang := range []string{"xx", "es", "yy"} {
+		require.NoError(cstream.Send(&pb.HelloRequest{
+			IsoLanguageCode: lang,
+			PartialSuccess:  true,
+		}), "could not send a message")
+	}
+
+	many, err := cstream.CloseAndRecv()
+	require.NoError(err, "could not close the stream")
+	require.Len(many.Greetings, 3)
+	require.Equal(uint32(2), many.Failures)
+	require.Equal("Hola", many.Greetings[1].Greeting)
+}
+
 func (s *serverTestSuite) TestSayBidirectional() {
 	require := s.Require()
 
@@ -160,3 +294,328 @@ func (s *serverTestSuite) TestSayBidirectional() {
 
 	require.Equal(expected, actual, "unexpected greetings")
 }
+
+func (s *serverTestSuite) TestListLanguages() {
+	require := s.Require()
+	client := s.initClient(context.Background())
+
+	rep, err := client.ListLanguages(context.Background(), &pb.ListLanguagesRequest{})
+	require.NoError(err, "could not call the service")
+	require.Contains(rep.IsoLanguageCodes, "en")
+	require.Contains(rep.IsoLanguageCodes, "fr")
+	require.IsIncreasing(rep.IsoLanguageCodes, "expected the languages to be sorted")
+}
+
+func TestTemplates(t *testing.T) {
+	srv := hellotest.NewServer(t, hellotest.WithMessages(map[string]string{
+		"en": "Hello, {name}!",
+		"fr": "{gender, select, female {Chère {name}} male {Cher {name}} other {Bonjour {name}}}",
+		"de": "Hallo",
+		"xx": "Broken {template",
+	}))
+	ctx := context.Background()
+
+	rep, err := srv.Client.Unary(ctx, "en", hello.WithName("Ada"))
+	require.NoError(t, err)
+	require.Equal(t, "Hello, Ada!", rep.Greeting)
+
+	// The name field takes precedence over a name in params
+	rep, err = srv.Client.Unary(ctx, "fr", hello.WithName("Ada"), hello.WithParams(map[string]string{"gender": "female", "name": "Bob"}))
+	require.NoError(t, err)
+	require.Equal(t, "Chère Ada", rep.Greeting)
+
+	rep, err = srv.Client.Unary(ctx, "de", hello.WithName("Ada"))
+	require.NoError(t, err)
+	require.Equal(t, "Hallo", rep.Greeting, "parameters should be ignored by fixed greetings")
+
+	// Missing parameters are an invalid argument with the language and field details
+	_, err = srv.Client.Unary(ctx, "fr", hello.WithParams(map[string]string{"gender": "male"}))
+	require.ErrorIs(t, err, hello.ErrInvalidRequest)
+
+	var herr *hello.Error
+	require.True(t, errors.As(err, &herr))
+	require.Equal(t, "fr", herr.Language)
+	require.Equal(t, `the greeting for "fr" requires the parameters: name`, herr.Message)
+
+	st, _ := status.FromError(err)
+	var fields []string
+	for _, detail := range st.Details() {
+		if br, ok := detail.(*errdetails.BadRequest); ok {
+			for _, v := range br.FieldViolations {
+				fields = append(fields, v.Field)
+			}
+		}
+	}
+	require.Equal(t, []string{"name"}, fields)
+
+	// Invalid templates are flagged when the catalog is loaded and are not served
+	_, err = srv.Client.Unary(ctx, "xx")
+	require.Equal(t, codes.NotFound, status.Code(err))
+
+	// Streams use the same parameters for every language and can report missing
+	// parameters for individual languages in partial success mode
+	replies, err := srv.Client.ServerStream(ctx, []string{"de", "en", "fr"}, hello.WithParams(map[string]string{"gender": "female"}), hello.PartialSuccess())
+	require.NoError(t, err)
+	defer replies.Close()
+
+	var greetings []string
+	for replies.Next() {
+		greetings = append(greetings, replies.Reply().Greeting)
+	}
+	require.NoError(t, replies.Err())
+	require.Equal(t, []string{"Hallo", "", ""}, greetings)
+	require.Len(t, replies.Failures(), 2)
+	require.ErrorIs(t, replies.Failures()[0], hello.ErrInvalidRequest)
+
+	session, err := srv.Client.Bidirectional(ctx, hello.WithName("Zoë"))
+	require.NoError(t, err)
+	defer session.Close()
+
+	require.NoError(t, session.Send("fr"))
+	rep, err = session.Recv()
+	require.NoError(t, err)
+	require.Equal(t, "Bonjour Zoë", rep.Greeting)
+}
+
+func TestVariants(t *testing.T) {
+	// The server clock is fixed at 09:30 in Tokyo, 01:30 in London and 20:30 the day
+	// before in New York
+	now := time.Date(2023, 6, 1, 0, 30, 0, 0, time.UTC)
+	clock := func() time.Time { return now }
+
+	srv := hellotest.NewServer(t, hellotest.WithClock(clock), hellotest.WithCatalog(catalog.Catalog{
+		"de": {
+			{Greeting: "Hallo, {name}"},
+			{Greeting: "Guten Tag, {name}", Register: catalog.Formal},
+			{Greeting: "Liebe Grüße", Context: "email"},
+		},
+		"en": {
+			{Greeting: "Hello"},
+			{Greeting: "Good morning", TimeOfDay: catalog.Morning},
+			{Greeting: "Good afternoon", TimeOfDay: catalog.Afternoon},
+			{Greeting: "Good evening", TimeOfDay: catalog.Evening},
+			{Greeting: "Good night", TimeOfDay: catalog.Night},
+		},
+	}))
+	ctx := context.Background()
+
+	rep, err := srv.Client.Unary(ctx, "de", hello.WithName("Ada"))
+	require.NoError(t, err)
+	require.Equal(t, "Hallo, Ada", rep.Greeting)
+
+	rep, err = srv.Client.Unary(ctx, "de", hello.WithName("Ada"), hello.WithRegister(catalog.Formal))
+	require.NoError(t, err)
+	require.Equal(t, "Guten Tag, Ada", rep.Greeting)
+
+	rep, err = srv.Client.Unary(ctx, "de", hello.WithRegister(catalog.Formal), hello.WithContext("email"))
+	require.NoError(t, err)
+	require.Equal(t, "Liebe Grüße", rep.Greeting)
+
+	// Without a timezone the untagged greeting is used, with one a variant for the time
+	// of day in that timezone is selected
+	rep, err = srv.Client.Unary(ctx, "en")
+	require.NoError(t, err)
+	require.Equal(t, "Hello", rep.Greeting)
+
+	for tz, expected := range map[string]string{
+		"Asia/Tokyo":       "Good morning",
+		"Europe/London":    "Good night",
+		"America/New_York": "Good evening",
+	} {
+		rep, err = srv.Client.Unary(ctx, "en", hello.WithTimezone(tz))
+		require.NoError(t, err)
+		require.Equal(t, expected, rep.Greeting, "wrong greeting in %s", tz)
+		require.Equal(t, "2023-06-01T00:30:00Z", rep.CreatedAt)
+	}
+
+	_, err = srv.Client.Unary(ctx, "en", hello.WithTimezone("Mars/Olympus_Mons"))
+	require.ErrorIs(t, err, hello.ErrInvalidRequest)
+
+	// The server rejects invalid selections even if the client does not validate them
+	_, err = srv.Conn(t).SayHello(ctx, &pb.HelloRequest{IsoLanguageCode: "de", Register: "polite"})
+	require.Equal(t, codes.InvalidArgument, status.Code(err))
+
+	// Streams select the same variants for every language
+	replies, err := srv.Client.ServerStream(ctx, []string{"de", "en"}, hello.WithName("Ada"), hello.WithRegister(catalog.Formal))
+	require.NoError(t, err)
+	defer replies.Close()
+
+	var greetings []string
+	for replies.Next() {
+		greetings = append(greetings, replies.Reply().Greeting)
+	}
+	require.NoError(t, replies.Err())
+	require.Equal(t, []string{"Guten Tag, Ada", "Hello"}, greetings)
+
+	session, err := srv.Client.Bidirectional(ctx, hello.WithContext("email"))
+	require.NoError(t, err)
+	defer session.Close()
+
+	require.NoError(t, session.Send("de"))
+	rep, err = session.Recv()
+	require.NoError(t, err)
+	require.Equal(t, "Liebe Grüße", rep.Greeting)
+}
+
+func TestSelection(t *testing.T) {
+	onboarding := catalog.Catalog{
+		"en": {{Greeting: "Welcome"}, {Greeting: "Hi there"}, {Greeting: "Hey"}},
+		"fr": {{Greeting: "Bienvenue"}, {Greeting: "Salut", Weight: 3}},
+	}
+	srv := hellotest.NewServer(t, hellotest.WithCatalog(onboarding), hellotest.WithSeed(42))
+	ctx := context.Background()
+
+	// Round-robin rotates on every request, including each language of a stream
+	var greetings []string
+	for i := 0; i < 2; i++ {
+		rep, err := srv.Client.Unary(ctx, "en", hello.WithSelection(hello.SelectRoundRobin))
+		require.NoError(t, err)
+		greetings = append(greetings, rep.Greeting)
+	}
+
+	replies, err := srv.Client.ServerStream(ctx, []string{"en", "en", "fr"}, hello.WithSelection(hello.SelectRoundRobin))
+	require.NoError(t, err)
+	defer replies.Close()
+
+	for replies.Next() {
+		greetings = append(greetings, replies.Reply().Greeting)
+	}
+	require.NoError(t, replies.Err())
+	require.Equal(t, []string{"Welcome", "Hi there", "Hey", "Welcome", "Bienvenue"}, greetings)
+
+	// Deterministic selection gives a user the same greeting from every RPC
+	opts := []hello.StreamOption{hello.WithSelection(hello.SelectDeterministic), hello.WithUserID("ada")}
+	rep, err := srv.Client.Unary(ctx, "fr", opts...)
+	require.NoError(t, err)
+	expected := rep.Greeting
+
+	session, err := srv.Client.Bidirectional(ctx, opts...)
+	require.NoError(t, err)
+	defer session.Close()
+
+	langs := make(chan string, 2)
+	langs <- "fr"
+	langs <- "fr"
+	close(langs)
+
+	collected, err := srv.Client.ClientStream(ctx, langs, opts...)
+	require.NoError(t, err)
+	defer collected.Close()
+
+	for collected.Next() {
+		require.Equal(t, expected, collected.Reply().Greeting)
+	}
+	require.NoError(t, collected.Err())
+
+	for i := 0; i < 3; i++ {
+		require.NoError(t, session.Send("fr"))
+		rep, err = session.Recv()
+		require.NoError(t, err)
+		require.Equal(t, expected, rep.Greeting)
+	}
+
+	// Random selection is reproducible on a server with the same seed
+	random := func(srv *hellotest.Server) (greetings []string) {
+		for i := 0; i < 20; i++ {
+			rep, err := srv.Client.Unary(ctx, "fr", hello.WithSelection(hello.SelectRandom))
+			require.NoError(t, err)
+			greetings = append(greetings, rep.Greeting)
+		}
+		return greetings
+	}
+
+	greetings = random(srv)
+	require.Equal(t, greetings, random(hellotest.NewServer(t, hellotest.WithCatalog(onboarding), hellotest.WithSeed(42))))
+	require.Subset(t, greetings, []string{"Bienvenue", "Salut"})
+
+	// Unknown strategies are rejected
+	_, err = srv.Client.Unary(ctx, "en", hello.WithSelection("shuffle"))
+	require.ErrorIs(t, err, hello.ErrInvalidRequest)
+}
+
+func TestDeprecatedCodes(t *testing.T) {
+	srv := hellotest.NewServer(t, hellotest.WithMessages(map[string]string{"he": "שלום", "nb": "Hei", "id": "Halo"}))
+	client := srv.Conn(t)
+	ctx := context.Background()
+
+	// Deprecated codes are answered with the canonical code and signaled in the trailer
+	var trailer metadata.MD
+	rep, err := client.SayHello(ctx, &pb.HelloRequest{IsoLanguageCode: "iw"}, grpc.Trailer(&trailer))
+	require.NoError(t, err)
+	require.Equal(t, "שלום", rep.Greeting)
+	require.Equal(t, "he", rep.IsoLanguageCode)
+	require.Equal(t, map[string]string{"iw": "he"}, hello.Deprecations(trailer))
+
+	// Alternative codes are canonicalized without a deprecation
+	trailer = nil
+	rep, err = client.SayHello(ctx, &pb.HelloRequest{IsoLanguageCode: "no"}, grpc.Trailer(&trailer))
+	require.NoError(t, err)
+	require.Equal(t, "nb", rep.IsoLanguageCode)
+	require.Empty(t, hello.Deprecations(trailer))
+
+	// Streams collect the deprecations of every request
+	stream, err := client.SayServerStream(ctx, &pb.HelloManyRequest{IsoLanguageCodes: []string{"iw", "heb", "in", "xx", "ji"}, PartialSuccess: true})
+	require.NoError(t, err)
+
+	var langs []string
+	for {
+		rep, err := stream.Recv()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		require.NoError(t, err)
+		langs = append(langs, rep.IsoLanguageCode)
+	}
+	// Languages that are not found are also answered with the canonical code
+	require.Equal(t, []string{"he", "he", "id", "xx", "yi"}, langs)
+	require.Equal(t, map[string]string{"iw": "he", "in": "id", "ji": "yi"}, hello.Deprecations(stream.Trailer()))
+
+	languages, err := client.ListLanguages(ctx, &pb.ListLanguagesRequest{})
+	require.NoError(t, err)
+	require.Equal(t, []string{"he", "id", "nb"}, languages.IsoLanguageCodes)
+}
+
+func TestWriting(t *testing.T) {
+	srv := hellotest.NewServer(t, hellotest.WithMessages(map[string]string{
+		"ar": "مرحبا {name}",
+		"en": "Hello",
+		"fr": "Cafe\u0301",
+		"ru": "{name}, привет",
+	}))
+	client := srv.Conn(t)
+	ctx := context.Background()
+
+	// Right-to-left greetings have their direction set but cannot be romanized
+	rep, err := client.SayHello(ctx, &pb.HelloRequest{IsoLanguageCode: "ar", Name: "Sami"})
+	require.NoError(t, err)
+	require.Equal(t, "Arab", rep.Script)
+	require.Equal(t, "rtl", rep.Direction)
+	require.Empty(t, rep.Romanization)
+
+	rep, err = client.SayHello(ctx, &pb.HelloRequest{IsoLanguageCode: "ru", Name: "Alice"})
+	require.NoError(t, err)
+	require.Equal(t, "Cyrl", rep.Script)
+	require.Equal(t, "ltr", rep.Direction)
+	require.Equal(t, "Alice, privet", rep.Romanization)
+
+	rep, err = client.SayHello(ctx, &pb.HelloRequest{IsoLanguageCode: "en"})
+	require.NoError(t, err)
+	require.Equal(t, "Latn", rep.Script)
+	require.Equal(t, "ltr", rep.Direction)
+	require.Empty(t, rep.Romanization)
+
+	// Greetings are normalized to NFC when the catalog is loaded
+	rep, err = client.SayHello(ctx, &pb.HelloRequest{IsoLanguageCode: "fr"})
+	require.NoError(t, err)
+	require.Equal(t, "Caf\u00e9", rep.Greeting)
+
+	// Replies with errors in partial success mode describe no greeting
+	stream, err := client.SayServerStream(ctx, &pb.HelloManyRequest{IsoLanguageCodes: []string{"xx"}, PartialSuccess: true})
+	require.NoError(t, err)
+
+	rep, err = stream.Recv()
+	require.NoError(t, err)
+	require.NotNil(t, rep.Error)
+	require.Empty(t, rep.Script)
+	require.Empty(t, rep.Direction)
+}
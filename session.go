@@ -0,0 +1,193 @@
+package hello
+
+import (
+	"context"
+	"errors"
+	"io"
+	"sync"
+
+	"github.com/pdeziel/grpc-example/pb"
+	"github.com/pdeziel/grpc-example/validate"
+)
+
+// ErrSessionClosed is returned when sending on a session that has already been closed
+// either by the client or by the server. If the server closed the session the reason
+// is available from Session.Err.
+var ErrSessionClosed = errors.New("bidirectional session is closed")
+
+// Session is a bidirectional greeting stream. Language codes can be sent from one
+// go routine while replies are received from another; Send and CloseSend are also safe
+// to call concurrently with each other. Replies are delivered on a channel that is
+// closed when the stream ends for any reason, after which Err reports why.
+type Session struct {
+	stream  pb.Hello_SayBidirectionalClient
+	ctx     context.Context
+	cancel  context.CancelFunc
+	replies chan *pb.HelloReply
+	done    chan struct{}
+	opts    *streamOptions
+
+	sendmu sync.Mutex
+	closed bool
+	sent   int
+	limits validate.Limits
+
+	errmu sync.RWMutex
+	err   error
+}
+
+// Bidirectional opens a new bidirectional session with the server. The session must be
+// closed by the caller to release its resources.
+func (c *Client) Bidirectional(ctx context.Context, opts ...StreamOption) (_ *Session, err error) {
+	conf := newStreamOptions(opts)
+
+	ctx, cancel := context.WithCancel(ctx)
+
+	var stream pb.Hello_SayBidirectionalClient
+	if stream, err = c.api.SayBidirectional(ctx); err != nil {
+		cancel()
+		return nil, wrapError(err)
+	}
+
+	s := &Session{
+		stream:  stream,
+		ctx:     ctx,
+		cancel:  cancel,
+		replies: make(chan *pb.HelloReply),
+		done:    make(chan struct{}),
+		opts:    conf,
+		limits:  c.validate(),
+	}
+
+	go s.recv()
+	return s, nil
+}
+
+// Send a request for a greeting in the specified language.
+func (s *Session) Send(langCode string) (err error) {
+	s.sendmu.Lock()
+	defer s.sendmu.Unlock()
+
+	if s.closed {
+		return ErrSessionClosed
+	}
+
+	req := s.opts.request(langCode)
+
+	// Invalid requests are not sent, the session is not affected by the failure
+	if err = s.limits.Message(req); err != nil {
+		return wrapError(err)
+	}
+
+	if err = s.limits.Stream(s.sent + 1); err != nil {
+		return wrapError(err)
+	}
+
+	s.sent++
+	if err = s.stream.Send(req); err != nil {
+		s.closed = true
+
+		// An EOF means the server ended the stream, the reason is reported by the
+		// receiver go routine rather than by Send.
+		if errors.Is(err, io.EOF) {
+			return ErrSessionClosed
+		}
+
+		s.fail(err)
+		return s.Err()
+	}
+	return nil
+}
+
+// Recv blocks until the next reply is received. It returns io.EOF when the server
+// ends the stream normally or the terminal error of the session otherwise.
+func (s *Session) Recv() (*pb.HelloReply, error) {
+	if rep, ok := <-s.replies; ok {
+		return rep, nil
+	}
+
+	if err := s.Err(); err != nil {
+		return nil, err
+	}
+	return nil, io.EOF
+}
+
+// Replies returns the channel that replies are delivered on. The channel is closed
+// when the session ends. Replies and Recv consume the same channel, so callers should
+// use one or the other.
+func (s *Session) Replies() <-chan *pb.HelloReply {
+	return s.replies
+}
+
+// Done returns a channel that is closed when the session has ended.
+func (s *Session) Done() <-chan struct{} {
+	return s.done
+}
+
+// CloseSend signals to the server that no more requests will be sent. Replies to
+// requests that have already been sent can still be received.
+func (s *Session) CloseSend() error {
+	s.sendmu.Lock()
+	defer s.sendmu.Unlock()
+
+	if s.closed {
+		return nil
+	}
+
+	s.closed = true
+	return s.stream.CloseSend()
+}
+
+// Err returns the first error encountered by the session, either sending or
+// receiving. It is nil while the session is active or if it ended normally.
+func (s *Session) Err() error {
+	s.errmu.RLock()
+	defer s.errmu.RUnlock()
+	return s.err
+}
+
+// Close cancels the session and waits for the receiver to stop. Any replies that have
+// not been consumed are discarded.
+func (s *Session) Close() error {
+	s.cancel()
+	<-s.done
+	return nil
+}
+
+// Receive replies from the server until the stream ends, closing the replies channel
+// on every exit path.
+func (s *Session) recv() {
+	defer close(s.done)
+	defer close(s.replies)
+
+	for {
+		rep, err := s.stream.Recv()
+		if err != nil {
+			if !errors.Is(err, io.EOF) {
+				s.fail(err)
+			}
+			return
+		}
+
+		select {
+		case s.replies <- rep:
+		case <-s.ctx.Done():
+			s.fail(s.ctx.Err())
+			return
+		}
+	}
+}
+
+// Record the first error that ends the session and cancel the stream.
+func (s *Session) fail(err error) {
+	s.errmu.Lock()
+	if s.err == nil {
+		// Report cancellation using the context error rather than a status error
+		if ctxErr := s.ctx.Err(); ctxErr != nil {
+			err = ctxErr
+		}
+		s.err = wrapError(err)
+	}
+	s.errmu.Unlock()
+	s.cancel()
+}
@@ -0,0 +1,228 @@
+package hello
+
+import (
+	"context"
+	"errors"
+	"io"
+
+	"github.com/pdeziel/grpc-example/pb"
+)
+
+// Replies is an iterator over the greetings returned by a streaming RPC. Each call to
+// Next blocks until the next reply arrives, the stream ends, or the context is
+// cancelled. The terminal error (if any) is available from Err once Next returns
+// false. Replies should be closed when the caller is done with it to release the
+// underlying stream, it is safe to call Close before the stream is exhausted.
+//
+//	replies, err := client.ServerStream(ctx, []string{"en", "fr"})
+//	if err != nil {
+//		return err
+//	}
+//	defer replies.Close()
+//
+//	for replies.Next() {
+//		fmt.Println(replies.Reply().Greeting)
+//	}
+//	return replies.Err()
+type Replies struct {
+	ctx      context.Context
+	cancel   context.CancelFunc
+	recv     func() (*pb.HelloReply, error)
+	reply    *pb.HelloReply
+	failures []error
+	err      error
+	done     bool
+}
+
+// StreamOption configures the behavior of a streaming RPC and the requests that it
+// sends. Options that configure requests, such as WithName, also apply to Unary.
+type StreamOption func(*streamOptions)
+
+type streamOptions struct {
+	partial   bool
+	name      string
+	params    map[string]string
+	register  string
+	timezone  string
+	context   string
+	selection Selection
+	userID    string
+}
+
+func newStreamOptions(opts []StreamOption) *streamOptions {
+	conf := &streamOptions{}
+	for _, opt := range opts {
+		opt(conf)
+	}
+	return conf
+}
+
+// PartialSuccess requests that the server reply to unknown language codes with a reply
+// that has its error field set rather than aborting the stream. Use ReplyError to get
+// the error for a reply, Replies also collects these errors in Failures.
+func PartialSuccess() StreamOption {
+	return func(o *streamOptions) {
+		o.partial = true
+	}
+}
+
+// WithName sets the name of the person being greeted, which is available to greeting
+// templates as the name parameter.
+func WithName(name string) StreamOption {
+	return func(o *streamOptions) {
+		o.name = name
+	}
+}
+
+// WithParams sets the parameters used to render greeting templates. The server returns
+// an InvalidArgument error (ErrInvalidRequest) if a required parameter is missing.
+func WithParams(params map[string]string) StreamOption {
+	return func(o *streamOptions) {
+		o.params = params
+	}
+}
+
+// WithRegister requests the formal or informal variant of the greeting if the catalog
+// has one for the language.
+func WithRegister(register string) StreamOption {
+	return func(o *streamOptions) {
+		o.register = register
+	}
+}
+
+// WithTimezone sets the IANA timezone of the person being greeted, e.g. Europe/Berlin,
+// which the server uses to select a variant of the greeting for the time of day.
+func WithTimezone(timezone string) StreamOption {
+	return func(o *streamOptions) {
+		o.timezone = timezone
+	}
+}
+
+// WithContext requests the variant of the greeting tagged with the application
+// specific context, e.g. email, if the catalog has one for the language.
+func WithContext(tag string) StreamOption {
+	return func(o *streamOptions) {
+		o.context = tag
+	}
+}
+
+// WithSelection sets the strategy the server uses to choose among variants of the
+// greeting that suit the request equally well, e.g. SelectRoundRobin to rotate through
+// the greetings on each request.
+func WithSelection(selection Selection) StreamOption {
+	return func(o *streamOptions) {
+		o.selection = selection
+	}
+}
+
+// WithUserID identifies the user being greeted so that SelectDeterministic always
+// chooses the same variant of the greeting for them.
+func WithUserID(userID string) StreamOption {
+	return func(o *streamOptions) {
+		o.userID = userID
+	}
+}
+
+// Create a request for a greeting in the language with the configured options.
+func (o *streamOptions) request(langCode string) *pb.HelloRequest {
+	return &pb.HelloRequest{
+		IsoLanguageCode: langCode,
+		PartialSuccess:  o.partial,
+		Name:            o.name,
+		Params:          o.params,
+		Register:        o.register,
+		Timezone:        o.timezone,
+		Context:         o.context,
+		Selection:       string(o.selection),
+		UserId:          o.userID,
+	}
+}
+
+func newReplies(ctx context.Context, cancel context.CancelFunc, recv func() (*pb.HelloReply, error)) *Replies {
+	return &Replies{
+		ctx:    ctx,
+		cancel: cancel,
+		recv:   recv,
+	}
+}
+
+// Create an iterator over replies that have already been received, e.g. from the
+// response of a client streaming RPC.
+func sliceReplies(ctx context.Context, replies []*pb.HelloReply) *Replies {
+	ctx, cancel := context.WithCancel(ctx)
+	return newReplies(ctx, cancel, func() (*pb.HelloReply, error) {
+		if len(replies) == 0 {
+			return nil, io.EOF
+		}
+
+		rep := replies[0]
+		replies = replies[1:]
+		return rep, nil
+	})
+}
+
+// Next advances the iterator to the next reply, returning false when the stream is
+// finished, an error occurred, or the context was cancelled.
+func (r *Replies) Next() bool {
+	if r.done {
+		return false
+	}
+
+	if err := r.ctx.Err(); err != nil {
+		r.finish(err)
+		return false
+	}
+
+	var err error
+	if r.reply, err = r.recv(); err != nil {
+		switch {
+		case errors.Is(err, io.EOF):
+			err = nil
+		case r.ctx.Err() != nil:
+			// Report cancellation using the context error rather than a status error
+			err = r.ctx.Err()
+		default:
+			err = wrapError(err)
+		}
+
+		r.finish(err)
+		return false
+	}
+
+	if err = ReplyError(r.reply); err != nil {
+		r.failures = append(r.failures, err)
+	}
+	return true
+}
+
+// Reply returns the most recent reply read by Next.
+func (r *Replies) Reply() *pb.HelloReply {
+	return r.reply
+}
+
+// Failures returns the errors of the replies that have been yielded so far which
+// failed in partial success mode. Failed replies are still yielded by Next.
+func (r *Replies) Failures() []error {
+	return r.failures
+}
+
+// Err returns the terminal error of the stream, it is nil if the stream ended
+// normally. Err should only be called after Next has returned false.
+func (r *Replies) Err() error {
+	return r.err
+}
+
+// Close the iterator and cancel the underlying stream.
+func (r *Replies) Close() error {
+	r.finish(nil)
+	return nil
+}
+
+func (r *Replies) finish(err error) {
+	if !r.done {
+		r.done = true
+		r.reply = nil
+		r.err = err
+		r.cancel()
+	}
+}
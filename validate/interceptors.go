@@ -0,0 +1,51 @@
+package validate
+
+import (
+	"context"
+
+	"google.golang.org/grpc"
+	"google.golang.org/protobuf/proto"
+)
+
+// UnaryInterceptor validates the request of unary RPCs before the handler is called.
+func UnaryInterceptor(limits Limits) grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+		if msg, ok := req.(proto.Message); ok {
+			if err := limits.Message(msg); err != nil {
+				return nil, err
+			}
+		}
+		return handler(ctx, req)
+	}
+}
+
+// StreamInterceptor validates every message received on a stream and limits the number
+// of messages that a client can send on a single stream.
+func StreamInterceptor(limits Limits) grpc.StreamServerInterceptor {
+	return func(srv interface{}, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+		return handler(srv, &stream{ServerStream: ss, limits: limits})
+	}
+}
+
+// Wraps a server stream to validate incoming messages.
+type stream struct {
+	grpc.ServerStream
+	limits Limits
+	recv   int
+}
+
+func (s *stream) RecvMsg(m interface{}) (err error) {
+	if err = s.ServerStream.RecvMsg(m); err != nil {
+		return err
+	}
+
+	s.recv++
+	if err = s.limits.Stream(s.recv); err != nil {
+		return err
+	}
+
+	if msg, ok := m.(proto.Message); ok {
+		return s.limits.Message(msg)
+	}
+	return nil
+}
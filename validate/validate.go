@@ -0,0 +1,345 @@
+/*
+Package validate implements declarative validation of the Hello request messages. The
+rules for each message are described by the schema below and are enforced on the
+server by the interceptors in this package and mirrored by the client before sending.
+Violations are returned as an InvalidArgument status error with BadRequest details
+that describe each offending field.
+*/
+package validate
+
+import (
+	"fmt"
+	"regexp"
+	"sort"
+	"time"
+	"unicode"
+	"unicode/utf8"
+
+	// Embed the timezone database so that timezones are validated the same way on
+	// hosts without one installed.
+	_ "time/tzdata"
+
+	"github.com/pdeziel/grpc-example/msgfmt"
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/reflect/protoreflect"
+)
+
+// Default limits used by the server and the client.
+const (
+	DefaultMaxCodeLength     = 35
+	DefaultMaxLanguageCodes  = 100
+	DefaultMaxStreamMessages = 1000
+	DefaultMaxNameLength     = 100
+	DefaultMaxParams         = 20
+	DefaultMaxParamLength    = 256
+	DefaultMaxUserIDLength   = 128
+)
+
+// Limits configures the bounds enforced by the validation rules. A zero value for any
+// of the limits means that the limit is not enforced.
+type Limits struct {
+	MaxCodeLength     int // maximum number of characters in a language code
+	MaxLanguageCodes  int // maximum number of language codes in a list
+	MaxStreamMessages int // maximum number of messages a client may send on a stream
+	MaxNameLength     int // maximum number of characters in a name
+	MaxParams         int // maximum number of template parameters in a request
+	MaxParamLength    int // maximum number of characters in a template parameter value
+	MaxUserIDLength   int // maximum number of characters in a user ID
+}
+
+// DefaultLimits returns the limits used by the server if none are specified.
+func DefaultLimits() Limits {
+	return Limits{
+		MaxCodeLength:     DefaultMaxCodeLength,
+		MaxLanguageCodes:  DefaultMaxLanguageCodes,
+		MaxStreamMessages: DefaultMaxStreamMessages,
+		MaxNameLength:     DefaultMaxNameLength,
+		MaxParams:         DefaultMaxParams,
+		MaxParamLength:    DefaultMaxParamLength,
+		MaxUserIDLength:   DefaultMaxUserIDLength,
+	}
+}
+
+// Language codes are loosely validated as BCP 47 tags: a 2-3 letter primary language
+// subtag followed by optional alphanumeric subtags separated by hyphens.
+var languageCode = regexp.MustCompile(`^[A-Za-z]{2,3}(-[A-Za-z0-9]{1,8})*$`)
+
+// Contexts are the same application specific tags that catalog variants are tagged with.
+var contextName = regexp.MustCompile(`^[A-Za-z0-9_.-]{0,64}$`)
+
+// A rule checks a single field value and returns a description of the violation, or
+// an empty string if the value is valid.
+type rule func(l Limits, v protoreflect.Value) string
+
+// A listRule checks the length of a repeated field.
+type listRule func(l Limits, n int) string
+
+// Describes the rules for a single field of a message. List rules apply to the length
+// of a repeated or map field while item rules apply to the value of a singular field,
+// to each element of a repeated field or to each value of a map field. Key rules apply
+// to each key of a map field.
+type field struct {
+	name  protoreflect.Name
+	list  []listRule
+	keys  []rule
+	items []rule
+}
+
+// The validation schema for each of the Hello request messages; messages that are not
+// in the schema are not validated.
+var schema = map[protoreflect.FullName][]field{
+	"hello.HelloRequest": {
+		{name: "iso_language_code", items: []rule{required, codeLength, codeSyntax}},
+		{name: "name", items: []rule{nameLength, printable}},
+		{name: "params", list: []listRule{maxParams}, keys: []rule{paramSyntax}, items: []rule{paramLength, printable}},
+		{name: "register", items: []rule{register}},
+		{name: "timezone", items: []rule{timezone}},
+		{name: "context", items: []rule{contextSyntax}},
+		{name: "selection", items: []rule{selection}},
+		{name: "user_id", items: []rule{userIDLength, printable}},
+	},
+	"hello.HelloManyRequest": {
+		{name: "iso_language_codes", list: []listRule{maxCodes}, items: []rule{required, codeLength, codeSyntax}},
+		{name: "name", items: []rule{nameLength, printable}},
+		{name: "params", list: []listRule{maxParams}, keys: []rule{paramSyntax}, items: []rule{paramLength, printable}},
+		{name: "register", items: []rule{register}},
+		{name: "timezone", items: []rule{timezone}},
+		{name: "context", items: []rule{contextSyntax}},
+		{name: "selection", items: []rule{selection}},
+		{name: "user_id", items: []rule{userIDLength, printable}},
+	},
+}
+
+// Message validates a request message against the schema, returning an InvalidArgument
+// status error describing every field violation or nil if the message is valid.
+func (l Limits) Message(msg proto.Message) error {
+	if msg == nil {
+		return nil
+	}
+
+	m := msg.ProtoReflect()
+	fields, ok := schema[m.Descriptor().FullName()]
+	if !ok {
+		return nil
+	}
+
+	var violations []*errdetails.BadRequest_FieldViolation
+	for _, f := range fields {
+		fd := m.Descriptor().Fields().ByName(f.name)
+		if fd == nil {
+			panic(fmt.Errorf("validation schema references unknown field %s.%s", m.Descriptor().FullName(), f.name))
+		}
+
+		if fd.IsMap() {
+			violations = append(violations, l.checkMap(f, m.Get(fd).Map())...)
+			continue
+		}
+
+		if fd.IsList() {
+			list := m.Get(fd).List()
+			for _, check := range f.list {
+				if desc := check(l, list.Len()); desc != "" {
+					violations = append(violations, violation(string(f.name), desc))
+				}
+			}
+
+			for i := 0; i < list.Len(); i++ {
+				violations = append(violations, l.check(fmt.Sprintf("%s[%d]", f.name, i), list.Get(i), f.items)...)
+			}
+			continue
+		}
+
+		violations = append(violations, l.check(string(f.name), m.Get(fd), f.items)...)
+	}
+
+	return invalid(violations...)
+}
+
+// IsLanguageCode returns true if the code is a syntactically valid language code, e.g.
+// for checking the language codes in a catalog.
+func IsLanguageCode(code string) bool {
+	return languageCode.MatchString(code)
+}
+
+// Stream checks that the number of messages received on a stream is within limits;
+// n is the number of messages received so far including the current one.
+func (l Limits) Stream(n int) error {
+	if l.MaxStreamMessages > 0 && n > l.MaxStreamMessages {
+		return invalid(violation("stream", fmt.Sprintf("at most %d messages may be sent on a stream", l.MaxStreamMessages)))
+	}
+	return nil
+}
+
+// Apply the rules to a value, only the first violation for the value is reported.
+func (l Limits) check(name string, v protoreflect.Value, rules []rule) []*errdetails.BadRequest_FieldViolation {
+	for _, check := range rules {
+		if desc := check(l, v); desc != "" {
+			return []*errdetails.BadRequest_FieldViolation{violation(name, desc)}
+		}
+	}
+	return nil
+}
+
+// Apply the rules of a field to the length, keys and values of a map in key order.
+func (l Limits) checkMap(f field, m protoreflect.Map) (violations []*errdetails.BadRequest_FieldViolation) {
+	for _, check := range f.list {
+		if desc := check(l, m.Len()); desc != "" {
+			violations = append(violations, violation(string(f.name), desc))
+		}
+	}
+
+	keys := make([]protoreflect.MapKey, 0, m.Len())
+	m.Range(func(key protoreflect.MapKey, _ protoreflect.Value) bool {
+		keys = append(keys, key)
+		return true
+	})
+	sort.Slice(keys, func(i, j int) bool { return keys[i].String() < keys[j].String() })
+
+	for _, key := range keys {
+		name := fmt.Sprintf("%s[%s]", f.name, key.String())
+		if v := l.check(name, key.Value(), f.keys); v != nil {
+			violations = append(violations, v...)
+			continue
+		}
+		violations = append(violations, l.check(name, m.Get(key), f.items)...)
+	}
+	return violations
+}
+
+func required(_ Limits, v protoreflect.Value) string {
+	if v.String() == "" {
+		return "value is required"
+	}
+	return ""
+}
+
+func codeLength(l Limits, v protoreflect.Value) string {
+	if l.MaxCodeLength > 0 && len(v.String()) > l.MaxCodeLength {
+		return fmt.Sprintf("language code must be at most %d characters", l.MaxCodeLength)
+	}
+	return ""
+}
+
+func codeSyntax(_ Limits, v protoreflect.Value) string {
+	if !languageCode.MatchString(v.String()) {
+		return "value is not a valid language code"
+	}
+	return ""
+}
+
+func nameLength(l Limits, v protoreflect.Value) string {
+	if l.MaxNameLength > 0 && utf8.RuneCountInString(v.String()) > l.MaxNameLength {
+		return fmt.Sprintf("name must be at most %d characters", l.MaxNameLength)
+	}
+	return ""
+}
+
+func paramSyntax(_ Limits, v protoreflect.Value) string {
+	// Parameter names are the names of the arguments of greeting templates
+	if !msgfmt.IsParamName(v.String()) {
+		return "parameter name must be a letter or underscore followed by letters, digits or underscores"
+	}
+	return ""
+}
+
+func paramLength(l Limits, v protoreflect.Value) string {
+	if l.MaxParamLength > 0 && utf8.RuneCountInString(v.String()) > l.MaxParamLength {
+		return fmt.Sprintf("parameter must be at most %d characters", l.MaxParamLength)
+	}
+	return ""
+}
+
+// Values that are inserted into greetings must not contain control characters or
+// bidirectional formatting characters that could be used to disguise the text of the
+// greeting. Joiners are allowed since they are required to write some names.
+func printable(_ Limits, v protoreflect.Value) string {
+	for _, r := range v.String() {
+		if unicode.IsControl(r) || unicode.Is(unicode.Bidi_Control, r) {
+			return "value must not contain control or bidirectional formatting characters"
+		}
+	}
+	return ""
+}
+
+func register(_ Limits, v protoreflect.Value) string {
+	switch v.String() {
+	case "", "formal", "informal":
+		return ""
+	default:
+		return "register must be formal or informal"
+	}
+}
+
+func timezone(_ Limits, v protoreflect.Value) string {
+	if tz := v.String(); tz != "" {
+		if _, err := time.LoadLocation(tz); err != nil {
+			return "value is not a valid IANA timezone"
+		}
+	}
+	return ""
+}
+
+func contextSyntax(_ Limits, v protoreflect.Value) string {
+	if !contextName.MatchString(v.String()) {
+		return "context must be at most 64 letters, digits, '.', '_' or '-'"
+	}
+	return ""
+}
+
+func selection(_ Limits, v protoreflect.Value) string {
+	switch v.String() {
+	case "", "deterministic", "round-robin", "random":
+		return ""
+	default:
+		return "selection must be deterministic, round-robin or random"
+	}
+}
+
+func userIDLength(l Limits, v protoreflect.Value) string {
+	if l.MaxUserIDLength > 0 && utf8.RuneCountInString(v.String()) > l.MaxUserIDLength {
+		return fmt.Sprintf("user ID must be at most %d characters", l.MaxUserIDLength)
+	}
+	return ""
+}
+
+func maxParams(l Limits, n int) string {
+	if l.MaxParams > 0 && n > l.MaxParams {
+		return fmt.Sprintf("at most %d parameters may be specified", l.MaxParams)
+	}
+	return ""
+}
+
+func maxCodes(l Limits, n int) string {
+	if l.MaxLanguageCodes > 0 && n > l.MaxLanguageCodes {
+		return fmt.Sprintf("at most %d language codes may be requested", l.MaxLanguageCodes)
+	}
+	return ""
+}
+
+func violation(field, desc string) *errdetails.BadRequest_FieldViolation {
+	return &errdetails.BadRequest_FieldViolation{
+		Field:       field,
+		Description: desc,
+	}
+}
+
+// Create an InvalidArgument status error with the violations attached as details.
+func invalid(violations ...*errdetails.BadRequest_FieldViolation) error {
+	if len(violations) == 0 {
+		return nil
+	}
+
+	msg := fmt.Sprintf("invalid %s: %s", violations[0].Field, violations[0].Description)
+	if len(violations) > 1 {
+		msg = fmt.Sprintf("%s (and %d more violations)", msg, len(violations)-1)
+	}
+
+	st, err := status.New(codes.InvalidArgument, msg).WithDetails(&errdetails.BadRequest{FieldViolations: violations})
+	if err != nil {
+		return status.Error(codes.InvalidArgument, msg)
+	}
+	return st.Err()
+}
@@ -0,0 +1,122 @@
+package validate_test
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/pdeziel/grpc-example/pb"
+	"github.com/pdeziel/grpc-example/validate"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/proto"
+)
+
+func TestMessage(t *testing.T) {
+	limits := validate.Limits{
+		MaxCodeLength:    8,
+		MaxLanguageCodes: 3,
+		MaxNameLength:    100,
+		MaxParams:        2,
+		MaxParamLength:   256,
+		MaxUserIDLength:  8,
+	}
+
+	testCases := []struct {
+		msg    proto.Message
+		fields []string
+	}{
+		{&pb.HelloRequest{IsoLanguageCode: "en"}, nil},
+		{&pb.HelloRequest{IsoLanguageCode: "zh-Hant"}, nil},
+		{&pb.HelloRequest{IsoLanguageCode: "fil"}, nil},
+		{&pb.HelloRequest{}, []string{"iso_language_code"}},
+		{&pb.HelloRequest{IsoLanguageCode: "e"}, []string{"iso_language_code"}},
+		{&pb.HelloRequest{IsoLanguageCode: "en_US"}, []string{"iso_language_code"}},
+		{&pb.HelloRequest{IsoLanguageCode: "en-"}, []string{"iso_language_code"}},
+		{&pb.HelloRequest{IsoLanguageCode: strings.Repeat("a", 1024)}, []string{"iso_language_code"}},
+		{&pb.HelloManyRequest{}, nil},
+		{&pb.HelloManyRequest{IsoLanguageCodes: []string{"en", "fr", "es"}}, nil},
+		{&pb.HelloManyRequest{IsoLanguageCodes: []string{"en", "", "es", "x"}}, []string{"iso_language_codes", "iso_language_codes[1]", "iso_language_codes[3]"}},
+		{&pb.HelloRequest{IsoLanguageCode: "fr", Name: "Zoë", Params: map[string]string{"gender": "female", "title_2": "Dr"}}, nil},
+		{&pb.HelloRequest{IsoLanguageCode: "fa", Name: "\u0645\u0647\u200c\u0633\u0627"}, nil},
+		{&pb.HelloRequest{IsoLanguageCode: "fr", Name: strings.Repeat("é", 101)}, []string{"name"}},
+		{&pb.HelloRequest{IsoLanguageCode: "fr", Name: "Bob\n"}, []string{"name"}},
+		{&pb.HelloRequest{IsoLanguageCode: "fr", Name: "\u202eboB"}, []string{"name"}},
+		{&pb.HelloRequest{IsoLanguageCode: "fr", Params: map[string]string{"2x": "a", "ok": strings.Repeat("a", 257), "a-b": "c", "bell": "\a"}}, []string{"params", "params[2x]", "params[a-b]", "params[bell]", "params[ok]"}},
+		{&pb.HelloManyRequest{IsoLanguageCodes: []string{"en"}, Name: "\x00"}, []string{"name"}},
+		{&pb.HelloManyRequest{Params: map[string]string{"a": "1", "b": "2", "c": "3"}}, []string{"params"}},
+		{&pb.HelloRequest{IsoLanguageCode: "de", Register: "formal", Timezone: "Europe/Berlin", Context: "email"}, nil},
+		{&pb.HelloRequest{IsoLanguageCode: "de", Register: "polite", Timezone: "Mars/Olympus", Context: "e mail"}, []string{"register", "timezone", "context"}},
+		{&pb.HelloRequest{IsoLanguageCode: "de", Timezone: "../etc/passwd"}, []string{"timezone"}},
+		{&pb.HelloManyRequest{Register: "informal", Timezone: "UTC", Context: strings.Repeat("x", 65)}, []string{"context"}},
+		{&pb.HelloRequest{IsoLanguageCode: "ja", Selection: "round-robin", UserId: "u-42"}, nil},
+		{&pb.HelloRequest{IsoLanguageCode: "ja", Selection: "shuffle", UserId: "user-12345"}, []string{"selection", "user_id"}},
+		{&pb.HelloManyRequest{Selection: "deterministic", UserId: "u\t1"}, []string{"user_id"}},
+		{&pb.HelloReply{}, nil},
+	}
+
+	for i, tc := range testCases {
+		err := limits.Message(tc.msg)
+		if len(tc.fields) == 0 {
+			require.NoError(t, err, "test case %d failed", i)
+			continue
+		}
+
+		st, ok := status.FromError(err)
+		require.True(t, ok, "test case %d: expected a status error", i)
+		require.Equal(t, codes.InvalidArgument, st.Code(), "test case %d failed", i)
+		require.Equal(t, tc.fields, violations(t, st), "test case %d failed", i)
+	}
+}
+
+func TestDefaultLimits(t *testing.T) {
+	limits := validate.DefaultLimits()
+
+	langs := make([]string, validate.DefaultMaxLanguageCodes)
+	for i := range langs {
+		langs[i] = "en"
+	}
+	require.NoError(t, limits.Message(&pb.HelloManyRequest{IsoLanguageCodes: langs}))
+
+	langs = append(langs, "fr")
+	require.Error(t, limits.Message(&pb.HelloManyRequest{IsoLanguageCodes: langs}))
+
+	// A zero value limit is not enforced
+	require.NoError(t, validate.Limits{}.Message(&pb.HelloManyRequest{IsoLanguageCodes: langs}))
+}
+
+func TestIsLanguageCode(t *testing.T) {
+	for _, code := range []string{"en", "fil", "zh-Hant", "de-AT", "sr-Latn-RS"} {
+		require.True(t, validate.IsLanguageCode(code), "expected %q to be valid", code)
+	}
+
+	for _, code := range []string{"", "e", "english", "en_US", "en-", "-en", "fr CA"} {
+		require.False(t, validate.IsLanguageCode(code), "expected %q to be invalid", code)
+	}
+}
+
+func TestStream(t *testing.T) {
+	limits := validate.Limits{MaxStreamMessages: 2}
+	require.NoError(t, limits.Stream(1))
+	require.NoError(t, limits.Stream(2))
+
+	st, ok := status.FromError(limits.Stream(3))
+	require.True(t, ok, "expected a status error")
+	require.Equal(t, codes.InvalidArgument, st.Code())
+	require.Equal(t, []string{"stream"}, violations(t, st))
+
+	require.NoError(t, validate.Limits{}.Stream(1e6))
+}
+
+func violations(t *testing.T, st *status.Status) []string {
+	fields := make([]string, 0)
+	for _, detail := range st.Details() {
+		if br, ok := detail.(*errdetails.BadRequest); ok {
+			for _, v := range br.FieldViolations {
+				fields = append(fields, v.Field)
+			}
+		}
+	}
+	return fields
+}
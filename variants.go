@@ -0,0 +1,261 @@
+package hello
+
+import (
+	"hash/fnv"
+	"math/rand"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/pdeziel/grpc-example/catalog"
+	"github.com/pdeziel/grpc-example/msgfmt"
+)
+
+// Query describes the variant of a greeting that best suits a request. An empty field
+// means that the request has no preference for that tag.
+type Query struct {
+	Register  string // formal or informal
+	TimeOfDay string // morning, afternoon, evening or night
+	Context   string // an application specific context such as email
+
+	// How to choose among the variants that suit the query equally well; the user ID
+	// seeds the deterministic selection.
+	Selection Selection
+	UserID    string
+}
+
+// Selection is the strategy used to choose among the variants of a greeting that suit
+// a query equally well, e.g. to show a different greeting on each onboarding screen.
+// Every strategy other than SelectFirst takes the weights of the variants into account.
+type Selection string
+
+const (
+	SelectFirst         Selection = ""              // the first of the variants in the catalog
+	SelectDeterministic Selection = "deterministic" // always the same variant for the user ID
+	SelectRoundRobin    Selection = "round-robin"   // rotate through the variants
+	SelectRandom        Selection = "random"        // a random variant
+)
+
+// The state of the round-robin and random selections. It is separate from the lock on
+// the messages so that choosing a variant does not block readers of the catalog.
+type selector struct {
+	sync.Mutex
+	rand  *rand.Rand
+	turns map[string]uint64
+}
+
+// The priority of each tag when selecting a variant. A matching context outweighs a
+// matching register, which outweighs a matching time of day, and since the priorities
+// are powers of two a single tag is never outweighed by the tags of lower priority.
+const (
+	contextPriority  = 4
+	registerPriority = 2
+	timePriority     = 1
+)
+
+// Select the greeting for the language that best suits the query. Variants are ranked
+// by, in order:
+//
+//  1. the fewest tags that conflict with the query, e.g. a formal variant for an
+//     informal request, so that an untagged variant is preferred over a wrong one;
+//  2. the most tags that match the query;
+//  3. the fewest tags that the query has no preference for, so that the default
+//     (untagged) greeting is used for a request without preferences.
+//
+// The selection strategy of the query then chooses among the highest ranked variants,
+// by default the first of them in the catalog so that the same request always gets
+// the same greeting. Aliases of the language code, e.g. the deprecated iw for Hebrew,
+// select the greeting of the canonical code.
+func (m *Messages) Select(lang string, q Query) (_ string, err error) {
+	m.RLock()
+	defer m.RUnlock()
+
+	var i int
+	if lang, i, err = m.selectVariant(lang, q); err != nil {
+		return "", err
+	}
+	return m.messages[lang][i].Greeting, nil
+}
+
+// Select the template of the greeting for the language that best suits the query, as
+// parsed when the catalog was loaded.
+func (m *Messages) template(lang string, q Query) (_ *msgfmt.Message, err error) {
+	m.RLock()
+	defer m.RUnlock()
+
+	var i int
+	if lang, i, err = m.selectVariant(lang, q); err != nil {
+		return nil, err
+	}
+	return m.templates[lang][i], nil
+}
+
+// Returns the canonical language code and the index of the selected variant; the
+// caller must hold the read lock.
+func (m *Messages) selectVariant(lang string, q Query) (_ string, _ int, err error) {
+	lang, _ = Canonical(lang)
+	variants, ok := m.messages[lang]
+	if !ok || len(variants) == 0 {
+		return "", 0, ErrLanguageNotFound
+	}
+	return lang, m.choose(lang, variants, bestVariants(variants, q), q), nil
+}
+
+// Seed the random selection so that the sequence of random greetings is reproducible,
+// e.g. in tests. Seeding also restarts the round-robin rotations.
+func (m *Messages) Seed(seed int64) {
+	m.selector.Lock()
+	defer m.selector.Unlock()
+	m.selector.rand = rand.New(rand.NewSource(seed))
+	m.selector.turns = nil
+}
+
+// The indices of the highest ranked variants for the query in catalog order.
+func bestVariants(variants []catalog.Variant, q Query) (best []int) {
+	var top variantScore
+	for i, v := range variants {
+		s := scoreVariant(v, q)
+		switch {
+		case i == 0 || s.better(top):
+			best, top = []int{i}, s
+		case !top.better(s):
+			best = append(best, i)
+		}
+	}
+	return best
+}
+
+// Choose one of the equally ranked variants, given by their indices, using the
+// selection strategy of the query and return its index. The weights of the variants
+// divide the range [0, total) into consecutive intervals and the variant is the one
+// whose interval contains n.
+func (m *Messages) choose(lang string, variants []catalog.Variant, best []int, q Query) int {
+	if len(best) == 1 {
+		return best[0]
+	}
+
+	var total uint64
+	for _, i := range best {
+		total += uint64(variants[i].Weighted())
+	}
+
+	var n uint64
+	switch q.Selection {
+	case SelectDeterministic:
+		hash := fnv.New64a()
+		hash.Write([]byte(q.UserID))
+		hash.Write([]byte{0})
+		hash.Write([]byte(lang))
+		n = hash.Sum64()
+	case SelectRoundRobin:
+		// Each set of equally ranked variants has its own rotation. The rotations are
+		// keyed only by the catalog rather than by the query, which clients choose, so
+		// the number of rotations is bounded by the variants in the catalog.
+		n = m.selector.turn(rotationKey(lang, best))
+	case SelectRandom:
+		n = m.selector.random(total)
+	default:
+		return best[0]
+	}
+
+	n %= total
+	for _, i := range best {
+		weight := uint64(variants[i].Weighted())
+		if n < weight {
+			return i
+		}
+		n -= weight
+	}
+	return best[len(best)-1]
+}
+
+// The key of the rotation of a set of variants of the language, e.g. "fr|0,2".
+func rotationKey(lang string, best []int) string {
+	indices := make([]string, 0, len(best))
+	for _, i := range best {
+		indices = append(indices, strconv.Itoa(i))
+	}
+	return lang + "|" + strings.Join(indices, ",")
+}
+
+// Returns the number of previous turns of the rotation.
+func (s *selector) turn(key string) (n uint64) {
+	s.Lock()
+	defer s.Unlock()
+
+	if s.turns == nil {
+		s.turns = make(map[string]uint64)
+	}
+
+	n = s.turns[key]
+	s.turns[key]++
+	return n
+}
+
+// Returns a random number in [0, n).
+func (s *selector) random(n uint64) uint64 {
+	s.Lock()
+	defer s.Unlock()
+
+	if s.rand == nil {
+		s.rand = rand.New(rand.NewSource(time.Now().UnixNano()))
+	}
+	return uint64(s.rand.Int63n(int64(n)))
+}
+
+type variantScore struct {
+	conflict int
+	match    int
+	extra    int
+}
+
+func scoreVariant(v catalog.Variant, q Query) (s variantScore) {
+	tags := []struct {
+		tag, query string
+		priority   int
+	}{
+		{v.Context, q.Context, contextPriority},
+		{v.Register, q.Register, registerPriority},
+		{v.TimeOfDay, q.TimeOfDay, timePriority},
+	}
+
+	for _, t := range tags {
+		switch {
+		case t.tag == "":
+		case t.query == "":
+			s.extra += t.priority
+		case t.tag == t.query:
+			s.match += t.priority
+		default:
+			s.conflict += t.priority
+		}
+	}
+	return s
+}
+
+// Returns true if the score ranks strictly higher than the other score.
+func (s variantScore) better(o variantScore) bool {
+	if s.conflict != o.conflict {
+		return s.conflict < o.conflict
+	}
+	if s.match != o.match {
+		return s.match > o.match
+	}
+	return s.extra < o.extra
+}
+
+// TimeOfDay returns the time of day of t in its location: morning from 5:00, afternoon
+// from 12:00, evening from 17:00 and night from 22:00.
+func TimeOfDay(t time.Time) string {
+	switch hour := t.Hour(); {
+	case hour >= 5 && hour < 12:
+		return catalog.Morning
+	case hour >= 12 && hour < 17:
+		return catalog.Afternoon
+	case hour >= 17 && hour < 22:
+		return catalog.Evening
+	default:
+		return catalog.Night
+	}
+}